@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/rand"
 	"fmt"
 	"log"
 	"time"
@@ -13,7 +12,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Полное тестирование Triple DES (DES-EDE3) ===\n")
+	fmt.Println("=== Полное тестирование Triple DES (DES-EDE3) ===")
+	fmt.Println()
 
 	// Создаем три независимых экземпляра DES для Triple DES
 	des1 := des.NewDES()
@@ -53,13 +53,11 @@ func main() {
 	fmt.Printf("Decrypted block: %x\n", decryptedBlock)
 	fmt.Printf("Match: %t\n\n", bytes.Equal(plaintextBlock, decryptedBlock))
 
-	// Генерируем IV
-	iv := make([]byte, tripleDesCipher.BlockSize())
-	if _, err := rand.Read(iv); err != nil {
-		log.Fatalf("Ошибка генерации IV: %v", err)
-	}
+	// IV не задаётся: контекст генерирует случайный IV для каждого сообщения
+	// и записывает его перед шифртекстом, поэтому сообщения не делят один IV
 
-	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===\n")
+	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===")
+	fmt.Println()
 
 	modes := []core.CipherMode{
 		core.ECB,
@@ -92,7 +90,7 @@ func main() {
 		for _, padding := range paddings {
 			fmt.Printf("Testing %v + %v... ", mode, padding)
 
-			ctx := core.NewCipherContext(tripleDesCipher, mode, padding, iv)
+			ctx := core.NewCipherContext(tripleDesCipher, mode, padding, nil)
 
 			startEnc := time.Now()
 			ciphertext, err := ctx.Encrypt(plaintext)
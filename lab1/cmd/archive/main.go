@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/archive"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+const usage = `Использование:
+  archive create  -key HEX [-cipher des] [-mode CBC] [-padding PKCS7] [-compress] -o ARCHIVE DIR
+  archive list    -key HEX ARCHIVE
+  archive extract -key HEX [-C DIR] ARCHIVE [MEMBER...]
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "create":
+		err = create(os.Args[2:])
+	case "list":
+		err = list(os.Args[2:])
+	case "extract":
+		err = extract(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func create(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	keyHex := fs.String("key", "", "ключ в hex")
+	cipherName := fs.String("cipher", "des", "шифр: "+strings.Join(registry.Names(), ", "))
+	modeName := fs.String("mode", "CBC", "режим шифрования (CBC, PCBC, CFB, OFB, CTR, RandomDelta)")
+	paddingName := fs.String("padding", "PKCS7", "режим паддинга (ANSIX923, PKCS7, ISO10126)")
+	compress := fs.Bool("compress", false, "сжимать файлы перед шифрованием")
+	output := fs.String("o", "", "путь к создаваемому архиву")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *output == "" {
+		return fmt.Errorf("create: нужны -o ARCHIVE и один каталог\n%s", usage)
+	}
+	key, err := parseKey(*keyHex)
+	if err != nil {
+		return err
+	}
+	mode, err := core.ParseCipherMode(*modeName)
+	if err != nil {
+		return err
+	}
+	padding, err := core.ParsePaddingMode(*paddingName)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	w, err := archive.NewWriter(out, key, archive.Options{
+		Cipher:   *cipherName,
+		Mode:     mode,
+		Padding:  padding,
+		Compress: *compress,
+	})
+	if err == nil {
+		err = w.AddDir(fs.Arg(0))
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*output)
+		return err
+	}
+
+	fmt.Printf("Архив %s создан\n", *output)
+	return nil
+}
+
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	keyHex := fs.String("key", "", "ключ в hex")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("list: нужен путь к архиву\n%s", usage)
+	}
+	key, err := parseKey(*keyHex)
+	if err != nil {
+		return err
+	}
+	f, err := archive.OpenFile(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	opts := f.Options()
+	fmt.Printf("Шифр: %s, режим: %s, паддинг: %s, сжатие: %t\n\n", opts.Cipher, opts.Mode, opts.Padding, opts.Compress)
+	for _, e := range f.Entries() {
+		fmt.Printf("%s %10d %s %s\n", e.Mode, e.Size, e.ModTime.Local().Format("2006-01-02 15:04:05"), e.Name)
+	}
+	return nil
+}
+
+func extract(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	keyHex := fs.String("key", "", "ключ в hex")
+	dir := fs.String("C", ".", "каталог для извлечения")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("extract: нужен путь к архиву\n%s", usage)
+	}
+	key, err := parseKey(*keyHex)
+	if err != nil {
+		return err
+	}
+	f, err := archive.OpenFile(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	members := fs.Args()[1:]
+	if len(members) == 0 {
+		return f.ExtractAll(*dir)
+	}
+	for _, name := range members {
+		if err := f.Extract(name, *dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("не задан ключ (-key)")
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключа: %v", err)
+	}
+	return key, nil
+}
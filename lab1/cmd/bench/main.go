@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/bench"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+func main() {
+	ciphers := flag.String("ciphers", "all", "шифры через запятую или all: "+strings.Join(bench.CipherNames(), ", "))
+	modes := flag.String("modes", "all", "режимы через запятую или all")
+	paddings := flag.String("paddings", "PKCS7", "паддинги через запятую или all")
+	workers := flag.String("workers", "0", "число воркеров файлового конвейера через запятую (0 — по числу CPU)")
+	sizes := flag.String("sizes", "1KiB,16KiB", "размеры данных через запятую (например 512,64KiB,16MiB)")
+	apis := flag.String("api", "memory,file", "API через запятую: memory, file")
+	minTime := flag.Duration("time", bench.DefaultMinTime, "минимальное время измерения одной операции")
+	jsonPath := flag.String("json", "", "записать отчёт в JSON-файл")
+	mdPath := flag.String("md", "", "записать Markdown-таблицу в файл (по умолчанию — в stdout)")
+	comparePath := flag.String("compare", "", "сравнить с базовым JSON-отчётом и завершиться с ошибкой при регрессии")
+	tolerance := flag.Float64("tolerance", 0.10, "допустимое замедление относительно базового отчёта (доля)")
+	flag.Parse()
+
+	matrix, err := parseMatrix(*ciphers, *modes, *paddings, *workers, *sizes, *apis)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	total := len(matrix.Cases()) * 2
+	done := 0
+	report, err := bench.Run(matrix, bench.Options{
+		MinTime: *minTime,
+		Progress: func(r bench.Result) {
+			done++
+			status := fmt.Sprintf("%.2f MB/s", r.MBPerSec)
+			if r.Err != "" {
+				status = "error: " + r.Err
+			}
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s\n", done, total, r.Key(), status)
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *jsonPath != "" {
+		if err := writeFile(*jsonPath, func(f *os.File) error { return bench.WriteJSON(f, report) }); err != nil {
+			log.Fatalf("Ошибка записи JSON: %v", err)
+		}
+	}
+	if *mdPath != "" {
+		if err := writeFile(*mdPath, func(f *os.File) error { return bench.WriteMarkdown(f, report) }); err != nil {
+			log.Fatalf("Ошибка записи Markdown: %v", err)
+		}
+	} else if err := bench.WriteMarkdown(os.Stdout, report); err != nil {
+		log.Fatal(err)
+	}
+
+	if *comparePath != "" {
+		f, err := os.Open(*comparePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		base, err := bench.ReadJSON(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		regressions, err := bench.Compare(base, report, *tolerance)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(regressions) == 0 {
+			fmt.Fprintf(os.Stderr, "Регрессий больше %.0f%% нет\n", *tolerance*100)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Регрессии (больше %.0f%%):\n", *tolerance*100)
+		for _, r := range regressions {
+			fmt.Fprintf(os.Stderr, "  %s: %d -> %d ns/op (+%.1f%%)\n", r.Key, r.BaseNs, r.Current, r.Slowdown*100)
+		}
+		os.Exit(1)
+	}
+}
+
+func parseMatrix(ciphers, modes, paddings, workers, sizes, apis string) (bench.Matrix, error) {
+	var m bench.Matrix
+
+	if ciphers != "all" {
+		m.Ciphers = splitList(ciphers)
+	}
+
+	if modes == "all" {
+		for mode := core.ECB; mode <= core.RandomDelta; mode++ {
+			m.Modes = append(m.Modes, mode)
+		}
+	} else {
+		for _, name := range splitList(modes) {
+			mode, err := core.ParseCipherMode(name)
+			if err != nil {
+				return m, err
+			}
+			m.Modes = append(m.Modes, mode)
+		}
+	}
+
+	if paddings == "all" {
+		for p := core.PadZeros; p <= core.PadISO10126; p++ {
+			m.Paddings = append(m.Paddings, p)
+		}
+	} else {
+		for _, name := range splitList(paddings) {
+			p, err := core.ParsePaddingMode(name)
+			if err != nil {
+				return m, err
+			}
+			m.Paddings = append(m.Paddings, p)
+		}
+	}
+
+	for _, s := range splitList(workers) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return m, fmt.Errorf("invalid worker count %q", s)
+		}
+		m.Workers = append(m.Workers, n)
+	}
+
+	for _, s := range splitList(sizes) {
+		n, err := bench.ParseSize(s)
+		if err != nil {
+			return m, err
+		}
+		m.Sizes = append(m.Sizes, n)
+	}
+
+	for _, s := range splitList(apis) {
+		api := bench.API(s)
+		if api != bench.APIMemory && api != bench.APIFile {
+			return m, fmt.Errorf("unknown API %q", s)
+		}
+		m.APIs = append(m.APIs, api)
+	}
+	return m, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
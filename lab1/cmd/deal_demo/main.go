@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/rand"
 	"fmt"
 	"log"
 	"time"
@@ -13,7 +12,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Полное тестирование DEAL ===\n")
+	fmt.Println("=== Полное тестирование DEAL ===")
+	fmt.Println()
 
 	desCipher := des.NewDES()
 	dealCipher := deal.NewDEAL128(desCipher)
@@ -50,13 +50,11 @@ func main() {
 	fmt.Printf("Decrypted block: %x\n", decryptedBlock)
 	fmt.Printf("Match: %t\n\n", bytes.Equal(plaintextBlock, decryptedBlock))
 
-	// Генерируем IV
-	iv := make([]byte, dealCipher.BlockSize())
-	if _, err := rand.Read(iv); err != nil {
-		log.Fatalf("Ошибка генерации IV: %v", err)
-	}
+	// IV не задаётся: контекст генерирует случайный IV для каждого сообщения
+	// и записывает его перед шифртекстом, поэтому сообщения не делят один IV
 
-	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===\n")
+	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===")
+	fmt.Println()
 
 	modes := []core.CipherMode{
 		core.ECB,
@@ -89,7 +87,7 @@ func main() {
 		for _, padding := range paddings {
 			fmt.Printf("Testing %v + %v... ", mode, padding)
 
-			ctx := core.NewCipherContext(dealCipher, mode, padding, iv)
+			ctx := core.NewCipherContext(dealCipher, mode, padding, nil)
 
 			startEnc := time.Now()
 			ciphertext, err := ctx.Encrypt(plaintext)
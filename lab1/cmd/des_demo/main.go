@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -60,10 +59,8 @@ func main() {
 	fmt.Printf("Feistel DES ciphertext:  %x\n", ciphertextBlock)
 	fmt.Printf("Results match: %t\n\n", bytes.Equal(ciphertextOriginal, ciphertextBlock))
 
-	iv := make([]byte, 8)
-	if _, err := rand.Read(iv); err != nil {
-		log.Fatalf("IV generation failed: %v", err)
-	}
+	// IV не задаётся: контекст генерирует случайный IV для каждого сообщения
+	// и записывает его перед шифртекстом, поэтому сообщения не делят один IV
 
 	fmt.Println("=== Тестирование режимов шифрования с Feistel DES ===")
 	fmt.Println()
@@ -84,7 +81,7 @@ func main() {
 	for _, mode := range modes {
 		fmt.Printf("\n=== Testing %v ===\n", mode)
 
-		ctx := core.NewCipherContext(desCipher, mode, padding, iv)
+		ctx := core.NewCipherContext(desCipher, mode, padding, nil)
 
 		ciphertext, err := ctx.Encrypt(plaintext)
 		if err != nil {
@@ -114,7 +111,7 @@ func main() {
 
 	for _, padMode := range testPaddings {
 		fmt.Printf("\nPadding mode: %v\n", padMode)
-		ctx := core.NewCipherContext(desCipher, core.ECB, padMode, iv)
+		ctx := core.NewCipherContext(desCipher, core.ECB, padMode, nil)
 
 		ciphertext, err := ctx.Encrypt(plaintext)
 		if err != nil {
@@ -137,7 +134,7 @@ func main() {
 	if err := os.WriteFile("test_input.txt", testData, 0644); err != nil {
 		log.Printf("Failed to create test file: %v", err)
 	} else {
-		ctx := core.NewCipherContext(desCipher, core.CBC, core.PadPKCS7, iv)
+		ctx := core.NewCipherContext(desCipher, core.CBC, core.PadPKCS7, nil)
 
 		if err := ctx.EncryptFile("test_input.txt", "test_encrypted.bin"); err != nil {
 			log.Printf("File encryption failed: %v", err)
@@ -180,7 +177,7 @@ func main() {
 
 	outputPath := "./test_files/" + decryptedFile
 
-	ctx := core.NewCipherContext(desCipher, core.ECB, core.PadPKCS7, iv)
+	ctx := core.NewCipherContext(desCipher, core.ECB, core.PadPKCS7, nil)
 
 	info, err := os.Stat(inputPath)
 	if err != nil {
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/attack"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+	desfeistel "github.com/NikitaKoros/cryptography/lab1/internal/crypto/des/feistel"
+)
+
+func main() {
+	roundList := flag.String("rounds", "3,5,8", "числа раундов DES через запятую")
+	trials := flag.Int("trials", 8, "число случайных ключей на каждое число раундов")
+	span := flag.Int("span", 2, "N перебирается от |ε|^-2·2^-span до |ε|^-2·2^span")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "начальное значение генератора")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(*seed))
+	for _, field := range strings.Split(*roundList, ",") {
+		rounds, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			log.Fatalf("Ошибка разбора числа раундов: %v", err)
+		}
+		if err := experiment(rounds, *trials, *span, rng); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// experiment оценивает долю успехов Algorithm 1 и 2 для нескольких N
+func experiment(rounds, trials, span int, rng *rand.Rand) error {
+	a1, err := attack.BestApproximation(rounds, 0)
+	if err != nil {
+		return err
+	}
+	a2, err := attack.BestApproximation(rounds-1, 2)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("DES, %d раундов\n", rounds)
+	fmt.Printf("  Algorithm 1: %v\n", a1)
+	fmt.Printf("  Algorithm 2: %v + раунд %d\n", a2, rounds)
+
+	// Степени двойки вокруг |ε|^-2 приближения для Algorithm 2
+	center := int(math.Round(-2 * math.Log2(math.Abs(a2.Bias))))
+	var sizes []int
+	for e := max(center-span, 1); e <= center+span; e++ {
+		sizes = append(sizes, 1<<e)
+	}
+
+	success1 := make([]int, len(sizes))
+	success2 := make([]int, len(sizes))
+	for trial := 0; trial < trials; trial++ {
+		pairs, subkeys, err := knownPairs(rounds, sizes[len(sizes)-1], rng)
+		if err != nil {
+			return err
+		}
+		for i, n := range sizes {
+			lin := &attack.LinearAttack{Rounds: rounds, Approximation: &a1}
+			r1, err := lin.Algorithm1(pairs[:n])
+			if err != nil {
+				return err
+			}
+			if r1.KeyParity == a1.KeyParity(subkeys) {
+				success1[i]++
+			}
+
+			lin.Approximation = &a2
+			r2, err := lin.Algorithm2(pairs[:n])
+			if err != nil {
+				return err
+			}
+			if r2.Rank(subkey(subkeys[rounds-1])) == 0 && r2.KeyParity == a2.KeyParity(subkeys) {
+				success2[i]++
+			}
+		}
+	}
+
+	fmt.Printf("  %-10s %14s %14s %14s\n", "N", "Alg.1", "Alg.1 теория", "Alg.2")
+	for i, n := range sizes {
+		fmt.Printf("  2^%-8d %13.1f%% %13.1f%% %13.1f%%\n", int(math.Log2(float64(n))),
+			100*float64(success1[i])/float64(trials),
+			100*attack.LinearSuccessProbability(n, a1.Bias),
+			100*float64(success2[i])/float64(trials))
+	}
+	fmt.Println()
+	return nil
+}
+
+// knownPairs шифрует n случайных текстов DES на rounds раундах случайным ключом
+func knownPairs(rounds, n int, rng *rand.Rand) ([]attack.Pair, [][]byte, error) {
+	key := make([]byte, 8)
+	rng.Read(key)
+	cipher, err := desfeistel.NewDESFeistelRounds(rounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cipher.SetEncryptionKey(key); err != nil {
+		return nil, nil, err
+	}
+	pairs := make([]attack.Pair, n)
+	for i := range pairs {
+		plaintext := make([]byte, 8)
+		rng.Read(plaintext)
+		ciphertext, err := cipher.EncryptBlock(plaintext)
+		if err != nil {
+			return nil, nil, err
+		}
+		pairs[i] = attack.Pair{Plaintext: plaintext, Ciphertext: ciphertext}
+	}
+	subkeys, err := des.NewDESKeySchedule().ExpandKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pairs, subkeys, nil
+}
+
+func subkey(key []byte) uint64 {
+	var k uint64
+	for _, b := range key[:6] {
+		k = k<<8 | uint64(b)
+	}
+	return k
+}
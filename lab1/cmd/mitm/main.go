@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/attack"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+func main() {
+	cipherName := flag.String("cipher", "des-fast", "шифр с 64-битным блоком: "+strings.Join(registry.Names(), ", "))
+	bits := flag.Int("bits", 20, "неизвестных битов в каждом из ключей K1 и K2")
+	nPairs := flag.Int("pairs", 3, "число известных пар открытый текст/шифртекст")
+	partitionList := flag.String("partitions", "1,2,4", "числа частей прямой таблицы через запятую")
+	workers := flag.Int("workers", 0, "число горутин (0 — по числу CPU)")
+	flag.Parse()
+
+	desc, err := registry.Lookup(*cipherName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	newCipher := func() core.SymmetricCipher { return desc.New() }
+
+	// Известная часть ключей случайна, неизвестны младшие биты первых 8 байт
+	spaces := make([]attack.KeySpace, 2)
+	keys := make([][]byte, 2)
+	for i := range spaces {
+		base := make([]byte, desc.KeySize)
+		rand.Read(base)
+		if spaces[i], err = attack.NewKeySpace(base, attack.DESKeyBits(*bits)); err != nil {
+			log.Fatal(err)
+		}
+		index := make([]byte, 1)
+		rand.Read(index)
+		keys[i] = make([]byte, desc.KeySize)
+		spaces[i].Key(keys[i], uint64(index[0])*spaces[i].Size()/256)
+	}
+
+	pairs, err := doubleEncrypt(newCipher, keys[0], keys[1], *nPairs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Шифр: %s, неизвестных битов: %d + %d, пар: %d\n", desc.Name, *bits, *bits, *nPairs)
+	fmt.Printf("K1 = %x\nK2 = %x\n", keys[0], keys[1])
+	mitm := &attack.MITM{New: newCipher, K1: spaces[0], K2: spaces[1], Workers: *workers}
+	fmt.Printf("Ожидаемо ложных пар ключей: %.3g после 1 пары, %.3g после %d\n\n",
+		mitm.ExpectedFalsePositives(1), mitm.ExpectedFalsePositives(*nPairs), *nPairs)
+
+	fmt.Printf("%-6s %12s %12s %14s %14s %8s %8s %12s %12s\n",
+		"части", "записей", "память", "шифрований", "дешифрований", "совпад.", "ложных", "прямой", "обратный")
+	for _, field := range strings.Split(*partitionList, ",") {
+		partitions, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			log.Fatalf("Ошибка разбора числа частей: %v", err)
+		}
+		mitm.Partitions = partitions
+		res, err := mitm.Run(pairs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s := res.Stats
+		fmt.Printf("%-6d %12d %10.1fMB %14d %14d %8d %8d %12s %12s\n",
+			partitions, s.TableEntries, float64(s.TableBytes)/(1<<20), s.Encryptions, s.Decryptions,
+			s.Matches, s.FalsePositives, s.ForwardTime.Round(1e6), s.BackwardTime.Round(1e6))
+		for _, c := range res.Candidates {
+			fmt.Printf("       найдено: K1 = %x, K2 = %x\n", c.K1, c.K2)
+		}
+	}
+}
+
+// doubleEncrypt шифрует n случайных блоков ключом k1, затем k2
+func doubleEncrypt(newCipher func() core.SymmetricCipher, k1, k2 []byte, n int) ([]attack.Pair, error) {
+	c1, c2 := newCipher(), newCipher()
+	if err := c1.SetEncryptionKey(k1); err != nil {
+		return nil, err
+	}
+	if err := c2.SetEncryptionKey(k2); err != nil {
+		return nil, err
+	}
+	pairs := make([]attack.Pair, n)
+	for i := range pairs {
+		p := make([]byte, c1.BlockSize())
+		rand.Read(p)
+		mid, err := c1.EncryptBlock(p)
+		if err != nil {
+			return nil, err
+		}
+		c, err := c2.EncryptBlock(mid)
+		if err != nil {
+			return nil, err
+		}
+		pairs[i] = attack.Pair{Plaintext: p, Ciphertext: c}
+	}
+	return pairs, nil
+}
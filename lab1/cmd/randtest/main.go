@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+	"github.com/NikitaKoros/cryptography/lab1/internal/randomness"
+)
+
+func main() {
+	cipherName := flag.String("cipher", "des", "шифр: "+strings.Join(registry.Names(), ", "))
+	modeName := flag.String("mode", "CTR", "режим шифрования (ECB, CBC, PCBC, CFB, OFB, CTR, RandomDelta)")
+	paddingName := flag.String("padding", "Zeros", "режим паддинга (Zeros, ANSIX923, PKCS7, ISO10126)")
+	nBits := flag.Int("bits", 1_000_000, "длина проверяемой последовательности в битах")
+	alpha := flag.Float64("alpha", randomness.DefaultAlpha, "уровень значимости")
+	keyHex := flag.String("key", "", "ключ в hex (по умолчанию случайный)")
+	ivHex := flag.String("iv", "", "IV в hex (по умолчанию случайный)")
+	flag.Parse()
+
+	desc, err := registry.Lookup(*cipherName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode, err := core.ParseCipherMode(*modeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	padding, err := core.ParsePaddingMode(*paddingName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	key, err := hexOrRandom(*keyHex, desc.KeySize)
+	if err != nil {
+		log.Fatalf("Ошибка разбора ключа: %v", err)
+	}
+	iv, err := hexOrRandom(*ivHex, desc.BlockSize)
+	if err != nil {
+		log.Fatalf("Ошибка разбора IV: %v", err)
+	}
+
+	c, err := registry.NewCipher(desc.Name, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := core.NewCipherContext(c, mode, padding, iv)
+
+	fmt.Printf("Шифр: %s, режим: %s, паддинг: %s\n", desc.Name, mode, padding)
+	fmt.Printf("Ключ: %x\nIV:   %x\n", key, iv)
+	fmt.Printf("Длина последовательности: %d бит, α = %g\n\n", *nBits, *alpha)
+
+	results, err := randomness.EvaluateCipher(ctx, *nBits, randomness.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := 0
+	fmt.Printf("%-26s %-10s %-10s %s\n", "Тест", "min P", "Кол-во P", "Результат")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%-26s %-10s %-10s %s (%v)\n", r.Name, "-", "-", "НЕПРИМЕНИМ", r.Err)
+			continue
+		}
+		status := "OK"
+		if !r.Passed(*alpha) {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%-26s %-10.6f %-10d %s\n", r.Name, r.MinPValue(), len(r.PValues), status)
+	}
+
+	fmt.Printf("\nНе пройдено тестов: %d из %d\n", failed, len(results))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// hexOrRandom разбирает hex-строку заданной длины или генерирует случайные байты
+func hexOrRandom(s string, size int) ([]byte, error) {
+	if s == "" {
+		buf := make([]byte, size)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) != size {
+		return nil, fmt.Errorf("ожидалось %d байт, получено %d", size, len(buf))
+	}
+	return buf, nil
+}
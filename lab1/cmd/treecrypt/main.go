@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+	"github.com/NikitaKoros/cryptography/lab1/internal/tree"
+)
+
+const usage = `Использование:
+  treecrypt encrypt -key HEX [-cipher des] [-mode CBC] [-padding PKCS7] [-names] SRC DST
+  treecrypt decrypt -key HEX SRC DST
+  treecrypt verify  -key HEX SRC
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var (
+		stats tree.Stats
+		err   error
+	)
+	switch os.Args[1] {
+	case "encrypt":
+		stats, err = encrypt(os.Args[2:])
+		if err == nil {
+			fmt.Printf("Зашифровано: %d, без изменений: %d, удалено: %d\n", stats.Encrypted, stats.Unchanged, stats.Removed)
+		}
+	case "decrypt":
+		stats, err = decrypt(os.Args[2:], false)
+		if err == nil {
+			fmt.Printf("Расшифровано и проверено файлов: %d\n", stats.Decrypted)
+		}
+	case "verify":
+		stats, err = decrypt(os.Args[2:], true)
+		if err == nil {
+			fmt.Printf("Проверено файлов: %d, все совпадают с манифестом\n", stats.Decrypted)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func encrypt(args []string) (tree.Stats, error) {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	keyHex := fs.String("key", "", "ключ в hex")
+	cipherName := fs.String("cipher", "des", "шифр: "+strings.Join(registry.Names(), ", "))
+	modeName := fs.String("mode", "CBC", "режим шифрования (CBC, PCBC, CFB, OFB, CTR, RandomDelta)")
+	paddingName := fs.String("padding", "PKCS7", "режим паддинга (ANSIX923, PKCS7, ISO10126)")
+	names := fs.Bool("names", false, "шифровать имена файлов")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return tree.Stats{}, fmt.Errorf("encrypt: нужны исходный каталог и каталог назначения\n%s", usage)
+	}
+	key, err := parseKey(*keyHex)
+	if err != nil {
+		return tree.Stats{}, err
+	}
+	mode, err := core.ParseCipherMode(*modeName)
+	if err != nil {
+		return tree.Stats{}, err
+	}
+	padding, err := core.ParsePaddingMode(*paddingName)
+	if err != nil {
+		return tree.Stats{}, err
+	}
+
+	return tree.Encrypt(fs.Arg(0), fs.Arg(1), key, tree.Options{
+		Cipher:       *cipherName,
+		Mode:         mode,
+		Padding:      padding,
+		EncryptNames: *names,
+	})
+}
+
+func decrypt(args []string, verifyOnly bool) (tree.Stats, error) {
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	keyHex := fs.String("key", "", "ключ в hex")
+	fs.Parse(args)
+
+	want := 2
+	if verifyOnly {
+		want = 1
+	}
+	if fs.NArg() != want {
+		return tree.Stats{}, fmt.Errorf("%s: неверное число аргументов\n%s", os.Args[1], usage)
+	}
+	key, err := parseKey(*keyHex)
+	if err != nil {
+		return tree.Stats{}, err
+	}
+
+	if verifyOnly {
+		return tree.Verify(fs.Arg(0), key)
+	}
+	return tree.Decrypt(fs.Arg(0), fs.Arg(1), key)
+}
+
+func parseKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("не задан ключ (-key)")
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключа: %v", err)
+	}
+	return key, nil
+}
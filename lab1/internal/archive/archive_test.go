@@ -0,0 +1,291 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+var testKey = []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+
+type member struct {
+	name string
+	data []byte
+}
+
+var testMembers = []member{
+	{"readme.txt", []byte("encrypted archive test")},
+	{"empty.bin", nil},
+	{"docs/notes.txt", bytes.Repeat([]byte("repeated line\n"), 40)},
+	{"docs/blocks.bin", bytes.Repeat([]byte{0}, 64)},
+}
+
+func buildArchive(t *testing.T, key []byte, opts Options) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key, opts)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	mtime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	if err := w.Add(Entry{Name: "docs", Mode: os.ModeDir | 0755, ModTime: mtime}, nil); err != nil {
+		t.Fatalf("Add(docs) failed: %v", err)
+	}
+	for _, m := range testMembers {
+		e := Entry{Name: m.name, Mode: 0640, ModTime: mtime}
+		if err := w.Add(e, bytes.NewReader(m.data)); err != nil {
+			t.Fatalf("Add(%s) failed: %v", m.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func openArchive(data, key []byte) (*Reader, error) {
+	return NewReader(bytes.NewReader(data), int64(len(data)), key)
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  []byte
+		opts Options
+	}{
+		{"des CBC", testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7}},
+		{"des CTR compressed", testKey, Options{Cipher: "des", Mode: core.CTR, Padding: core.PadANSIX923, Compress: true}},
+		{"3des RandomDelta", bytes.Repeat(testKey, 3), Options{Cipher: "3des", Mode: core.RandomDelta, Padding: core.PadISO10126}},
+		{"deal-128 OFB compressed", bytes.Repeat(testKey, 2), Options{Cipher: "deal-128", Mode: core.OFB, Padding: core.PadPKCS7, Compress: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildArchive(t, tt.key, tt.opts)
+			r, err := openArchive(data, tt.key)
+			if err != nil {
+				t.Fatalf("NewReader failed: %v", err)
+			}
+			if got := r.Options(); got != tt.opts {
+				t.Errorf("Options() = %+v, want %+v", got, tt.opts)
+			}
+
+			entries := r.Entries()
+			if len(entries) != len(testMembers)+1 || entries[0].Name != "docs" || !entries[0].IsDir() {
+				t.Fatalf("unexpected entries: %+v", entries)
+			}
+			for i, m := range testMembers {
+				e := entries[i+1]
+				if e.Name != m.name || e.Size != int64(len(m.data)) || e.Mode != 0640 {
+					t.Errorf("entry %d = %+v, want %s (%d bytes)", i+1, e, m.name, len(m.data))
+				}
+				got, err := r.ReadFile(m.name)
+				if err != nil {
+					t.Fatalf("ReadFile(%s) failed: %v", m.name, err)
+				}
+				if !bytes.Equal(got, m.data) {
+					t.Errorf("ReadFile(%s) = %q, want %q", m.name, got, m.data)
+				}
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	plain := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7})
+	compressed := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7, Compress: true})
+	if len(compressed) >= len(plain) {
+		t.Errorf("compressed archive is %d bytes, uncompressed %d", len(compressed), len(plain))
+	}
+}
+
+func TestRandomIVs(t *testing.T) {
+	opts := Options{Cipher: "des", Mode: core.CTR, Padding: core.PadPKCS7}
+	a := buildArchive(t, testKey, opts)
+	b := buildArchive(t, testKey, opts)
+	if bytes.Equal(a, b) {
+		t.Error("two archives of the same content are identical: IVs are not random")
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	data := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7})
+	wrong := bytes.Clone(testKey)
+	wrong[0] ^= 0x80
+	if _, err := openArchive(data, wrong); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication for wrong key, got %v", err)
+	}
+}
+
+func TestTamperedHeader(t *testing.T) {
+	data := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7})
+	// Подмена режима в заголовке должна обнаруживаться до расшифрования индекса
+	data[len(headerMagic)+2] = byte(core.PCBC)
+	if _, err := openArchive(data, testKey); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication for tampered header, got %v", err)
+	}
+}
+
+func TestTamperedMember(t *testing.T) {
+	data := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CTR, Padding: core.PadPKCS7})
+	r, err := openArchive(data, testKey)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	var target Entry
+	for _, e := range r.Entries() {
+		if e.Name == "readme.txt" {
+			target = e
+		}
+	}
+	data[target.Offset+target.Length-1] ^= 0x01
+
+	if _, err := r.ReadFile("readme.txt"); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication for tampered member, got %v", err)
+	}
+	// Остальные элементы по-прежнему читаются
+	if got, err := r.ReadFile("docs/notes.txt"); err != nil || !bytes.Equal(got, testMembers[2].data) {
+		t.Errorf("ReadFile(docs/notes.txt) = %q, %v", got, err)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	data := buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7})
+	for n := len(headerMagic); n < len(data); n++ {
+		if _, err := openArchive(data[:n], testKey); !errors.Is(err, ErrTruncated) {
+			t.Fatalf("archive truncated to %d of %d bytes: expected ErrTruncated, got %v", n, len(data), err)
+		}
+	}
+	if _, err := openArchive([]byte("CR"), testKey); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("expected ErrNotArchive, got %v", err)
+	}
+	if _, err := openArchive(append([]byte("PK\x03\x04"), data[4:]...), testKey); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("expected ErrNotArchive, got %v", err)
+	}
+}
+
+func TestReadFileNotFound(t *testing.T) {
+	r, err := openArchive(buildArchive(t, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7}), testKey)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if _, err := r.ReadFile("missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestWriterRejects(t *testing.T) {
+	var buf bytes.Buffer
+	for _, opts := range []Options{
+		{Cipher: "des", Mode: core.ECB, Padding: core.PadPKCS7},
+		{Cipher: "des", Mode: core.CBC, Padding: core.PadZeros},
+		{Cipher: "no-such-cipher", Mode: core.CBC, Padding: core.PadPKCS7},
+	} {
+		if _, err := NewWriter(&buf, testKey, opts); err == nil {
+			t.Errorf("NewWriter(%+v): expected error", opts)
+		}
+	}
+
+	w, err := NewWriter(&buf, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for _, name := range []string{"", ".", "../escape", "/etc/passwd", "a/../../b"} {
+		if err := w.Add(Entry{Name: name, Mode: 0644}, strings.NewReader("x")); err == nil {
+			t.Errorf("Add(%q): expected error", name)
+		}
+	}
+	if err := w.Add(Entry{Name: "a.txt", Mode: 0644}, strings.NewReader("x")); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := w.Add(Entry{Name: "./a.txt", Mode: 0644}, strings.NewReader("y")); err == nil {
+		t.Error("expected error for duplicate member")
+	}
+	if err := w.Add(Entry{Name: "link", Mode: os.ModeSymlink | 0777}, nil); err == nil {
+		t.Error("expected error for symlink")
+	}
+}
+
+func TestDirectoryRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	mtime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := map[string]string{
+		"a.txt":         "first file",
+		"sub/b.txt":     "second file",
+		"sub/deep/c.sh": "#!/bin/sh\necho hi\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "sub/deep/c.sh"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.arc")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWriter(out, testKey, Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7, Compress: true})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := w.AddDir(src); err != nil {
+		t.Fatalf("AddDir failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	out.Close()
+
+	f, err := OpenFile(archivePath, testKey)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+
+	// Извлечение одного элемента
+	single := t.TempDir()
+	if err := f.Extract("sub/deep/c.sh", single); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(single, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("Extract of one member created other files: %v", err)
+	}
+
+	dst := t.TempDir()
+	if err := f.ExtractAll(dst); err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+	for _, dir := range []string{single, dst} {
+		p := filepath.Join(dir, "sub/deep/c.sh")
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0750 || !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: mode %v, mtime %v; want 0750, %v", p, info.Mode().Perm(), info.ModTime(), mtime)
+		}
+	}
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, content)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package archive
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+// Reader даёт доступ к элементам архива. Индекс расшифровывается и проверяется
+// при открытии; содержимое элементов читается только по запросу.
+type Reader struct {
+	r       io.ReaderAt
+	ctx     *core.CipherContext
+	macKey  []byte
+	opts    Options
+	entries []Entry
+	byName  map[string]int
+}
+
+// File архив, открытый с диска
+type File struct {
+	*Reader
+	f *os.File
+}
+
+// OpenFile открывает архив по пути path
+func OpenFile(path string, key []byte) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := NewReader(f, info.Size(), key)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &File{Reader: r, f: f}, nil
+}
+
+// Close закрывает файл архива
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// NewReader читает заголовок и окончание архива размером size, проверяет HMAC
+// индекса и расшифровывает его. Неверный ключ или изменённый заголовок/индекс
+// дают ErrAuthentication, обрезанный архив — ErrTruncated.
+func NewReader(r io.ReaderAt, size int64, key []byte) (*Reader, error) {
+	header, opts, err := readHeader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if size < int64(len(header))+footerSize {
+		return nil, ErrTruncated
+	}
+
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, err
+	}
+	if string(footer[footerSize-len(footerMagic):]) != footerMagic {
+		return nil, ErrTruncated
+	}
+	indexOffset := int64(binary.BigEndian.Uint64(footer[0:8]))
+	indexLength := int64(binary.BigEndian.Uint64(footer[8:16]))
+	tag := footer[16 : 16+tagSize]
+	if indexOffset < int64(len(header)) || indexLength < 0 || indexOffset > size-footerSize ||
+		indexLength != size-footerSize-indexOffset {
+		// Окончание на месте, но не совпадает с длиной файла: архив обрезан
+		// посередине или склеен из частей
+		return nil, ErrTruncated
+	}
+
+	ciphertext := make([]byte, indexLength)
+	if _, err := r.ReadAt(ciphertext, indexOffset); err != nil {
+		return nil, err
+	}
+	macKey := deriveMACKey(key)
+	if !hmac.Equal(tag, indexTag(macKey, header, indexOffset, ciphertext)) {
+		return nil, ErrAuthentication
+	}
+
+	c, err := registry.NewCipher(opts.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	ctx := core.NewCipherContext(c, opts.Mode, opts.Padding, nil)
+	encoded, err := ctx.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("archive: decrypting index: %w", err)
+	}
+	var idx index
+	if err := json.Unmarshal(encoded, &idx); err != nil {
+		return nil, fmt.Errorf("archive: malformed index: %w", err)
+	}
+
+	ar := &Reader{
+		r:       r,
+		ctx:     ctx,
+		macKey:  macKey,
+		opts:    opts,
+		entries: idx.Entries,
+		byName:  make(map[string]int, len(idx.Entries)),
+	}
+	next := int64(len(header))
+	for i, e := range idx.Entries {
+		if e.Offset != next || e.Length < 0 {
+			return nil, fmt.Errorf("archive: malformed index: member %q at unexpected offset", e.Name)
+		}
+		next += e.Length + tagSize
+		ar.byName[e.Name] = i
+	}
+	if next != indexOffset {
+		return nil, errors.New("archive: malformed index: members do not end at the index")
+	}
+	return ar, nil
+}
+
+func readHeader(r io.ReaderAt, size int64) ([]byte, Options, error) {
+	fixed := len(headerMagic) + 5
+	if size < int64(fixed) {
+		if size >= int64(len(headerMagic)) {
+			return nil, Options{}, ErrTruncated
+		}
+		return nil, Options{}, ErrNotArchive
+	}
+	header := make([]byte, fixed)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, Options{}, err
+	}
+	if string(header[:len(headerMagic)]) != headerMagic {
+		return nil, Options{}, ErrNotArchive
+	}
+	p := header[len(headerMagic):]
+	if p[0] != version {
+		return nil, Options{}, fmt.Errorf("archive: unsupported version %d", p[0])
+	}
+	opts := Options{
+		Compress: p[1]&flagCompressed != 0,
+		Mode:     core.CipherMode(p[2]),
+		Padding:  core.PaddingMode(p[3]),
+	}
+	nameLen := int(p[4])
+	if size < int64(fixed+nameLen) {
+		return nil, Options{}, ErrTruncated
+	}
+	name := make([]byte, nameLen)
+	if _, err := r.ReadAt(name, int64(fixed)); err != nil {
+		return nil, Options{}, err
+	}
+	opts.Cipher = string(name)
+	return append(header, name...), opts, nil
+}
+
+// Options возвращает параметры, с которыми был создан архив
+func (ar *Reader) Options() Options {
+	return ar.opts
+}
+
+// Entries возвращает метаданные всех элементов в порядке добавления
+func (ar *Reader) Entries() []Entry {
+	return append([]Entry(nil), ar.entries...)
+}
+
+// ReadFile расшифровывает содержимое одного элемента, не затрагивая остальные
+func (ar *Reader) ReadFile(name string) ([]byte, error) {
+	i, ok := ar.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return ar.read(i)
+}
+
+func (ar *Reader) read(i int) ([]byte, error) {
+	e := ar.entries[i]
+	buf := make([]byte, e.Length+tagSize)
+	if _, err := ar.r.ReadAt(buf, e.Offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	ciphertext, tag := buf[:e.Length], buf[e.Length:]
+	if !hmac.Equal(tag, memberTag(ar.macKey, i, ciphertext)) {
+		return nil, fmt.Errorf("%w: member %s", ErrAuthentication, e.Name)
+	}
+
+	data, err := ar.ctx.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("archive: decrypting %s: %w", e.Name, err)
+	}
+	if ar.opts.Compress {
+		if data, err = io.ReadAll(flate.NewReader(bytes.NewReader(data))); err != nil {
+			return nil, fmt.Errorf("archive: decompressing %s: %w", e.Name, err)
+		}
+	}
+	if int64(len(data)) != e.Size {
+		return nil, fmt.Errorf("archive: %s: size mismatch: got %d bytes, want %d", e.Name, len(data), e.Size)
+	}
+	return data, nil
+}
+
+// Extract извлекает один элемент в каталог dir, восстанавливая права и время изменения
+func (ar *Reader) Extract(name, dir string) error {
+	i, ok := ar.byName[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return ar.extract(i, dir)
+}
+
+// ExtractAll извлекает все элементы в каталог dir
+func (ar *Reader) ExtractAll(dir string) error {
+	for i := range ar.entries {
+		if err := ar.extract(i, dir); err != nil {
+			return err
+		}
+	}
+	// Время каталогов восстанавливается в конце: запись файлов внутри его меняет
+	for _, e := range ar.entries {
+		if e.IsDir() {
+			if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(e.Name)), e.ModTime, e.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ar *Reader) extract(i int, dir string) error {
+	e := ar.entries[i]
+	name, err := cleanName(e.Name)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+
+	if e.IsDir() {
+		if err := os.MkdirAll(target, e.Mode.Perm()|0700); err != nil {
+			return err
+		}
+		return os.Chtimes(target, e.ModTime, e.ModTime)
+	}
+
+	data, err := ar.read(i)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, e.Mode.Perm()); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, e.Mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(target, e.ModTime, e.ModTime)
+}
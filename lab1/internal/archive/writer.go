@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+// Options параметры создаваемого архива
+type Options struct {
+	Cipher   string           // имя шифра из реестра
+	Mode     core.CipherMode  // режим шифрования; ECB не допускается
+	Padding  core.PaddingMode // режим паддинга; PadZeros не допускается
+	Compress bool             // сжимать содержимое элементов (DEFLATE) перед шифрованием
+}
+
+// Writer последовательно записывает элементы архива
+type Writer struct {
+	w       io.Writer
+	ctx     *core.CipherContext
+	macKey  []byte
+	header  []byte
+	opts    Options
+	offset  int64
+	entries []Entry
+	names   map[string]struct{}
+	closed  bool
+}
+
+// NewWriter создаёт архив, записывающий данные в w, и сразу записывает заголовок.
+// Для каждого элемента контекст генерирует собственный случайный IV.
+func NewWriter(w io.Writer, key []byte, opts Options) (*Writer, error) {
+	if opts.Mode == core.ECB {
+		return nil, errors.New("archive: ECB mode reveals repeated blocks and is not supported")
+	}
+	if opts.Padding == core.PadZeros {
+		// Нулевой паддинг неотличим от нулей в конце данных и испортил бы содержимое
+		return nil, errors.New("archive: zero padding is ambiguous and is not supported")
+	}
+	if len(opts.Cipher) == 0 || len(opts.Cipher) > 255 {
+		return nil, fmt.Errorf("archive: invalid cipher name %q", opts.Cipher)
+	}
+	c, err := registry.NewCipher(opts.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := encodeHeader(opts)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:      w,
+		ctx:    core.NewCipherContext(c, opts.Mode, opts.Padding, nil),
+		macKey: deriveMACKey(key),
+		header: header,
+		opts:   opts,
+		offset: int64(len(header)),
+		names:  make(map[string]struct{}),
+	}, nil
+}
+
+func encodeHeader(opts Options) []byte {
+	var flags byte
+	if opts.Compress {
+		flags |= flagCompressed
+	}
+	header := []byte(headerMagic)
+	header = append(header, version, flags, byte(opts.Mode), byte(opts.Padding), byte(len(opts.Cipher)))
+	return append(header, opts.Cipher...)
+}
+
+// Add добавляет элемент с метаданными e и содержимым из r.
+// Для каталогов r может быть nil. Поля Size, Offset и Length заполняются автоматически.
+func (aw *Writer) Add(e Entry, r io.Reader) error {
+	if aw.closed {
+		return errors.New("archive: writer is closed")
+	}
+	name, err := cleanName(e.Name)
+	if err != nil {
+		return err
+	}
+	if _, ok := aw.names[name]; ok {
+		return fmt.Errorf("archive: duplicate member %q", name)
+	}
+	if !e.IsDir() && !e.Mode.IsRegular() {
+		return fmt.Errorf("archive: %s: only regular files and directories are supported", name)
+	}
+
+	var data []byte
+	if !e.IsDir() && r != nil {
+		if data, err = io.ReadAll(r); err != nil {
+			return err
+		}
+	}
+
+	e.Name = name
+	e.Size = int64(len(data))
+	e.ModTime = e.ModTime.UTC()
+
+	if aw.opts.Compress {
+		if data, err = compress(data); err != nil {
+			return err
+		}
+	}
+	ciphertext, err := aw.ctx.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("archive: encrypting %s: %w", name, err)
+	}
+	tag := memberTag(aw.macKey, len(aw.entries), ciphertext)
+
+	if _, err := aw.w.Write(ciphertext); err != nil {
+		return err
+	}
+	if _, err := aw.w.Write(tag); err != nil {
+		return err
+	}
+
+	e.Offset = aw.offset
+	e.Length = int64(len(ciphertext))
+	aw.offset += int64(len(ciphertext) + len(tag))
+	aw.entries = append(aw.entries, e)
+	aw.names[name] = struct{}{}
+	return nil
+}
+
+// AddFile добавляет файл или каталог с диска под именем name
+func (aw *Writer) AddFile(name, filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	e := Entry{Name: name, Mode: info.Mode(), ModTime: info.ModTime()}
+	if info.IsDir() {
+		return aw.Add(e, nil)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return aw.Add(e, f)
+}
+
+// AddDir рекурсивно добавляет содержимое каталога root; имена элементов
+// задаются относительно root. Символические ссылки и прочие специальные файлы пропускаются.
+func (aw *Writer) AddDir(root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." || !(d.IsDir() || d.Type().IsRegular()) {
+			return nil
+		}
+		return aw.AddFile(filepath.ToSlash(rel), p)
+	})
+}
+
+// Close записывает зашифрованный индекс и окончание архива. Закрывать w должен вызывающий.
+func (aw *Writer) Close() error {
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+
+	encoded, err := json.Marshal(index{Entries: aw.entries})
+	if err != nil {
+		return err
+	}
+	ciphertext, err := aw.ctx.Encrypt(encoded)
+	if err != nil {
+		return fmt.Errorf("archive: encrypting index: %w", err)
+	}
+	if _, err := aw.w.Write(ciphertext); err != nil {
+		return err
+	}
+
+	footer := make([]byte, 0, footerSize)
+	footer = binary.BigEndian.AppendUint64(footer, uint64(aw.offset))
+	footer = binary.BigEndian.AppendUint64(footer, uint64(len(ciphertext)))
+	footer = append(footer, indexTag(aw.macKey, aw.header, aw.offset, ciphertext)...)
+	footer = append(footer, footerMagic...)
+	_, err = aw.w.Write(footer)
+	return err
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cleanName приводит имя к виду "a/b/c" и отклоняет пути, выходящие за корень архива
+func cleanName(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive: invalid member name %q", name)
+	}
+	return clean, nil
+}
@@ -0,0 +1,112 @@
+package bench
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+// baseline эталонный шифр стандартной библиотеки
+type baseline struct {
+	keySize   int
+	blockSize int
+	newBlock  func(key []byte) (cipher.Block, error)
+}
+
+// baselines шифры стандартной библиотеки для сравнения с собственными реализациями
+var baselines = map[string]baseline{
+	"stdlib-aes-128": {keySize: 16, blockSize: aes.BlockSize, newBlock: aes.NewCipher},
+	"stdlib-des":     {keySize: 8, blockSize: des.BlockSize, newBlock: des.NewCipher},
+	"stdlib-3des":    {keySize: 24, blockSize: des.BlockSize, newBlock: des.NewTripleDESCipher},
+}
+
+// CipherNames возвращает имена всех шифров: из реестра и эталонные
+func CipherNames() []string {
+	names := registry.Names()
+	for name := range baselines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCipher создаёт шифр по имени (из реестра или эталонный) с установленным ключом
+func NewCipher(name string, key []byte) (core.SymmetricCipher, error) {
+	b, ok := baselines[name]
+	if !ok {
+		return registry.NewCipher(name, key)
+	}
+	if len(key) != b.keySize {
+		return nil, fmt.Errorf("bench: %s key must be %d bytes, got %d", name, b.keySize, len(key))
+	}
+	s := &stdCipher{newBlock: b.newBlock, blockSize: b.blockSize}
+	if err := s.SetEncryptionKey(key); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func cipherSizes(name string) (keySize, blockSize int, err error) {
+	if b, ok := baselines[name]; ok {
+		return b.keySize, b.blockSize, nil
+	}
+	d, err := registry.Lookup(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.KeySize, d.BlockSize, nil
+}
+
+// stdCipher адаптирует cipher.Block к core.SymmetricCipher
+type stdCipher struct {
+	newBlock  func(key []byte) (cipher.Block, error)
+	block     cipher.Block
+	blockSize int
+}
+
+func (s *stdCipher) SetEncryptionKey(key []byte) error {
+	block, err := s.newBlock(key)
+	if err != nil {
+		return err
+	}
+	s.block = block
+	return nil
+}
+
+func (s *stdCipher) SetDecryptionKey(key []byte) error {
+	return s.SetEncryptionKey(key)
+}
+
+func (s *stdCipher) EncryptBlock(block []byte) ([]byte, error) {
+	if s.block == nil {
+		return nil, errors.New("key not set")
+	}
+	if len(block) != s.blockSize {
+		return nil, fmt.Errorf("block must be %d bytes", s.blockSize)
+	}
+	out := make([]byte, s.blockSize)
+	s.block.Encrypt(out, block)
+	return out, nil
+}
+
+func (s *stdCipher) DecryptBlock(block []byte) ([]byte, error) {
+	if s.block == nil {
+		return nil, errors.New("key not set")
+	}
+	if len(block) != s.blockSize {
+		return nil, fmt.Errorf("block must be %d bytes", s.blockSize)
+	}
+	out := make([]byte, s.blockSize)
+	s.block.Decrypt(out, block)
+	return out, nil
+}
+
+func (s *stdCipher) BlockSize() int {
+	return s.blockSize
+}
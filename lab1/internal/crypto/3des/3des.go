@@ -4,38 +4,113 @@ import (
 	"errors"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
 )
 
-// TripleDES реализует Triple DES в режиме EDE3 (Encrypt-Decrypt-Encrypt с 3 ключами)
-// Использует схему: Encrypt(K1) → Decrypt(K2) → Encrypt(K3)
-// Эффективная длина ключа: 168 бит (3×56)
+// Variant порядок операций Triple DES
+type Variant int
+
+const (
+	// EDE Encrypt(K1) → Decrypt(K2) → Encrypt(K3), как в ANSI X9.52 и SP 800-67
+	EDE Variant = iota
+	// EEE Encrypt(K1) → Encrypt(K2) → Encrypt(K3)
+	EEE
+)
+
+// KeyingOption вариант ключевания по ANSI X9.52 / SP 800-67
+type KeyingOption int
+
+const (
+	// KeyingOption1 три независимых ключа K1, K2, K3 (24 байта)
+	KeyingOption1 KeyingOption = iota + 1
+	// KeyingOption2 K1 и K2 независимы, K3 = K1 (16 байт или 24 байта с K3 = K1)
+	KeyingOption2
+	// KeyingOption3 K1 = K2 = K3 (8 байт или повтор одной части); EDE совпадает с одинарным DES
+	KeyingOption3
+)
+
+// DetectKeyingOption определяет вариант ключевания SP 800-67 по частям ключа:
+// 24-байтовый ключ с K3 = K1 — это вариант 2, любой ключ с K1 = K2 = K3 —
+// вариант 3. Части сравниваются без учёта битов чётности.
+func DetectKeyingOption(key []byte) (KeyingOption, error) {
+	k1, k2, k3, err := splitKey(key)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case des.SameKey(k1, k2) && des.SameKey(k2, k3):
+		return KeyingOption3, nil
+	case des.SameKey(k1, k3):
+		return KeyingOption2, nil
+	default:
+		return KeyingOption1, nil
+	}
+}
+
+// TripleDES реализует Triple DES в режимах EDE и EEE
+// EDE: Encrypt(K1) → Decrypt(K2) → Encrypt(K3); EEE: Encrypt(K1) → Encrypt(K2) → Encrypt(K3)
+// Ключ разбивается на части по длине: 24 байта — EDE3/EEE3 (168 бит),
+// 16 байт — EDE2/EEE2 (K3 = K1, 112 бит), 8 байт — совместимость с одинарным DES;
+// вариант ключевания определяется по совпадению частей (см. DetectKeyingOption)
 type TripleDES struct {
 	des1 core.SymmetricCipher // Первый DES для шифрования с K1
-	des2 core.SymmetricCipher // Второй DES для дешифрования с K2
+	des2 core.SymmetricCipher // Второй DES: дешифрование (EDE) или шифрование (EEE) с K2
 	des3 core.SymmetricCipher // Третий DES для шифрования с K3
+
+	variant   Variant
+	option    KeyingOption
+	keyPolicy des.KeyPolicy
+	keyReport des.KeyReport
+	destroyed bool
 }
 
-// NewTripleDES создает новый экземпляр Triple DES
+// NewTripleDES создает новый экземпляр Triple DES в режиме EDE
 // Принимает три независимых экземпляра DES шифра
 func NewTripleDES(des1, des2, des3 core.SymmetricCipher) *TripleDES {
+	return NewTripleDESVariant(des1, des2, des3, EDE)
+}
+
+// NewTripleDESVariant создает Triple DES с заданным порядком операций
+func NewTripleDESVariant(des1, des2, des3 core.SymmetricCipher, variant Variant) *TripleDES {
 	return &TripleDES{
-		des1: des1,
-		des2: des2,
-		des3: des3,
+		des1:    des1,
+		des2:    des2,
+		des3:    des3,
+		variant: variant,
+	}
+}
+
+// splitKey разбивает ключ на K1, K2, K3 по его длине
+func splitKey(key []byte) (k1, k2, k3 []byte, err error) {
+	switch len(key) {
+	case 24:
+		return key[0:8], key[8:16], key[16:24], nil
+	case 16:
+		return key[0:8], key[8:16], key[0:8], nil
+	case 8:
+		return key, key, key, nil
+	default:
+		return nil, nil, nil, errors.New("Triple DES key must be 8, 16 or 24 bytes")
 	}
 }
 
 // SetEncryptionKey устанавливает ключ шифрования для Triple DES
-// Ожидает 24 байта (192 бита): 8 байт для K1, 8 байт для K2, 8 байт для K3
+// Принимает 24 байта (K1, K2, K3), 16 байт (K1, K2, K3 = K1) или 8 байт (K1 = K2 = K3)
 func (t *TripleDES) SetEncryptionKey(key []byte) error {
-	if len(key) != 24 {
-		return errors.New("Triple DES key must be exactly 24 bytes (192 bits)")
+	if t.destroyed {
+		return core.ErrDestroyed
+	}
+	key1, key2, key3, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	option, err := DetectKeyingOption(key)
+	if err != nil {
+		return err
+	}
+	if err := t.checkKey(key1, key2, key3); err != nil {
+		return err
 	}
-
-	// Разделяем ключ на три части по 8 байт
-	key1 := key[0:8]
-	key2 := key[8:16]
-	key3 := key[16:24]
 
 	// Устанавливаем ключи для шифрования
 	// des1: шифрование с K1
@@ -43,8 +118,13 @@ func (t *TripleDES) SetEncryptionKey(key []byte) error {
 		return err
 	}
 
-	// des2: дешифрование с K2 (для EDE схемы)
-	if err := t.des2.SetDecryptionKey(key2); err != nil {
+	// des2: дешифрование с K2 для EDE, шифрование для EEE
+	if t.variant == EEE {
+		err = t.des2.SetEncryptionKey(key2)
+	} else {
+		err = t.des2.SetDecryptionKey(key2)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -53,20 +133,27 @@ func (t *TripleDES) SetEncryptionKey(key []byte) error {
 		return err
 	}
 
+	t.option = option
 	return nil
 }
 
 // SetDecryptionKey устанавливает ключ дешифрования для Triple DES
-// Ожидает 24 байта (192 бита): 8 байт для K1, 8 байт для K2, 8 байт для K3
+// Принимает ключи тех же длин, что и SetEncryptionKey
 func (t *TripleDES) SetDecryptionKey(key []byte) error {
-	if len(key) != 24 {
-		return errors.New("Triple DES key must be exactly 24 bytes (192 bits)")
+	if t.destroyed {
+		return core.ErrDestroyed
+	}
+	key1, key2, key3, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	option, err := DetectKeyingOption(key)
+	if err != nil {
+		return err
+	}
+	if err := t.checkKey(key1, key2, key3); err != nil {
+		return err
 	}
-
-	// Разделяем ключ на три части по 8 байт
-	key1 := key[0:8]
-	key2 := key[8:16]
-	key3 := key[16:24]
 
 	// Устанавливаем ключи для дешифрования (обратный порядок)
 	// des3: дешифрование с K3
@@ -74,8 +161,13 @@ func (t *TripleDES) SetDecryptionKey(key []byte) error {
 		return err
 	}
 
-	// des2: шифрование с K2 (обратная операция для EDE)
-	if err := t.des2.SetEncryptionKey(key2); err != nil {
+	// des2: шифрование с K2 для EDE (обратная операция), дешифрование для EEE
+	if t.variant == EEE {
+		err = t.des2.SetDecryptionKey(key2)
+	} else {
+		err = t.des2.SetEncryptionKey(key2)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -84,12 +176,61 @@ func (t *TripleDES) SetDecryptionKey(key []byte) error {
 		return err
 	}
 
+	t.option = option
+	return nil
+}
+
+// KeyingOption возвращает вариант ключевания последнего установленного ключа
+// (0, пока ключ не установлен)
+func (t *TripleDES) KeyingOption() KeyingOption {
+	return t.option
+}
+
+// Variant возвращает порядок операций
+func (t *TripleDES) Variant() Variant {
+	return t.variant
+}
+
+// SetKeyPolicy задаёт политику проверки ключа: StrictKeys отвергает ключ,
+// если любой из K1, K2, K3 слабый, полуслабый, возможно слабый или с ошибкой
+// чётности, а в режиме EDE также если K1 == K2 или K2 == K3 (в том числе
+// ключ из 8 байт)
+func (t *TripleDES) SetKeyPolicy(policy des.KeyPolicy) {
+	t.keyPolicy = policy
+}
+
+// KeyReport возвращает недостатки последнего установленного ключа
+func (t *TripleDES) KeyReport() des.KeyReport {
+	return t.keyReport
+}
+
+// checkKey проверяет K1, K2, K3 по отдельности и на совпадение.
+// При K1 == K2 или K2 == K3 EDE сводится к одинарному DES с оставшимся ключом.
+func (t *TripleDES) checkKey(key1, key2, key3 []byte) error {
+	var report des.KeyReport
+	for _, key := range [][]byte{key1, key2, key3} {
+		r, err := des.CheckKey(key)
+		if err != nil {
+			return err
+		}
+		report = report.Merge(r)
+	}
+	if t.variant == EDE && (des.SameKey(key1, key2) || des.SameKey(key2, key3)) {
+		report = report.Merge(des.KeyReport{Issues: []des.KeyIssue{des.RepeatedKey}})
+	}
+	if t.keyPolicy == des.StrictKeys && !report.OK() {
+		return report.Err()
+	}
+	t.keyReport = report
 	return nil
 }
 
 // EncryptBlock шифрует один 64-битный блок
-// Схема: Encrypt(K1) → Decrypt(K2) → Encrypt(K3)
+// Схема: Encrypt(K1) → Decrypt(K2) → Encrypt(K3) (для EEE второй шаг — Encrypt)
 func (t *TripleDES) EncryptBlock(block []byte) ([]byte, error) {
+	if t.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if len(block) != 8 {
 		return nil, errors.New("Triple DES block must be exactly 8 bytes")
 	}
@@ -100,14 +241,14 @@ func (t *TripleDES) EncryptBlock(block []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	// Второй этап: Decrypt с K2
-	decrypted2, err := t.des2.DecryptBlock(encrypted1)
+	// Второй этап: Decrypt (EDE) или Encrypt (EEE) с K2
+	middle, err := t.middle(encrypted1, false)
 	if err != nil {
 		return nil, err
 	}
 
 	// Третий этап: Encrypt с K3
-	encrypted3, err := t.des3.EncryptBlock(decrypted2)
+	encrypted3, err := t.des3.EncryptBlock(middle)
 	if err != nil {
 		return nil, err
 	}
@@ -116,8 +257,11 @@ func (t *TripleDES) EncryptBlock(block []byte) ([]byte, error) {
 }
 
 // DecryptBlock дешифрует один 64-битный блок
-// Схема: Decrypt(K3) → Encrypt(K2) → Decrypt(K1)
+// Схема: Decrypt(K3) → Encrypt(K2) → Decrypt(K1) (для EEE второй шаг — Decrypt)
 func (t *TripleDES) DecryptBlock(block []byte) ([]byte, error) {
+	if t.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if len(block) != 8 {
 		return nil, errors.New("Triple DES block must be exactly 8 bytes")
 	}
@@ -128,14 +272,14 @@ func (t *TripleDES) DecryptBlock(block []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	// Второй этап: Encrypt с K2
-	encrypted2, err := t.des2.EncryptBlock(decrypted3)
+	// Второй этап: Encrypt (EDE) или Decrypt (EEE) с K2
+	middle, err := t.middle(decrypted3, true)
 	if err != nil {
 		return nil, err
 	}
 
 	// Третий этап: Decrypt с K1
-	decrypted1, err := t.des1.DecryptBlock(encrypted2)
+	decrypted1, err := t.des1.DecryptBlock(middle)
 	if err != nil {
 		return nil, err
 	}
@@ -143,10 +287,30 @@ func (t *TripleDES) DecryptBlock(block []byte) ([]byte, error) {
 	return decrypted1, nil
 }
 
+// middle выполняет второй этап с K2: в EDE он противоположен направлению
+// всего шифра, в EEE совпадает с ним
+func (t *TripleDES) middle(block []byte, decrypt bool) ([]byte, error) {
+	if decrypt == (t.variant == EEE) {
+		return t.des2.DecryptBlock(block)
+	}
+	return t.des2.EncryptBlock(block)
+}
+
 // BlockSize возвращает размер блока в байтах (8 байт для Triple DES)
 func (t *TripleDES) BlockSize() int {
 	return 8
 }
 
+// Destroy стирает ключи всех трёх экземпляров DES
+func (t *TripleDES) Destroy() {
+	core.Destroy(t.des1)
+	core.Destroy(t.des2)
+	core.Destroy(t.des3)
+	t.destroyed = true
+}
+
 // Проверка соответствия интерфейсу SymmetricCipher
-var _ core.SymmetricCipher = (*TripleDES)(nil)
+var (
+	_ core.SymmetricCipher = (*TripleDES)(nil)
+	_ core.Destroyer       = (*TripleDES)(nil)
+)
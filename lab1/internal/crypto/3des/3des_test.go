@@ -0,0 +1,224 @@
+package threedes
+
+import (
+	"bytes"
+	stddes "crypto/des"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+func newTripleDES() *TripleDES {
+	return NewTripleDES(des.NewDES(), des.NewDES(), des.NewDES())
+}
+
+func TestTripleDESKeyPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want des.KeyIssue
+	}{
+		{"K1 == K2", "0123456789ABCDEF" + "0123456789ABCDEF" + "23456789ABCDEF01", des.RepeatedKey},
+		{"K2 == K3 up to parity", "0123456789ABCDEF" + "23456789ABCDEF01" + "22446688AACCEE00", des.RepeatedKey},
+		{"weak K3", "0123456789ABCDEF" + "23456789ABCDEF01" + "FEFEFEFEFEFEFEFE", des.WeakKey},
+		{"parity error in K2", "0123456789ABCDEF" + "23456789ABCDEF00" + "456789ABCDEF0123", des.ParityError},
+	}
+	for _, tt := range tests {
+		key, _ := hex.DecodeString(tt.key)
+
+		lenient := newTripleDES()
+		if err := lenient.SetEncryptionKey(key); err != nil {
+			t.Errorf("%s: lenient SetEncryptionKey: %v", tt.name, err)
+		}
+		if !lenient.KeyReport().Has(tt.want) {
+			t.Errorf("%s: report %v, want %v", tt.name, lenient.KeyReport().Issues, tt.want)
+		}
+
+		strict := newTripleDES()
+		strict.SetKeyPolicy(des.StrictKeys)
+		if err := strict.SetDecryptionKey(key); !errors.Is(err, des.ErrInsecureKey) {
+			t.Errorf("%s: strict SetDecryptionKey: got %v, want ErrInsecureKey", tt.name, err)
+		}
+	}
+
+	key, _ := hex.DecodeString("0123456789ABCDEF" + "23456789ABCDEF01" + "456789ABCDEF0123")
+	strict := newTripleDES()
+	strict.SetKeyPolicy(des.StrictKeys)
+	if err := strict.SetEncryptionKey(key); err != nil {
+		t.Errorf("strict mode rejected an independent key: %v", err)
+	}
+	if !strict.KeyReport().OK() {
+		t.Errorf("unexpected issues %v", strict.KeyReport().Issues)
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// Известные ответы TMOVS (NIST SP 800-20) для варианта ключевания 3:
+// переменный открытый текст, обратная перестановка, переменный ключ,
+// перестановка и подстановка
+var tmovsVectors = []struct {
+	key, plaintext, ciphertext string
+}{
+	{"0101010101010101", "8000000000000000", "95F8A5E5DD31D900"},
+	{"0101010101010101", "0000000000000001", "166B40B44ABA4BD6"},
+	{"0101010101010101", "95F8A5E5DD31D900", "8000000000000000"},
+	{"8001010101010101", "0000000000000000", "95A8D72813DAA94D"},
+	{"1046913489980131", "0000000000000000", "88D55E54F54C97B4"},
+	{"7CA110454A1A6E57", "01A1D6D039776742", "690F5B0D9A26939B"},
+	{"0131D9619DC1376E", "5CD54CA83DEF57DA", "7A389D10354BD271"},
+}
+
+func TestTripleDESTMOVS(t *testing.T) {
+	for _, v := range tmovsVectors {
+		// Ключ из 8 байт и явный K1 = K2 = K3 дают один результат
+		for _, key := range []string{v.key, v.key + v.key, v.key + v.key + v.key} {
+			c := newTripleDES()
+			if err := c.SetEncryptionKey(mustHex(t, key)); err != nil {
+				t.Fatalf("SetEncryptionKey(%s): %v", key, err)
+			}
+			got, _ := c.EncryptBlock(mustHex(t, v.plaintext))
+			if want := mustHex(t, v.ciphertext); !bytes.Equal(got, want) {
+				t.Errorf("key %s, plaintext %s: got %X, want %X", key, v.plaintext, got, want)
+			}
+
+			c = newTripleDES()
+			if err := c.SetDecryptionKey(mustHex(t, key)); err != nil {
+				t.Fatalf("SetDecryptionKey(%s): %v", key, err)
+			}
+			back, _ := c.DecryptBlock(got)
+			if want := mustHex(t, v.plaintext); !bytes.Equal(back, want) {
+				t.Errorf("key %s: decrypted %X, want %X", key, back, want)
+			}
+		}
+	}
+}
+
+// Векторы EDE3 из SP 800-67; EDE2 получен через openssl enc -des-ede-ecb.
+// Режима EEE в openssl нет, поэтому EEE3 и EEE2 получены цепочкой из трёх
+// шифрований openssl enc -des-ecb -nopad ключами K1, K2, K3 (K1, K2, K1 для EEE2)
+func TestTripleDESVariants(t *testing.T) {
+	const (
+		k1 = "0123456789ABCDEF"
+		k2 = "23456789ABCDEF01"
+		k3 = "456789ABCDEF0123"
+	)
+	plaintext := []byte("The qufck brown fox jump")
+	tests := []struct {
+		name       string
+		variant    Variant
+		key        string
+		option     KeyingOption
+		ciphertext string
+	}{
+		{"EDE3", EDE, k1 + k2 + k3, KeyingOption1, "A826FD8CE53B855FCCE21C8112256FE668D5C05DD9B6B900"},
+		{"EDE2", EDE, k1 + k2, KeyingOption2, "C44862F70CF2FBDC9077D0909FA91B884CABD61FC58E0CBB"},
+		{"EEE3", EEE, k1 + k2 + k3, KeyingOption1, "CE2719FF408A7AFAC3F4683AD32C6B5BEC6AD3D6DA9DC9B3"},
+		{"EEE2", EEE, k1 + k2, KeyingOption2, "500013533151E90C7314612FB856088E28B61BD250FE2D39"},
+	}
+	for _, tt := range tests {
+		enc := NewTripleDESVariant(des.NewDES(), des.NewDES(), des.NewDES(), tt.variant)
+		dec := NewTripleDESVariant(des.NewFastDES(), des.NewFastDES(), des.NewFastDES(), tt.variant)
+		if err := enc.SetEncryptionKey(mustHex(t, tt.key)); err != nil {
+			t.Fatalf("%s: SetEncryptionKey: %v", tt.name, err)
+		}
+		if err := dec.SetDecryptionKey(mustHex(t, tt.key)); err != nil {
+			t.Fatalf("%s: SetDecryptionKey: %v", tt.name, err)
+		}
+		if enc.KeyingOption() != tt.option {
+			t.Errorf("%s: keying option %d, want %d", tt.name, enc.KeyingOption(), tt.option)
+		}
+
+		want := mustHex(t, tt.ciphertext)
+		for off := 0; off < len(plaintext); off += 8 {
+			got, _ := enc.EncryptBlock(plaintext[off : off+8])
+			if !bytes.Equal(got, want[off:off+8]) {
+				t.Errorf("%s block %d: got %X, want %X", tt.name, off/8, got, want[off:off+8])
+			}
+			back, _ := dec.DecryptBlock(want[off : off+8])
+			if !bytes.Equal(back, plaintext[off:off+8]) {
+				t.Errorf("%s block %d: decrypted %X, want %X", tt.name, off/8, back, plaintext[off:off+8])
+			}
+		}
+	}
+
+	// Двухключевой EDE совпадает с crypto/des на ключе K1‖K2‖K1
+	std, _ := stddes.NewTripleDESCipher(mustHex(t, k1+k2+k1))
+	c := newTripleDES()
+	c.SetEncryptionKey(mustHex(t, k1+k2))
+	want := make([]byte, 8)
+	std.Encrypt(want, plaintext)
+	if got, _ := c.EncryptBlock(plaintext[:8]); !bytes.Equal(got, want) {
+		t.Errorf("EDE2: got %X, crypto/des %X", got, want)
+	}
+}
+
+func TestDetectKeyingOption(t *testing.T) {
+	const (
+		k1 = "0123456789ABCDEF"
+		k2 = "23456789ABCDEF01"
+		k3 = "456789ABCDEF0123"
+	)
+	for _, tt := range []struct {
+		key  string
+		want KeyingOption
+	}{
+		{k1 + k2 + k3, KeyingOption1},
+		{k1 + k2, KeyingOption2},
+		{k1, KeyingOption3},
+		// SP 800-67: K3 = K1 — это два независимых ключа, в том числе
+		// когда K1 и K3 различаются только битами чётности
+		{k1 + k2 + k1, KeyingOption2},
+		{k1 + k2 + "0022446688AACCEE", KeyingOption2},
+		{k1 + k1 + k1, KeyingOption3},
+		{k1 + k1, KeyingOption3},
+		{k1 + k1 + k3, KeyingOption1},
+	} {
+		if got, err := DetectKeyingOption(mustHex(t, tt.key)); err != nil || got != tt.want {
+			t.Errorf("%s: got %d, %v; want %d", tt.key, got, err, tt.want)
+		}
+	}
+
+	c := newTripleDES()
+	if err := c.SetEncryptionKey(mustHex(t, k1+k2+k1)); err != nil {
+		t.Fatal(err)
+	}
+	if c.KeyingOption() != KeyingOption2 {
+		t.Errorf("K3 = K1: keying option %d, want %d", c.KeyingOption(), KeyingOption2)
+	}
+	for _, size := range []int{0, 7, 12, 32} {
+		if _, err := DetectKeyingOption(make([]byte, size)); err == nil {
+			t.Errorf("%d bytes: expected error", size)
+		}
+		if err := newTripleDES().SetEncryptionKey(make([]byte, size)); err == nil {
+			t.Errorf("%d bytes: SetEncryptionKey accepted the key", size)
+		}
+	}
+}
+
+// Ключ из 8 байт в режиме EDE вырождается в DES и отвергается строгой
+// политикой; в EEE повтор ключа не сводит шифр к одинарному DES
+func TestTripleDESSingleKeyPolicy(t *testing.T) {
+	key := mustHex(t, "0123456789ABCDEF")
+
+	ede := newTripleDES()
+	ede.SetKeyPolicy(des.StrictKeys)
+	if err := ede.SetEncryptionKey(key); !errors.Is(err, des.ErrInsecureKey) {
+		t.Errorf("EDE strict: got %v, want ErrInsecureKey", err)
+	}
+
+	eee := NewTripleDESVariant(des.NewDES(), des.NewDES(), des.NewDES(), EEE)
+	eee.SetKeyPolicy(des.StrictKeys)
+	if err := eee.SetEncryptionKey(key); err != nil {
+		t.Errorf("EEE strict: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package attack
+
+import (
+	"encoding/binary"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+// Раундовая функция DES на uint32/uint64 для анализа: биты нумеруются от
+// старшего, как в таблицах стандарта. S-блок j получает биты 6j..6j+5
+// расширенного значения и выдаёт биты 4j..4j+3 до перестановки P.
+
+// sboxTable S-блоки с 6-битным входом в порядке битов стандарта
+var sboxTable = func() (t [8][64]uint8) {
+	for j := range t {
+		for x := 0; x < 64; x++ {
+			row := x>>4&2 | x&1
+			col := x >> 1 & 0xF
+			t[j][x] = uint8(des.SBoxes[j][row][col])
+		}
+	}
+	return t
+}()
+
+// pTable и pInverse P и P^-1: позиция источника (с нуля) для каждого бита результата
+var pTable, pInverse = func() (p, inv [32]int) {
+	for i, src := range des.P {
+		p[i] = src - 1
+		inv[src-1] = i
+	}
+	return p, inv
+}()
+
+// expand расширение E: 32 → 48 бит
+func expand(x uint32) uint64 {
+	var out uint64
+	for _, src := range des.Expansion {
+		out = out<<1 | uint64(x>>(32-src)&1)
+	}
+	return out
+}
+
+// permute32 переставляет биты x по таблице источников с нуля
+func permute32(x uint32, table *[32]int) uint32 {
+	var out uint32
+	for _, src := range table {
+		out = out<<1 | x>>(31-src)&1
+	}
+	return out
+}
+
+// sboxField 6 бит S-блока j из 48-битного значения
+func sboxField(v uint64, j int) uint8 {
+	return uint8(v>>(42-6*j)) & 0x3F
+}
+
+// nibble 4 бита выхода S-блока j из 32-битного значения до P
+func nibble(x uint32, j int) uint8 {
+	return uint8(x>>(28-4*j)) & 0xF
+}
+
+// ActiveSBoxes номера S-блоков (с нуля), на вход которых после расширения E
+// попадает ненулевая часть x
+func ActiveSBoxes(x uint32) []int {
+	e := expand(x)
+	var active []int
+	for j := 0; j < 8; j++ {
+		if sboxField(e, j) != 0 {
+			active = append(active, j)
+		}
+	}
+	return active
+}
+
+// sboxMask маска 6 бит S-блока j в 48-битном раундовом ключе
+func sboxMask(j int) uint64 {
+	return uint64(0x3F) << (42 - 6*j)
+}
+
+// toState применяет IP к блоку и возвращает половины L и R
+func toState(block []byte) (uint32, uint32) {
+	permuted, _ := des.IPPermutation.Permute(block)
+	x := binary.BigEndian.Uint64(permuted)
+	return uint32(x >> 32), uint32(x)
+}
+
+// fromState собирает блок из половин L и R и применяет IP^-1
+func fromState(left, right uint32) []byte {
+	block := make([]byte, 8)
+	binary.BigEndian.PutUint64(block, uint64(left)<<32|uint64(right))
+	out, _ := des.IPInversePermutation.Permute(block)
+	return out
+}
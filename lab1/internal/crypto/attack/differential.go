@@ -0,0 +1,530 @@
+package attack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// DifferenceTable таблица распределения разностей S-блока: элемент [a][b] —
+// число входов x, для которых S(x) ⊕ S(x ⊕ a) = b
+type DifferenceTable [64][16]int
+
+var differenceTables = func() (t [8]DifferenceTable) {
+	for j := range t {
+		for x := 0; x < 64; x++ {
+			for a := 0; a < 64; a++ {
+				t[j][a][sboxTable[j][x]^sboxTable[j][x^a]]++
+			}
+		}
+	}
+	return t
+}()
+
+// DESDifferenceTables таблицы распределения разностей восьми S-блоков DES
+func DESDifferenceTables() [8]DifferenceTable {
+	return differenceTables
+}
+
+// Probability вероятность перехода разности in в out
+func (t *DifferenceTable) Probability(in, out uint8) float64 {
+	return float64(t[in][out]) / 64
+}
+
+// RoundDifference переход разностей через функцию f одного раунда
+type RoundDifference struct {
+	In          uint32  // разность на входе f
+	Out         uint32  // разность на выходе f (после P)
+	Probability float64 // вероятность перехода
+}
+
+// Characteristic дифференциальная характеристика сети Фейстеля DES без IP:
+// разность (InputL, InputR) после заданных раундов переходит в
+// (OutputL, OutputR) с вероятностью Probability
+type Characteristic struct {
+	InputL, InputR   uint32
+	Rounds           []RoundDifference
+	OutputL, OutputR uint32
+	Probability      float64
+}
+
+func (c Characteristic) String() string {
+	return fmt.Sprintf("(%08X, %08X) → (%08X, %08X), p = 2^%.2f",
+		c.InputL, c.InputR, c.OutputL, c.OutputR, math.Log2(c.Probability))
+}
+
+// SearchOptions ограничения поиска характеристик
+type SearchOptions struct {
+	MaxActive      int     // наибольшее число активных S-блоков в раунде, 0 — 1
+	Limit          int     // сколько лучших характеристик вернуть, 0 — 256
+	MinProbability float64 // порог вероятности, 0 — 2^-32
+}
+
+// SearchCharacteristics ищет наиболее вероятные характеристики на rounds
+// раундов ветвями и границами. Разность на входе f каждого раунда должна
+// активировать не больше MaxActive S-блоков. Результат отсортирован по
+// убыванию вероятности.
+func SearchCharacteristics(rounds int, opts SearchOptions) ([]Characteristic, error) {
+	if rounds < 1 {
+		return nil, errors.New("attack: characteristic must cover at least one round")
+	}
+	if opts.MaxActive == 0 {
+		opts.MaxActive = 1
+	}
+	if opts.MaxActive < 1 || opts.MaxActive > 3 {
+		return nil, errors.New("attack: MaxActive must be between 1 and 3")
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 256
+	}
+	if opts.MinProbability == 0 {
+		opts.MinProbability = math.Exp2(-32)
+	}
+
+	s := &searcher{
+		rounds: rounds,
+		opts:   opts,
+		inputs: append([]uint32{0}, lowWeightInputs(opts.MaxActive)...),
+		bound:  opts.MinProbability,
+	}
+	for _, x1 := range s.inputs {
+		s.first(x1)
+	}
+	s.trim()
+	return s.found, nil
+}
+
+// searcher состояние поиска характеристик. Разности на входе f обозначены
+// x_1..x_rounds, x_0 = ΔL0; x_(i+1) = x_(i-1) ⊕ y_i, итог — (x_r, x_(r+1)).
+type searcher struct {
+	rounds int
+	opts   SearchOptions
+	inputs []uint32 // допустимые разности на входе f, включая 0
+	path   []RoundDifference
+	found  []Characteristic
+	bound  float64 // характеристики с меньшей вероятностью отбрасываются
+}
+
+// first перебирает переходы первого раунда: x_2 выбирается свободно, потому
+// что ΔL0 = x_2 ⊕ y_1
+func (s *searcher) first(x1 uint32) {
+	for _, t := range transitions(x1) {
+		if t.Probability < s.bound {
+			continue
+		}
+		s.path = append(s.path[:0], t)
+		for _, x2 := range s.inputs {
+			if x1 == 0 && x2 == 0 {
+				continue
+			}
+			s.next(x2^t.Out, x1, x2, t.Probability)
+		}
+	}
+}
+
+// next продолжает характеристику раундом с входной разностью x при
+// предыдущей разности prev
+func (s *searcher) next(inputL, prev, x uint32, p float64) {
+	round := len(s.path) + 1
+	if round > s.rounds {
+		s.record(inputL, prev, x, p)
+		return
+	}
+	for _, t := range transitions(x) {
+		q := p * t.Probability
+		if q < s.bound {
+			continue
+		}
+		following := prev ^ t.Out
+		if round < s.rounds && len(ActiveSBoxes(following)) > s.opts.MaxActive {
+			continue
+		}
+		s.path = append(s.path, t)
+		s.next(inputL, x, following, q)
+		s.path = s.path[:len(s.path)-1]
+	}
+}
+
+func (s *searcher) record(inputL, last, following uint32, p float64) {
+	s.found = append(s.found, Characteristic{
+		InputL:      inputL,
+		InputR:      s.path[0].In,
+		Rounds:      slices.Clone(s.path),
+		OutputL:     last,
+		OutputR:     following,
+		Probability: p,
+	})
+	if len(s.found) >= 4*s.opts.Limit {
+		s.trim()
+		s.bound = max(s.bound, s.found[len(s.found)-1].Probability)
+	}
+}
+
+// trim оставляет Limit лучших характеристик
+func (s *searcher) trim() {
+	slices.SortStableFunc(s.found, func(a, b Characteristic) int {
+		return -cmpFloat(a.Probability, b.Probability)
+	})
+	if len(s.found) > s.opts.Limit {
+		s.found = s.found[:s.opts.Limit]
+	}
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// lowWeightInputs все ненулевые 32-битные разности, активирующие не больше
+// maxActive S-блоков. Бит R может попасть только в S-блоки выбранного
+// множества, поэтому перебираются подмножества его «собственных» битов.
+func lowWeightInputs(maxActive int) []uint32 {
+	seen := map[uint32]bool{}
+	var inputs []uint32
+	var walk func(start int, set []int)
+	walk = func(start int, set []int) {
+		if len(set) > 0 {
+			var allowed []int
+			for bit := 0; bit < 32; bit++ {
+				inside := true
+				for _, j := range ActiveSBoxes(uint32(1) << (31 - bit)) {
+					if !slices.Contains(set, j) {
+						inside = false
+					}
+				}
+				if inside {
+					allowed = append(allowed, bit)
+				}
+			}
+			for m := 1; m < 1<<len(allowed); m++ {
+				var x uint32
+				for i, bit := range allowed {
+					if m>>i&1 == 1 {
+						x |= 1 << (31 - bit)
+					}
+				}
+				if !seen[x] {
+					seen[x] = true
+					inputs = append(inputs, x)
+				}
+			}
+		}
+		if len(set) == maxActive {
+			return
+		}
+		for j := start; j < 8; j++ {
+			walk(j+1, append(set, j))
+		}
+	}
+	walk(0, nil)
+	slices.Sort(inputs)
+	return inputs
+}
+
+// transitions возможные разности на выходе f для входной разности x
+// с вероятностями, по убыванию вероятности
+func transitions(x uint32) []RoundDifference {
+	e := expand(x)
+	out := []RoundDifference{{In: x, Probability: 1}}
+	for j := 0; j < 8; j++ {
+		in := sboxField(e, j)
+		if in == 0 {
+			continue
+		}
+		var next []RoundDifference
+		for _, t := range out {
+			for b := uint8(1); b < 16; b++ {
+				if n := differenceTables[j][in][b]; n > 0 {
+					next = append(next, RoundDifference{
+						In:          x,
+						Out:         t.Out | uint32(b)<<(28-4*j),
+						Probability: t.Probability * float64(n) / 64,
+					})
+				}
+			}
+		}
+		out = next
+	}
+	for i := range out {
+		out[i].Out = permute32(out[i].Out, &pTable)
+	}
+	slices.SortStableFunc(out, func(a, b RoundDifference) int {
+		return -cmpFloat(a.Probability, b.Probability)
+	})
+	return out
+}
+
+// DifferentialAttack восстанавливает биты ключа последнего раунда DES
+// с уменьшенным числом раундов по парам выбранных открытых текстов.
+//
+// Используется характеристика на Rounds-3 раунда с итоговой разностью
+// (L', R'). В следующем раунде S-блоки, неактивные для E(R'), дают нулевую
+// разность, поэтому у правильной пары разность на выходе этих S-блоков
+// в последнем раунде равна P^-1(ΔR_r ⊕ L'). Для каждого такого S-блока
+// подсчитываются 6-битные ключи, согласованные с парой; правильный ключ
+// набирает голоса всех правильных пар. Пары, для которых переход
+// невозможен по таблице разностей, отбрасываются.
+type DifferentialAttack struct {
+	Oracle          core.SymmetricCipher // DES на Rounds раундов с неизвестным ключом
+	Rounds          int                  // не меньше 4
+	Characteristics []Characteristic     // на Rounds-3 раунда; nil — выбрать поиском
+	Rand            *rand.Rand           // источник открытых текстов; nil — от текущего времени
+	BatchSize       int                  // пар между проверками останова, 0 — 8
+	MaxPairs        int                  // предел пар на характеристику, 0 — 1<<14
+}
+
+// SBoxRecovery найденные 6 бит ключа последнего раунда для S-блока
+type SBoxRecovery struct {
+	SBox           int   // номер S-блока с нуля
+	Key            uint8 // 6 бит раундового ключа
+	Votes          int   // голоса за найденный ключ
+	RunnerUp       int   // голоса за следующий по числу голосов ключ
+	Characteristic int   // индекс характеристики в DifferentialResult.Characteristics
+}
+
+// DifferentialResult итог атаки
+type DifferentialResult struct {
+	Subkey          uint64 // найденные биты 48-битного ключа последнего раунда
+	Mask            uint64 // какие биты Subkey найдены
+	SBoxes          []SBoxRecovery
+	Characteristics []Characteristic
+	Pairs           int // зашифрованных пар выбранных открытых текстов
+	Discarded       int // пар, отброшенных фильтром
+	Duration        time.Duration
+}
+
+// Bits позиции найденных битов ключа последнего раунда (с единицы, от
+// старшего бита, как в PC-2)
+func (r *DifferentialResult) Bits() []int {
+	return maskBits(r.Mask)
+}
+
+// maskBits позиции единичных битов 48-битной маски (с единицы, от старшего)
+func maskBits(mask uint64) []int {
+	var bits []int
+	for i := 0; i < 48; i++ {
+		if mask>>(47-i)&1 == 1 {
+			bits = append(bits, i+1)
+		}
+	}
+	return bits
+}
+
+// Run выполняет атаку
+func (a *DifferentialAttack) Run() (*DifferentialResult, error) {
+	if a.Oracle == nil || a.Oracle.BlockSize() != 8 {
+		return nil, errors.New("attack: differential attack requires a DES oracle")
+	}
+	if a.Rounds < 4 {
+		return nil, errors.New("attack: differential attack needs at least 4 rounds")
+	}
+	chars := a.Characteristics
+	if chars == nil {
+		var err error
+		if chars, err = ChooseCharacteristics(a.Rounds - 3); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range chars {
+		if len(c.Rounds) != a.Rounds-3 {
+			return nil, fmt.Errorf("attack: characteristic covers %d rounds, want %d", len(c.Rounds), a.Rounds-3)
+		}
+	}
+	rng := a.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	start := time.Now()
+	res := &DifferentialResult{Characteristics: chars}
+	for i, c := range chars {
+		var targets []int
+		for _, j := range inactiveSBoxes(c.OutputR) {
+			if res.Mask&sboxMask(j) == 0 {
+				targets = append(targets, j)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if err := a.recover(c, i, targets, rng, res); err != nil {
+			return nil, err
+		}
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// recover набирает пары по характеристике c, пока каждый S-блок из targets
+// не получит ключ с отрывом от остальных
+func (a *DifferentialAttack) recover(c Characteristic, index int, targets []int, rng *rand.Rand, res *DifferentialResult) error {
+	batch := a.BatchSize
+	if batch <= 0 {
+		batch = 8
+	}
+	maxPairs := a.MaxPairs
+	if maxPairs <= 0 {
+		maxPairs = 1 << 14
+	}
+
+	counts := make([][64]int, 8)
+	for pairs := 0; ; {
+		for n := 0; n < batch; n++ {
+			l0, r0 := rng.Uint32(), rng.Uint32()
+			c1, err := a.Oracle.EncryptBlock(fromState(l0, r0))
+			if err != nil {
+				return err
+			}
+			c2, err := a.Oracle.EncryptBlock(fromState(l0^c.InputL, r0^c.InputR))
+			if err != nil {
+				return err
+			}
+			pairs++
+			res.Pairs++
+
+			// После IP шифртекст равен R_r ‖ L_r
+			rr1, lr1 := toState(c1)
+			rr2, lr2 := toState(c2)
+			e1, e2 := expand(lr1), expand(lr2)
+			outDiff := permute32(rr1^rr2^c.OutputL, &pInverse)
+
+			if !possible(targets, e1^e2, outDiff) {
+				res.Discarded++
+				continue
+			}
+			for _, j := range targets {
+				in1, in2, out := sboxField(e1, j), sboxField(e2, j), nibble(outDiff, j)
+				if in1 == in2 {
+					// Нулевая разность на входе согласуется с любым ключом
+					continue
+				}
+				for k := uint8(0); k < 64; k++ {
+					if sboxTable[j][in1^k]^sboxTable[j][in2^k] == out {
+						counts[j][k]++
+					}
+				}
+			}
+		}
+
+		done := true
+		for _, j := range targets {
+			if _, votes, runnerUp := leader(&counts[j]); !separated(votes, runnerUp, c.Probability) {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+		if pairs >= maxPairs {
+			return fmt.Errorf("attack: no clear key after %d pairs for characteristic %v", pairs, c)
+		}
+	}
+
+	for _, j := range targets {
+		key, votes, runnerUp := leader(&counts[j])
+		res.SBoxes = append(res.SBoxes, SBoxRecovery{
+			SBox: j, Key: key, Votes: votes, RunnerUp: runnerUp, Characteristic: index,
+		})
+		res.Subkey |= uint64(key) << (42 - 6*j)
+		res.Mask |= sboxMask(j)
+	}
+	slices.SortFunc(res.SBoxes, func(a, b SBoxRecovery) int { return a.SBox - b.SBox })
+	return nil
+}
+
+// possible проверяет по таблицам разностей, что переход в каждом S-блоке
+// из targets возможен
+func possible(targets []int, inDiff uint64, outDiff uint32) bool {
+	for _, j := range targets {
+		if differenceTables[j][sboxField(inDiff, j)][nibble(outDiff, j)] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// leader ключ с наибольшим числом голосов и число голосов у двух первых мест
+func leader(counts *[64]int) (key uint8, votes, runnerUp int) {
+	for k, n := range counts {
+		switch {
+		case n > votes:
+			key, votes, runnerUp = uint8(k), n, votes
+		case n > runnerUp:
+			runnerUp = n
+		}
+	}
+	return key, votes, runnerUp
+}
+
+// separated решает, что ключ с votes голосами отделился от следующего.
+// При вероятности 1 все пары правильные, и достаточно небольшого отрыва;
+// иначе отрыв должен превышать разброс голосов случайных пар.
+func separated(votes, runnerUp int, probability float64) bool {
+	if probability == 1 {
+		return votes >= runnerUp+4
+	}
+	return float64(votes-runnerUp) >= max(8, 4*math.Sqrt(float64(runnerUp)))
+}
+
+// inactiveSBoxes S-блоки, на вход которых разность x не попадает
+func inactiveSBoxes(x uint32) []int {
+	active := ActiveSBoxes(x)
+	var inactive []int
+	for j := 0; j < 8; j++ {
+		if !slices.Contains(active, j) {
+			inactive = append(inactive, j)
+		}
+	}
+	return inactive
+}
+
+// ChooseCharacteristics подбирает характеристики на rounds раундов для атаки:
+// жадно берёт наиболее вероятную характеристику, добавляющую больше всего
+// новых неактивных S-блоков, пока они добавляются
+func ChooseCharacteristics(rounds int) ([]Characteristic, error) {
+	found, err := SearchCharacteristics(rounds, SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var chosen []Characteristic
+	covered := map[int]bool{}
+	for {
+		best, bestGain := -1, 0
+		for i, c := range found {
+			gain := 0
+			for _, j := range inactiveSBoxes(c.OutputR) {
+				if !covered[j] {
+					gain++
+				}
+			}
+			if gain == 0 {
+				continue
+			}
+			if best < 0 || c.Probability > found[best].Probability ||
+				c.Probability == found[best].Probability && gain > bestGain {
+				best, bestGain = i, gain
+			}
+		}
+		if best < 0 {
+			break
+		}
+		chosen = append(chosen, found[best])
+		for _, j := range inactiveSBoxes(found[best].OutputR) {
+			covered[j] = true
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, fmt.Errorf("attack: no usable %d-round characteristic found", rounds)
+	}
+	return chosen, nil
+}
@@ -0,0 +1,188 @@
+package attack
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+	desfeistel "github.com/NikitaKoros/cryptography/lab1/internal/crypto/des/feistel"
+)
+
+func TestDESDifferenceTables(t *testing.T) {
+	for j, table := range DESDifferenceTables() {
+		if table[0][0] != 64 {
+			t.Errorf("S%d: zero difference maps to zero %d times, want 64", j+1, table[0][0])
+		}
+		for a := 0; a < 64; a++ {
+			sum := 0
+			for b, n := range table[a] {
+				sum += n
+				if n%2 != 0 || n > 16 && a != 0 {
+					t.Errorf("S%d: entry [%02X][%X] = %d", j+1, a, b, n)
+				}
+				// Критерий проектирования DES: изменение одного входного бита
+				// меняет не меньше двух выходных
+				if bits.OnesCount(uint(a)) == 1 && bits.OnesCount(uint(b)) == 1 && n != 0 {
+					t.Errorf("S%d: one-bit difference %02X gives one-bit output %X", j+1, a, b)
+				}
+			}
+			if sum != 64 {
+				t.Errorf("S%d: row %02X sums to %d, want 64", j+1, a, sum)
+			}
+		}
+		// S(x) ≠ S(x ⊕ 001100) и S(x) ≠ S(x ⊕ 11ef00) для всех x
+		if table[0x0C][0] != 0 {
+			t.Errorf("S%d: difference 0C maps to zero", j+1)
+		}
+		for a := 0x30; a < 0x40; a += 4 {
+			if table[a][0] != 0 {
+				t.Errorf("S%d: difference %02X maps to zero", j+1, a)
+			}
+		}
+	}
+}
+
+// Поиск находит две 3-раундовые характеристики Бихама и Шамира с p = 1/16
+func TestSearchCharacteristics(t *testing.T) {
+	found, err := SearchCharacteristics(3, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[[2]uint32]bool{{0x40080000, 0x04000000}: false, {0x00200008, 0x00000400}: false}
+	for _, c := range found {
+		if c.Probability != 1.0/16 {
+			break
+		}
+		key := [2]uint32{c.InputL, c.InputR}
+		if _, ok := want[key]; ok {
+			want[key] = true
+			if c.OutputL != c.InputR || c.OutputR != c.InputL {
+				t.Errorf("%v: expected an iterative characteristic", c)
+			}
+		}
+	}
+	for input, ok := range want {
+		if !ok {
+			t.Errorf("characteristic (%08X, %08X) with p = 1/16 not found", input[0], input[1])
+		}
+	}
+
+	// Однораундовые характеристики с p = 1 возможны только при ΔR = 0
+	one, _ := SearchCharacteristics(1, SearchOptions{})
+	if len(one) == 0 || one[0].Probability != 1 {
+		t.Fatal("no 1-round characteristic with probability 1")
+	}
+	for _, c := range one {
+		if (c.Probability == 1) != (c.InputR == 0) {
+			t.Errorf("1-round characteristic %v", c)
+		}
+	}
+	if _, err := SearchCharacteristics(0, SearchOptions{}); err == nil {
+		t.Error("expected error for zero rounds")
+	}
+}
+
+// Доля пар 3-раундового DES, прошедших характеристику, близка к её вероятности
+func TestCharacteristicProbability(t *testing.T) {
+	found, _ := SearchCharacteristics(3, SearchOptions{})
+	c := found[0]
+	cipher, err := desfeistel.NewDESFeistelRounds(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	key := make([]byte, 8)
+	rng.Read(key)
+	cipher.SetEncryptionKey(key)
+
+	const pairs = 4096
+	hits := 0
+	for i := 0; i < pairs; i++ {
+		l0, r0 := rng.Uint32(), rng.Uint32()
+		c1, _ := cipher.EncryptBlock(fromState(l0, r0))
+		c2, _ := cipher.EncryptBlock(fromState(l0^c.InputL, r0^c.InputR))
+		r1, l1 := toState(c1)
+		r2, l2 := toState(c2)
+		if l1^l2 == c.OutputL && r1^r2 == c.OutputR {
+			hits++
+		}
+	}
+	expected := c.Probability * pairs
+	if math.Abs(float64(hits)-expected) > 5*math.Sqrt(expected) {
+		t.Errorf("%v: %d of %d pairs follow it, expected about %.0f", c, hits, pairs, expected)
+	}
+}
+
+func lastRoundKey(t *testing.T, key []byte, rounds int) uint64 {
+	t.Helper()
+	subkeys, err := des.NewDESKeySchedule().ExpandKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var k uint64
+	for _, b := range subkeys[rounds-1] {
+		k = k<<8 | uint64(b)
+	}
+	return k
+}
+
+func TestDifferentialAttack(t *testing.T) {
+	for _, tc := range []struct {
+		rounds   int
+		maxPairs int
+	}{
+		{4, 64},
+		{6, 4096},
+	} {
+		for seed := int64(1); seed <= 3; seed++ {
+			rng := rand.New(rand.NewSource(seed))
+			key := make([]byte, 8)
+			rng.Read(key)
+			oracle, err := desfeistel.NewDESFeistelRounds(tc.rounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := oracle.SetEncryptionKey(key); err != nil {
+				t.Fatal(err)
+			}
+
+			attack := &DifferentialAttack{Oracle: oracle, Rounds: tc.rounds, Rand: rng}
+			res, err := attack.Run()
+			if err != nil {
+				t.Fatalf("%d rounds, key %X: %v", tc.rounds, key, err)
+			}
+			want := lastRoundKey(t, key, tc.rounds)
+			if res.Subkey != want&res.Mask {
+				t.Errorf("%d rounds, key %X: recovered %012X under mask %012X, want %012X",
+					tc.rounds, key, res.Subkey, res.Mask, want&res.Mask)
+			}
+			if len(res.Bits()) < 42 {
+				t.Errorf("%d rounds: recovered only %d subkey bits", tc.rounds, len(res.Bits()))
+			}
+			if res.Pairs > tc.maxPairs {
+				t.Errorf("%d rounds: used %d pairs, expected at most %d", tc.rounds, res.Pairs, tc.maxPairs)
+			}
+			t.Logf("%d rounds: %d pairs (%d discarded), %d bits of K%d", tc.rounds, res.Pairs, res.Discarded, len(res.Bits()), tc.rounds)
+		}
+	}
+}
+
+func TestDifferentialAttackErrors(t *testing.T) {
+	oracle, _ := desfeistel.NewDESFeistelRounds(3)
+	oracle.SetEncryptionKey(make([]byte, 8))
+	if _, err := (&DifferentialAttack{Oracle: oracle, Rounds: 3}).Run(); err == nil {
+		t.Error("expected error for 3 rounds")
+	}
+	one, _ := SearchCharacteristics(1, SearchOptions{})
+	if _, err := (&DifferentialAttack{Oracle: oracle, Rounds: 6, Characteristics: one}).Run(); err == nil {
+		t.Error("expected error for a characteristic of the wrong length")
+	}
+	if _, err := (&DifferentialAttack{Rounds: 4}).Run(); err == nil {
+		t.Error("expected error without oracle")
+	}
+	if _, err := desfeistel.NewDESFeistelRounds(17); err == nil {
+		t.Error("expected error for 17 rounds")
+	}
+}
@@ -0,0 +1,77 @@
+// Package attack учебные атаки на блочные шифры репозитория: встреча
+// посередине на двойное шифрование с уменьшенным пространством ключей,
+// дифференциальный и линейный криптоанализ DES с уменьшенным числом раундов.
+package attack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeySpace уменьшенное пространство ключей: биты ключа на позициях Unknown
+// перебираются, остальные берутся из Base. Позиции нумеруются с нуля от
+// старшего бита первого байта.
+type KeySpace struct {
+	Base    []byte
+	Unknown []int
+}
+
+// NewKeySpace проверяет позиции и возвращает пространство ключей
+func NewKeySpace(base []byte, unknown []int) (KeySpace, error) {
+	if len(base) == 0 {
+		return KeySpace{}, errors.New("attack: empty base key")
+	}
+	if len(unknown) > 32 {
+		return KeySpace{}, fmt.Errorf("attack: at most 32 unknown key bits are supported, got %d", len(unknown))
+	}
+	seen := make(map[int]bool, len(unknown))
+	for _, pos := range unknown {
+		if pos < 0 || pos >= 8*len(base) {
+			return KeySpace{}, fmt.Errorf("attack: key bit %d out of range for %d-byte key", pos, len(base))
+		}
+		if seen[pos] {
+			return KeySpace{}, fmt.Errorf("attack: key bit %d listed twice", pos)
+		}
+		seen[pos] = true
+	}
+	return KeySpace{
+		Base:    append([]byte(nil), base...),
+		Unknown: append([]int(nil), unknown...),
+	}, nil
+}
+
+// DESKeyBits позиции n младших значащих битов ключа DES; биты чётности
+// пропускаются, чтобы разные индексы давали разные расписания ключей
+func DESKeyBits(n int) []int {
+	bits := make([]int, 0, n)
+	for pos := 63; pos >= 0 && len(bits) < n; pos-- {
+		if pos%8 != 7 {
+			bits = append(bits, pos)
+		}
+	}
+	return bits
+}
+
+// Bits число неизвестных битов
+func (s KeySpace) Bits() int {
+	return len(s.Unknown)
+}
+
+// Size число ключей в пространстве
+func (s KeySpace) Size() uint64 {
+	return 1 << len(s.Unknown)
+}
+
+// Key записывает в dst ключ с номером index: бит i номера попадает
+// на позицию Unknown[i]
+func (s KeySpace) Key(dst []byte, index uint64) {
+	copy(dst, s.Base)
+	for i, pos := range s.Unknown {
+		mask := byte(0x80) >> (pos % 8)
+		if index>>i&1 == 1 {
+			dst[pos/8] |= mask
+		} else {
+			dst[pos/8] &^= mask
+		}
+	}
+}
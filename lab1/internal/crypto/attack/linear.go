@@ -0,0 +1,557 @@
+package attack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+// LinearTable таблица линейных приближений S-блока: элемент [a][b] —
+// число входов x, для которых a·x = b·S(x), минус 32
+type LinearTable [64][16]int
+
+var linearTables = func() (t [8]LinearTable) {
+	for j := range t {
+		for a := 0; a < 64; a++ {
+			for b := 0; b < 16; b++ {
+				n := -32
+				for x := 0; x < 64; x++ {
+					if parity(uint64(a&x)) == parity(uint64(b)&uint64(sboxTable[j][x])) {
+						n++
+					}
+				}
+				t[j][a][b] = n
+			}
+		}
+	}
+	return t
+}()
+
+// DESLinearTables таблицы линейных приближений восьми S-блоков DES
+func DESLinearTables() [8]LinearTable {
+	return linearTables
+}
+
+// Bias смещение приближения a·x = b·S(x): вероятность минус 1/2
+func (t *LinearTable) Bias(in, out uint8) float64 {
+	return float64(t[in][out]) / 64
+}
+
+func parity(x uint64) uint8 {
+	return uint8(bits.OnesCount64(x) & 1)
+}
+
+// RoundApproximation линейное приближение функции f одного раунда:
+// In·R ⊕ Out·f(R, K) = Key·K с вероятностью 1/2 + Bias
+type RoundApproximation struct {
+	In   uint32  // маска входа f
+	Out  uint32  // маска выхода f (после P)
+	Key  uint64  // маска 48-битного раундового ключа
+	Bias float64 // смещение
+}
+
+// Approximation линейное приближение сети Фейстеля DES без IP на
+// len(Rounds) раундов: InputL·L0 ⊕ InputR·R0 ⊕ OutputL·Lr ⊕ OutputR·Rr
+// равно ⊕ Rounds[i].Key·K(i+1) с вероятностью 1/2 + Bias
+type Approximation struct {
+	InputL, InputR   uint32
+	Rounds           []RoundApproximation
+	OutputL, OutputR uint32
+	Bias             float64
+}
+
+func (a Approximation) String() string {
+	return fmt.Sprintf("(%08X, %08X) → (%08X, %08X), ε = %+.4f ≈ ±2^%.2f",
+		a.InputL, a.InputR, a.OutputL, a.OutputR, a.Bias, math.Log2(math.Abs(a.Bias)))
+}
+
+// Parity левая часть приближения для состояний до и после раундов
+func (a Approximation) Parity(l0, r0, lr, rr uint32) uint8 {
+	return parity(uint64(a.InputL&l0 ^ a.InputR&r0 ^ a.OutputL&lr ^ a.OutputR&rr))
+}
+
+// KeyParity правая часть приближения для раундовых ключей subkeys
+// (по 6 байт, начиная с первого раунда)
+func (a Approximation) KeyParity(subkeys [][]byte) uint8 {
+	var p uint8
+	for i, r := range a.Rounds {
+		p ^= parity(r.Key & subkey48(subkeys[i]))
+	}
+	return p
+}
+
+func subkey48(key []byte) uint64 {
+	var k uint64
+	for _, b := range key[:6] {
+		k = k<<8 | uint64(b)
+	}
+	return k
+}
+
+// LinearSearchOptions ограничения поиска линейных приближений
+type LinearSearchOptions struct {
+	MaxActive int     // наибольшее число активных S-блоков в раунде, 0 — 1
+	Limit     int     // сколько лучших приближений вернуть, 0 — 256
+	MinBias   float64 // порог |смещения|, 0 — 2^-24
+}
+
+// SearchApproximations ищет приближения на rounds раундов с наибольшим
+// |смещением| ветвями и границами. Маска выхода f каждого раунда должна
+// активировать не больше MaxActive S-блоков. Смещения раундов складываются
+// по лемме о нагромождении. Результат отсортирован по убыванию |смещения|.
+func SearchApproximations(rounds int, opts LinearSearchOptions) ([]Approximation, error) {
+	if rounds < 1 {
+		return nil, errors.New("attack: approximation must cover at least one round")
+	}
+	if opts.MaxActive == 0 {
+		opts.MaxActive = 1
+	}
+	if opts.MaxActive < 1 || opts.MaxActive > 3 {
+		return nil, errors.New("attack: MaxActive must be between 1 and 3")
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 256
+	}
+	if opts.MinBias == 0 {
+		opts.MinBias = math.Exp2(-24)
+	}
+
+	masks := append([]uint32{0}, lowWeightMasks(opts.MaxActive)...)
+	cache := map[uint32][]RoundApproximation{}
+	// best[k] — наибольшая |корреляция| на k раундов; один раунд может быть
+	// пустым, поэтому best[1] = 1
+	best := []float64{1, 1}
+	var s *linearSearcher
+	for k := 1; k <= rounds; k++ {
+		limit := 1
+		if k == rounds {
+			limit = opts.Limit
+		}
+		s = &linearSearcher{
+			rounds: k,
+			limit:  limit,
+			active: opts.MaxActive,
+			masks:  masks,
+			cache:  cache,
+			best:   best,
+			bound:  2 * opts.MinBias,
+		}
+		for _, b1 := range s.masks {
+			s.first(b1)
+		}
+		s.trim()
+		if k >= 2 {
+			if len(s.found) == 0 {
+				return nil, nil
+			}
+			best = append(best, 2*math.Abs(s.found[0].Bias))
+		}
+	}
+	return s.found, nil
+}
+
+// linearSearcher состояние поиска приближений. Маски выхода f обозначены
+// b_1..b_rounds, b_0 = маска R0; b_(i+1) = b_(i-1) ⊕ a_i, где a_i — маска
+// входа f раунда i; итог — (b_(r+1), b_r). Поиск ведётся по корреляции 2ε.
+type linearSearcher struct {
+	rounds int
+	limit  int
+	active int      // MaxActive
+	masks  []uint32 // допустимые маски выхода f, включая 0
+	cache  map[uint32][]RoundApproximation
+	best   []float64 // оценки |корреляции| оставшихся раундов
+	path   []RoundApproximation
+	found  []Approximation
+	bound  float64 // приближения с меньшей |корреляцией| отбрасываются
+}
+
+// first перебирает приближения первого раунда: b_2 выбирается свободно,
+// потому что маска R0 равна b_2 ⊕ a_1
+func (s *linearSearcher) first(b1 uint32) {
+	for _, t := range s.approximations(b1) {
+		c := 2 * t.Bias
+		if math.Abs(c)*s.best[s.rounds-1] < s.bound {
+			break
+		}
+		s.path = append(s.path[:0], t)
+		for _, b2 := range s.masks {
+			if b1 == 0 && b2 == 0 {
+				continue
+			}
+			s.next(b2^t.In, b1, b2, c)
+		}
+	}
+}
+
+// next продолжает приближение раундом с маской выхода f b при маске
+// выхода предыдущего раунда prev
+func (s *linearSearcher) next(inputR, prev, b uint32, c float64) {
+	round := len(s.path) + 1
+	if round > s.rounds {
+		s.record(inputR, prev, b, c)
+		return
+	}
+	for _, t := range s.approximations(b) {
+		q := c * 2 * t.Bias
+		if math.Abs(q)*s.best[s.rounds-round] < s.bound {
+			break
+		}
+		following := prev ^ t.In
+		if round < s.rounds && outputCount(following) > s.active {
+			continue
+		}
+		s.path = append(s.path, t)
+		s.next(inputR, b, following, q)
+		s.path = s.path[:len(s.path)-1]
+	}
+}
+
+func (s *linearSearcher) record(inputR, last, following uint32, c float64) {
+	if math.Abs(c) == 1 {
+		// Все раунды пустые: тождество без битов ключа
+		return
+	}
+	s.found = append(s.found, Approximation{
+		InputL:  s.path[0].Out,
+		InputR:  inputR,
+		Rounds:  slices.Clone(s.path),
+		OutputL: following,
+		OutputR: last,
+		Bias:    c / 2,
+	})
+	if len(s.found) >= 4*s.limit {
+		s.trim()
+		s.bound = max(s.bound, 2*math.Abs(s.found[len(s.found)-1].Bias))
+	}
+}
+
+// trim оставляет Limit лучших приближений
+func (s *linearSearcher) trim() {
+	slices.SortStableFunc(s.found, func(a, b Approximation) int {
+		return -cmpFloat(math.Abs(a.Bias), math.Abs(b.Bias))
+	})
+	if len(s.found) > s.limit {
+		s.found = s.found[:s.limit]
+	}
+}
+
+func (s *linearSearcher) approximations(b uint32) []RoundApproximation {
+	if cached, ok := s.cache[b]; ok {
+		return cached
+	}
+	out := roundApproximations(b)
+	s.cache[b] = out
+	return out
+}
+
+// pInverseBytes P^-1 по байтам: перестановка битов линейна по XOR
+var pInverseBytes = func() (t [4][256]uint32) {
+	for i := range t {
+		for v := range t[i] {
+			t[i][v] = permute32(uint32(v)<<(24-8*i), &pInverse)
+		}
+	}
+	return t
+}()
+
+// outputCount число S-блоков, выходы которых попадают в маску b выхода f
+func outputCount(b uint32) int {
+	z := pInverseBytes[0][b>>24] ^ pInverseBytes[1][b>>16&0xFF] ^
+		pInverseBytes[2][b>>8&0xFF] ^ pInverseBytes[3][b&0xFF]
+	z = (z | z>>1 | z>>2 | z>>3) & 0x11111111
+	return bits.OnesCount32(z)
+}
+
+// outputActive S-блоки, выходы которых попадают в маску b выхода f
+func outputActive(b uint32) []int {
+	z := permute32(b, &pInverse)
+	var active []int
+	for j := 0; j < 8; j++ {
+		if nibble(z, j) != 0 {
+			active = append(active, j)
+		}
+	}
+	return active
+}
+
+// lowWeightMasks все ненулевые маски выхода f, затрагивающие не больше
+// maxActive S-блоков: выходы S-блоков маскируются до перестановки P
+func lowWeightMasks(maxActive int) []uint32 {
+	var masks []uint32
+	var walk func(start int, z uint32, n int)
+	walk = func(start int, z uint32, n int) {
+		if z != 0 {
+			masks = append(masks, permute32(z, &pTable))
+		}
+		if n == maxActive {
+			return
+		}
+		for j := start; j < 8; j++ {
+			for b := uint32(1); b < 16; b++ {
+				walk(j+1, z|b<<(28-4*j), n+1)
+			}
+		}
+	}
+	walk(0, 0, 0)
+	slices.Sort(masks)
+	return masks
+}
+
+// roundApproximations приближения f с маской выхода b и ненулевым
+// смещением, по убыванию |смещения|
+func roundApproximations(b uint32) []RoundApproximation {
+	z := permute32(b, &pInverse)
+	type partial struct {
+		key uint64
+		c   float64
+	}
+	out := []partial{{c: 1}}
+	for j := 0; j < 8; j++ {
+		mask := nibble(z, j)
+		if mask == 0 {
+			continue
+		}
+		var next []partial
+		for _, p := range out {
+			for a := uint64(1); a < 64; a++ {
+				if n := linearTables[j][a][mask]; n != 0 {
+					next = append(next, partial{key: p.key | a<<(42-6*j), c: p.c * float64(n) / 32})
+				}
+			}
+		}
+		out = next
+	}
+	res := make([]RoundApproximation, len(out))
+	for i, p := range out {
+		res[i] = RoundApproximation{In: expandTranspose(p.key), Out: b, Key: p.key, Bias: p.c / 2}
+	}
+	slices.SortStableFunc(res, func(a, b RoundApproximation) int {
+		return -cmpFloat(math.Abs(a.Bias), math.Abs(b.Bias))
+	})
+	return res
+}
+
+// expandTranspose маска на R, для которой (маска)·R = m·E(R)
+func expandTranspose(m uint64) uint32 {
+	var x uint32
+	for i, src := range des.Expansion {
+		if m>>(47-i)&1 == 1 {
+			x ^= 1 << (32 - src)
+		}
+	}
+	return x
+}
+
+// BestApproximation приближение на rounds раундов с наибольшим |смещением|,
+// у которого маска OutputL затрагивает не больше maxTargets S-блоков
+// (0 — без ограничения). Такие S-блоки угадываются в Algorithm2.
+func BestApproximation(rounds, maxTargets int) (Approximation, error) {
+	found, err := SearchApproximations(rounds, LinearSearchOptions{})
+	if err != nil {
+		return Approximation{}, err
+	}
+	for _, a := range found {
+		if maxTargets == 0 || len(outputActive(a.OutputL)) <= maxTargets {
+			return a, nil
+		}
+	}
+	return Approximation{}, fmt.Errorf("attack: no usable %d-round approximation found", rounds)
+}
+
+// LinearAttack линейный криптоанализ Мацуи DES с уменьшенным числом раундов
+// по известным открытым текстам.
+//
+// Algorithm1 использует приближение на все Rounds раундов и по большинству
+// значений левой части находит один бит — сумму битов раундовых ключей.
+// Algorithm2 использует приближение на Rounds-1 раундов, а последний раунд
+// вычисляет для каждого значения 6 бит ключа каждого S-блока, затронутого
+// маской OutputL; правильный ключ даёт наибольшее отклонение счётчика от N/2.
+type LinearAttack struct {
+	Rounds        int            // число раундов шифра, не меньше 1 (Algorithm2 — 2)
+	Approximation *Approximation // nil — выбрать поиском
+}
+
+// LinearCandidate значение угадываемых битов ключа последнего раунда
+type LinearCandidate struct {
+	Subkey uint64 // биты под маской LinearResult.Mask
+	Count  int    // пар с нулевой левой частью
+}
+
+// LinearResult итог атаки
+type LinearResult struct {
+	Approximation Approximation
+	KeyParity     uint8  // найденное значение ⊕ Rounds[i].Key·K(i+1)
+	Subkey        uint64 // найденные биты ключа последнего раунда (Algorithm2)
+	Mask          uint64 // какие биты Subkey найдены
+	Count         int    // пар с нулевой левой частью у лучшего кандидата
+	Pairs         int
+	Candidates    []LinearCandidate // по убыванию |Count - Pairs/2| (Algorithm2)
+	Duration      time.Duration
+}
+
+// Bits позиции найденных битов ключа последнего раунда (с единицы, от
+// старшего бита, как в PC-2)
+func (r *LinearResult) Bits() []int {
+	return maskBits(r.Mask)
+}
+
+// Rank место значения subkey среди кандидатов (с нуля), -1 — нет среди них
+func (r *LinearResult) Rank(subkey uint64) int {
+	for i, c := range r.Candidates {
+		if c.Subkey == subkey&r.Mask {
+			return i
+		}
+	}
+	return -1
+}
+
+// Algorithm1 находит бит KeyParity по известным парам
+func (a *LinearAttack) Algorithm1(pairs []Pair) (*LinearResult, error) {
+	if a.Rounds < 1 {
+		return nil, errors.New("attack: linear attack needs at least 1 round")
+	}
+	approx, err := a.approximation(a.Rounds, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPairs(pairs); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	count := 0
+	for _, p := range pairs {
+		l0, r0 := toState(p.Plaintext)
+		// После IP шифртекст равен R_r ‖ L_r
+		rr, lr := toState(p.Ciphertext)
+		if approx.Parity(l0, r0, lr, rr) == 0 {
+			count++
+		}
+	}
+	return &LinearResult{
+		Approximation: approx,
+		KeyParity:     guessParity(count, len(pairs), approx.Bias),
+		Count:         count,
+		Pairs:         len(pairs),
+		Duration:      time.Since(start),
+	}, nil
+}
+
+// Algorithm2 находит биты ключа последнего раунда и бит KeyParity
+// приближения на Rounds-1 раундов. Пары группируются по битам E(L_r),
+// нужным угадываемым S-блокам, поэтому каждый кандидат проверяется за
+// число групп, а не пар.
+func (a *LinearAttack) Algorithm2(pairs []Pair) (*LinearResult, error) {
+	if a.Rounds < 2 {
+		return nil, errors.New("attack: Algorithm 2 needs at least 2 rounds")
+	}
+	approx, err := a.approximation(a.Rounds-1, 2)
+	if err != nil {
+		return nil, err
+	}
+	targets := outputActive(approx.OutputL)
+	if len(targets) == 0 || len(targets) > 2 {
+		return nil, fmt.Errorf("attack: OutputL of the approximation touches %d S-boxes, want 1 or 2", len(targets))
+	}
+	if err := checkPairs(pairs); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	width := 6 * len(targets)
+	// counts[parity][биты E(L_r) целевых S-блоков]
+	counts := [2][]int{make([]int, 1<<width), make([]int, 1<<width)}
+	for _, p := range pairs {
+		l0, r0 := toState(p.Plaintext)
+		rr, lr := toState(p.Ciphertext)
+		// L_(r-1) = R_r ⊕ f(L_r, K_r), R_(r-1) = L_r
+		fixed := parity(uint64(approx.InputL&l0 ^ approx.InputR&r0 ^ approx.OutputL&rr ^ approx.OutputR&lr))
+		e := expand(lr)
+		idx := 0
+		for _, j := range targets {
+			idx = idx<<6 | int(sboxField(e, j))
+		}
+		counts[fixed][idx]++
+	}
+
+	// part[v] = OutputL·f для входов v целевых S-блоков после сложения с ключом
+	z := permute32(approx.OutputL, &pInverse)
+	part := make([]uint8, 1<<width)
+	for v := range part {
+		for i, j := range targets {
+			in := v >> (6 * (len(targets) - 1 - i)) & 0x3F
+			part[v] ^= parity(uint64(nibble(z, j) & sboxTable[j][in]))
+		}
+	}
+
+	res := &LinearResult{Approximation: approx, Pairs: len(pairs)}
+	res.Candidates = make([]LinearCandidate, 1<<width)
+	for g := range res.Candidates {
+		count := 0
+		for idx := range part {
+			count += counts[part[idx^g]][idx]
+		}
+		var subkey uint64
+		for i, j := range targets {
+			subkey |= uint64(g>>(6*(len(targets)-1-i))&0x3F) << (42 - 6*j)
+		}
+		res.Candidates[g] = LinearCandidate{Subkey: subkey, Count: count}
+	}
+	half := float64(len(pairs)) / 2
+	slices.SortStableFunc(res.Candidates, func(x, y LinearCandidate) int {
+		return -cmpFloat(math.Abs(float64(x.Count)-half), math.Abs(float64(y.Count)-half))
+	})
+
+	best := res.Candidates[0]
+	for _, j := range targets {
+		res.Mask |= sboxMask(j)
+	}
+	res.Subkey = best.Subkey
+	res.Count = best.Count
+	res.KeyParity = guessParity(best.Count, len(pairs), approx.Bias)
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+func (a *LinearAttack) approximation(rounds, maxTargets int) (Approximation, error) {
+	if a.Approximation == nil {
+		return BestApproximation(rounds, maxTargets)
+	}
+	if len(a.Approximation.Rounds) != rounds {
+		return Approximation{}, fmt.Errorf("attack: approximation covers %d rounds, want %d", len(a.Approximation.Rounds), rounds)
+	}
+	return *a.Approximation, nil
+}
+
+func checkPairs(pairs []Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("attack: at least one plaintext/ciphertext pair is required")
+	}
+	for _, p := range pairs {
+		if len(p.Plaintext) != 8 || len(p.Ciphertext) != 8 {
+			return errors.New("attack: plaintext and ciphertext must be 8 bytes")
+		}
+	}
+	return nil
+}
+
+// guessParity значение правой части по числу count пар с нулевой левой
+// частью: при положительном смещении большинство нулей означает ноль
+func guessParity(count, pairs int, bias float64) uint8 {
+	if (2*count > pairs) == (bias < 0) {
+		return 1
+	}
+	return 0
+}
+
+// LinearSuccessProbability вероятность успеха Algorithm1 на n известных
+// текстах при смещении bias (лемма 2 Мацуи): Φ(2·√n·|ε|)
+func LinearSuccessProbability(n int, bias float64) float64 {
+	x := 2 * math.Sqrt(float64(n)) * math.Abs(bias)
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
@@ -0,0 +1,233 @@
+package attack
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+	desfeistel "github.com/NikitaKoros/cryptography/lab1/internal/crypto/des/feistel"
+)
+
+func TestDESLinearTables(t *testing.T) {
+	tables := DESLinearTables()
+	strongest := 0
+	for j, table := range tables {
+		if table[0][0] != 32 {
+			t.Errorf("S%d: trivial approximation holds %d-32 times, want 32", j+1, table[0][0])
+		}
+		for a := 0; a < 64; a++ {
+			for b := 0; b < 16; b++ {
+				n := table[a][b]
+				if n%2 != 0 {
+					t.Errorf("S%d: entry [%02X][%X] = %d is odd", j+1, a, b, n)
+				}
+				// Выходы S-блоков сбалансированы
+				if (a == 0) != (b == 0) && n != 0 {
+					t.Errorf("S%d: entry [%02X][%X] = %d, want 0", j+1, a, b, n)
+				}
+				if a != 0 && abs(n) > abs(strongest) {
+					strongest = n
+				}
+			}
+		}
+	}
+	// Самое сильное приближение — у S5 (Мацуи): x[4] = S(x)[0..3] с p = 12/64
+	if got := tables[4][0x10][0xF]; got != -20 || strongest != -20 {
+		t.Errorf("NS5(16, 15) - 32 = %d, strongest entry %d, want -20", got, strongest)
+	}
+	if tables[4].Bias(0x10, 0xF) != -20.0/64 {
+		t.Errorf("Bias = %v", tables[4].Bias(0x10, 0xF))
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Лучшие смещения совпадают с таблицей Мацуи: 1.56·2^-3, 1.22·2^-6, 1.95·2^-10
+func TestSearchApproximations(t *testing.T) {
+	for _, tc := range []struct {
+		rounds int
+		bias   float64
+	}{
+		{1, 20.0 / 64},
+		{3, 25.0 / 128},
+		{5, 1250.0 / 65536},
+		{7, 125.0 / 65536},
+	} {
+		found, err := SearchApproximations(tc.rounds, LinearSearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) == 0 {
+			t.Fatalf("%d rounds: nothing found", tc.rounds)
+		}
+		if got := math.Abs(found[0].Bias); got != tc.bias {
+			t.Errorf("%d rounds: best %v, want |ε| = %v", tc.rounds, found[0], tc.bias)
+		}
+		for i, a := range found {
+			if len(a.Rounds) != tc.rounds {
+				t.Fatalf("%v covers %d rounds", a, len(a.Rounds))
+			}
+			if i > 0 && math.Abs(a.Bias) > math.Abs(found[i-1].Bias) {
+				t.Fatalf("%d rounds: results are not sorted", tc.rounds)
+			}
+		}
+	}
+
+	one, _ := SearchApproximations(1, LinearSearchOptions{})
+	if r := one[0].Rounds[0]; r.In != 0x00008000 || r.Out != 0x21040080 || r.Bias != -20.0/64 {
+		t.Errorf("1 round: best round approximation %08X → %08X (ε = %v), want S5: 00008000 → 21040080",
+			r.In, r.Out, r.Bias)
+	}
+	if _, err := SearchApproximations(0, LinearSearchOptions{}); err == nil {
+		t.Error("expected error for zero rounds")
+	}
+}
+
+// knownPairs шифрует n случайных текстов DES на rounds раундах со случайным
+// ключом и возвращает пары и раундовые ключи
+func knownPairs(t *testing.T, rounds, n int, rng *rand.Rand) ([]Pair, [][]byte) {
+	t.Helper()
+	key := make([]byte, 8)
+	rng.Read(key)
+	cipher, err := desfeistel.NewDESFeistelRounds(rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cipher.SetEncryptionKey(key); err != nil {
+		t.Fatal(err)
+	}
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		plaintext := make([]byte, 8)
+		rng.Read(plaintext)
+		ciphertext, err := cipher.EncryptBlock(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs[i] = Pair{Plaintext: plaintext, Ciphertext: ciphertext}
+	}
+	subkeys, err := des.NewDESKeySchedule().ExpandKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pairs, subkeys
+}
+
+// Доля пар 3-раундового DES, для которых выполняется приближение, близка к 1/2 + ε
+func TestApproximationBias(t *testing.T) {
+	found, _ := SearchApproximations(3, LinearSearchOptions{})
+	a := found[0]
+	const n = 4096
+	pairs, subkeys := knownPairs(t, 3, n, rand.New(rand.NewSource(1)))
+	hits := 0
+	for _, p := range pairs {
+		l0, r0 := toState(p.Plaintext)
+		rr, lr := toState(p.Ciphertext)
+		if a.Parity(l0, r0, lr, rr) == a.KeyParity(subkeys) {
+			hits++
+		}
+	}
+	expected := (0.5 + a.Bias) * n
+	if math.Abs(float64(hits)-expected) > 5*math.Sqrt(n/4) {
+		t.Errorf("%v holds for %d of %d pairs, expected about %.0f", a, hits, n, expected)
+	}
+}
+
+func TestLinearAttack(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		rounds, pairs int
+	}{
+		{3, 128},
+		{5, 1 << 13},
+	} {
+		for trial := 0; trial < 3; trial++ {
+			pairs, subkeys := knownPairs(t, tc.rounds, tc.pairs, rng)
+			attack := &LinearAttack{Rounds: tc.rounds}
+
+			r1, err := attack.Algorithm1(pairs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := r1.Approximation.KeyParity(subkeys); r1.KeyParity != want {
+				t.Errorf("%d rounds, Algorithm 1: key parity %d, want %d (%d of %d zeros)",
+					tc.rounds, r1.KeyParity, want, r1.Count, r1.Pairs)
+			}
+
+			r2, err := attack.Algorithm2(pairs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := subkey48(subkeys[tc.rounds-1])
+			if r2.Subkey != last&r2.Mask || r2.Rank(last) != 0 {
+				t.Errorf("%d rounds, Algorithm 2: recovered %012X under mask %012X, want %012X (rank %d)",
+					tc.rounds, r2.Subkey, r2.Mask, last&r2.Mask, r2.Rank(last))
+			}
+			if want := r2.Approximation.KeyParity(subkeys); r2.KeyParity != want {
+				t.Errorf("%d rounds, Algorithm 2: key parity %d, want %d", tc.rounds, r2.KeyParity, want)
+			}
+			if len(r2.Bits()) != 6*len(outputActive(r2.Approximation.OutputL)) {
+				t.Errorf("%d rounds: %d bits recovered", tc.rounds, len(r2.Bits()))
+			}
+		}
+	}
+}
+
+// Атака Мацуи на 8 раундов: приближение на 7 раундов с |ε| = 1.95·2^-10
+// и 2^20 ≈ 4|ε|^-2 известных текстов. При таком N правильный ключ не всегда
+// первый, поэтому проверяется, что он среди первых кандидатов.
+func TestLinearAttackEightRounds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 2^20 encryptions in short mode")
+	}
+	pairs, subkeys := knownPairs(t, 8, 1<<20, rand.New(rand.NewSource(8)))
+	res, err := (&LinearAttack{Rounds: 8}).Algorithm2(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := subkey48(subkeys[7])
+	if rank := res.Rank(last); rank < 0 || rank > 3 {
+		t.Errorf("K8 bits %012X under mask %012X ranked %d, best candidate %012X",
+			last&res.Mask, res.Mask, rank, res.Subkey)
+	}
+}
+
+func TestLinearSuccessProbability(t *testing.T) {
+	// Таблица Мацуи для Algorithm 1: N = |ε|^-2/4, |ε|^-2, 4|ε|^-2
+	bias := math.Exp2(-5)
+	for _, tc := range []struct {
+		n    int
+		want float64
+	}{
+		{1 << 8, 0.841},
+		{1 << 10, 0.977},
+		{1 << 12, 0.99997},
+	} {
+		if got := LinearSuccessProbability(tc.n, bias); math.Abs(got-tc.want) > 1e-3 {
+			t.Errorf("N = %d: %.5f, want %.5f", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestLinearAttackErrors(t *testing.T) {
+	pairs, _ := knownPairs(t, 3, 4, rand.New(rand.NewSource(1)))
+	if _, err := (&LinearAttack{}).Algorithm1(pairs); err == nil {
+		t.Error("expected error for zero rounds")
+	}
+	if _, err := (&LinearAttack{Rounds: 1}).Algorithm2(pairs); err == nil {
+		t.Error("expected error for Algorithm 2 on one round")
+	}
+	if _, err := (&LinearAttack{Rounds: 3}).Algorithm1(nil); err == nil {
+		t.Error("expected error without pairs")
+	}
+	found, _ := SearchApproximations(2, LinearSearchOptions{})
+	if _, err := (&LinearAttack{Rounds: 4, Approximation: &found[0]}).Algorithm1(pairs); err == nil {
+		t.Error("expected error for an approximation of the wrong length")
+	}
+}
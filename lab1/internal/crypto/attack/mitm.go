@@ -0,0 +1,262 @@
+package attack
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// Pair известная пара открытого текста и шифртекста
+type Pair struct {
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+// MITM атака встречи посередине на двойное шифрование C = E_K2(E_K1(P)).
+//
+// Прямой проход шифрует P первой пары всеми ключами K1 и сортирует
+// промежуточные значения; обратный проход дешифрует C всеми ключами K2 и ищет
+// результат в таблице. Вместо 2^(|K1|+|K2|) операций полного перебора нужно
+// 2^|K1| + 2^|K2| операций и 2^|K1| записей памяти. Совпадения по первой паре
+// проверяются на остальных парах.
+//
+// Partitions задаёт компромисс память/время: таблица строится по частям
+// пространства K1, и на каждую часть выполняется полный обратный проход.
+// Память делится на Partitions, а число дешифрований умножается на него.
+type MITM struct {
+	New        func() core.SymmetricCipher // создаёт шифр с 64-битным блоком
+	K1, K2     KeySpace
+	Workers    int // число горутин, 0 — runtime.NumCPU()
+	Partitions int // число частей прямой таблицы, 0 — одна
+}
+
+// Candidate найденная пара ключей, согласованная со всеми парами текстов
+type Candidate struct {
+	K1 []byte
+	K2 []byte
+}
+
+// Stats затраты атаки
+type Stats struct {
+	TableEntries   int           // записей в таблице одной части
+	TableBytes     int64         // память таблицы одной части
+	Encryptions    uint64        // шифрований в прямом проходе
+	Decryptions    uint64        // дешифрований в обратном проходе
+	Matches        int           // совпадений с таблицей по первой паре
+	FalsePositives int           // совпадений, отброшенных на остальных парах
+	ForwardTime    time.Duration // построение и сортировка таблиц
+	BackwardTime   time.Duration // обратные проходы и проверка
+}
+
+// Result найденные ключи и затраты атаки
+type Result struct {
+	Candidates []Candidate
+	Stats      Stats
+}
+
+// entry промежуточное значение E_K1(P) и номер ключа K1
+type entry struct {
+	mid   uint64
+	index uint32
+}
+
+// ExpectedFalsePositives ожидаемое число ложных пар ключей, переживших
+// проверку на pairs парах текстов: 2^(|K1| + |K2| - 64·pairs)
+func (m *MITM) ExpectedFalsePositives(pairs int) float64 {
+	return math.Exp2(float64(m.K1.Bits() + m.K2.Bits() - 64*pairs))
+}
+
+// Run выполняет атаку по известным парам текстов
+func (m *MITM) Run(pairs []Pair) (*Result, error) {
+	if m.New == nil {
+		return nil, errors.New("attack: cipher constructor must not be nil")
+	}
+	if m.New().BlockSize() != 8 {
+		return nil, errors.New("attack: meet-in-the-middle requires a 64-bit block cipher")
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("attack: at least one plaintext/ciphertext pair is required")
+	}
+	for _, p := range pairs {
+		if len(p.Plaintext) != 8 || len(p.Ciphertext) != 8 {
+			return nil, errors.New("attack: plaintext and ciphertext must be 8 bytes")
+		}
+	}
+	if len(m.K1.Base) == 0 || len(m.K2.Base) == 0 {
+		return nil, errors.New("attack: key spaces must not be empty")
+	}
+
+	partitions := uint64(max(m.Partitions, 1))
+	partitions = min(partitions, m.K1.Size())
+	per := (m.K1.Size() + partitions - 1) / partitions
+
+	res := &Result{}
+	for lo := uint64(0); lo < m.K1.Size(); lo += per {
+		hi := min(lo+per, m.K1.Size())
+
+		start := time.Now()
+		table, err := m.forward(pairs[0].Plaintext, lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		res.Stats.ForwardTime += time.Since(start)
+		res.Stats.Encryptions += hi - lo
+		res.Stats.TableEntries = max(res.Stats.TableEntries, len(table))
+		res.Stats.TableBytes = max(res.Stats.TableBytes, int64(len(table))*int64(unsafe.Sizeof(entry{})))
+
+		start = time.Now()
+		if err := m.backward(pairs, table, res); err != nil {
+			return nil, err
+		}
+		res.Stats.BackwardTime += time.Since(start)
+		res.Stats.Decryptions += m.K2.Size()
+	}
+
+	slices.SortFunc(res.Candidates, func(a, b Candidate) int {
+		if c := bytes.Compare(a.K1, b.K1); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.K2, b.K2)
+	})
+	return res, nil
+}
+
+// forward шифрует plaintext ключами K1 с номерами [lo, hi) и сортирует результат
+func (m *MITM) forward(plaintext []byte, lo, hi uint64) ([]entry, error) {
+	table := make([]entry, hi-lo)
+	err := m.parallel(hi-lo, func(from, to uint64) error {
+		c := m.New()
+		key := make([]byte, len(m.K1.Base))
+		for i := from; i < to; i++ {
+			m.K1.Key(key, lo+i)
+			if err := c.SetEncryptionKey(key); err != nil {
+				return err
+			}
+			mid, err := c.EncryptBlock(plaintext)
+			if err != nil {
+				return err
+			}
+			table[i] = entry{mid: binary.BigEndian.Uint64(mid), index: uint32(lo + i)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(table, func(a, b entry) int {
+		return cmp.Compare(a.mid, b.mid)
+	})
+	return table, nil
+}
+
+// backward дешифрует шифртекст первой пары всеми ключами K2, ищет совпадения
+// в таблице и проверяет их на остальных парах
+func (m *MITM) backward(pairs []Pair, table []entry, res *Result) error {
+	var mu sync.Mutex
+	return m.parallel(m.K2.Size(), func(from, to uint64) error {
+		c1, c2 := m.New(), m.New()
+		key1 := make([]byte, len(m.K1.Base))
+		key2 := make([]byte, len(m.K2.Base))
+		var found []Candidate
+		matches, falsePositives := 0, 0
+
+		for j := from; j < to; j++ {
+			m.K2.Key(key2, j)
+			if err := c2.SetDecryptionKey(key2); err != nil {
+				return err
+			}
+			block, err := c2.DecryptBlock(pairs[0].Ciphertext)
+			if err != nil {
+				return err
+			}
+			mid := binary.BigEndian.Uint64(block)
+
+			for k := sort.Search(len(table), func(k int) bool { return table[k].mid >= mid }); k < len(table) && table[k].mid == mid; k++ {
+				matches++
+				m.K1.Key(key1, uint64(table[k].index))
+				if err := c1.SetEncryptionKey(key1); err != nil {
+					return err
+				}
+				ok, err := consistent(c1, c2, pairs[1:])
+				if err != nil {
+					return err
+				}
+				if !ok {
+					falsePositives++
+					continue
+				}
+				found = append(found, Candidate{
+					K1: append([]byte(nil), key1...),
+					K2: append([]byte(nil), key2...),
+				})
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		res.Candidates = append(res.Candidates, found...)
+		res.Stats.Matches += matches
+		res.Stats.FalsePositives += falsePositives
+		return nil
+	})
+}
+
+// consistent проверяет, что E_K1(P) == D_K2(C) для всех пар
+func consistent(c1, c2 core.SymmetricCipher, pairs []Pair) (bool, error) {
+	for _, p := range pairs {
+		forward, err := c1.EncryptBlock(p.Plaintext)
+		if err != nil {
+			return false, err
+		}
+		backward, err := c2.DecryptBlock(p.Ciphertext)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(forward, backward) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parallel делит [0, n) на непрерывные диапазоны и обрабатывает их в горутинах.
+// Возвращает первую ошибку по порядку диапазонов.
+func (m *MITM) parallel(n uint64, fn func(from, to uint64) error) error {
+	workers := uint64(m.Workers)
+	if workers == 0 {
+		workers = uint64(runtime.NumCPU())
+	}
+	workers = max(min(workers, n), 1)
+
+	per := (n + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := uint64(0); w < workers; w++ {
+		from, to := w*per, min((w+1)*per, n)
+		if from >= to {
+			break
+		}
+		wg.Add(1)
+		go func(w, from, to uint64) {
+			defer wg.Done()
+			errs[w] = fn(from, to)
+		}(w, from, to)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package attack
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	threedes "github.com/NikitaKoros/cryptography/lab1/internal/crypto/3des"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/deal"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+// doubleEncrypt шифрует случайные открытые тексты ключами k1, затем k2
+func doubleEncrypt(t *testing.T, newCipher func() core.SymmetricCipher, k1, k2 []byte, n int, rng *rand.Rand) []Pair {
+	t.Helper()
+	c1, c2 := newCipher(), newCipher()
+	if err := c1.SetEncryptionKey(k1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.SetEncryptionKey(k2); err != nil {
+		t.Fatal(err)
+	}
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		p := make([]byte, 8)
+		rng.Read(p)
+		mid, _ := c1.EncryptBlock(p)
+		c, _ := c2.EncryptBlock(mid)
+		pairs[i] = Pair{Plaintext: p, Ciphertext: c}
+	}
+	return pairs
+}
+
+// newAttack готовит атаку на ключи со случайной известной частью и bits
+// неизвестными битами, а также истинные ключи из этих пространств
+func newAttack(t *testing.T, newCipher func() core.SymmetricCipher, keySize, bits int, rng *rand.Rand) (*MITM, []byte, []byte) {
+	t.Helper()
+	// Для составных ключей неизвестными остаются младшие биты первого подключа
+	positions := DESKeyBits(bits)
+	spaces := make([]KeySpace, 2)
+	keys := make([][]byte, 2)
+	for i := range spaces {
+		base := make([]byte, keySize)
+		rng.Read(base)
+		space, err := NewKeySpace(base, positions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spaces[i] = space
+		keys[i] = make([]byte, keySize)
+		space.Key(keys[i], rng.Uint64()%space.Size())
+	}
+	return &MITM{New: newCipher, K1: spaces[0], K2: spaces[1]}, keys[0], keys[1]
+}
+
+func TestMITMRecoversDoubleDESKeys(t *testing.T) {
+	ciphers := map[string]struct {
+		new     func() core.SymmetricCipher
+		keySize int
+		bits    int
+	}{
+		"DES":     {func() core.SymmetricCipher { return des.NewDES() }, 8, 12},
+		"FastDES": {func() core.SymmetricCipher { return des.NewFastDES() }, 8, 16},
+		"3DES": {func() core.SymmetricCipher {
+			return threedes.NewTripleDES(des.NewFastDES(), des.NewFastDES(), des.NewFastDES())
+		}, 24, 10},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for name, tc := range ciphers {
+		attack, k1, k2 := newAttack(t, tc.new, tc.keySize, tc.bits, rng)
+		pairs := doubleEncrypt(t, tc.new, k1, k2, 3, rng)
+
+		res, err := attack.Run(pairs)
+		if err != nil {
+			t.Fatalf("%s: Run: %v", name, err)
+		}
+		if len(res.Candidates) != 1 {
+			t.Fatalf("%s: %d candidates, want 1", name, len(res.Candidates))
+		}
+		if !bytes.Equal(res.Candidates[0].K1, k1) || !bytes.Equal(res.Candidates[0].K2, k2) {
+			t.Errorf("%s: recovered %X/%X, want %X/%X", name, res.Candidates[0].K1, res.Candidates[0].K2, k1, k2)
+		}
+		if res.Stats.Encryptions != attack.K1.Size() || res.Stats.Decryptions != attack.K2.Size() {
+			t.Errorf("%s: %d encryptions and %d decryptions, want %d each",
+				name, res.Stats.Encryptions, res.Stats.Decryptions, attack.K1.Size())
+		}
+		if res.Stats.Matches != len(res.Candidates)+res.Stats.FalsePositives {
+			t.Errorf("%s: %d matches, %d candidates, %d false positives",
+				name, res.Stats.Matches, len(res.Candidates), res.Stats.FalsePositives)
+		}
+	}
+}
+
+// Деление таблицы на части уменьшает память и умножает обратные проходы,
+// не меняя ответа
+func TestMITMPartitions(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	newCipher := func() core.SymmetricCipher { return des.NewFastDES() }
+	attack, k1, k2 := newAttack(t, newCipher, 8, 12, rng)
+	pairs := doubleEncrypt(t, newCipher, k1, k2, 2, rng)
+
+	whole, err := attack.Run(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attack.Partitions = 4
+	attack.Workers = 3
+	split, err := attack.Run(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(split.Candidates) != 1 || !bytes.Equal(split.Candidates[0].K1, k1) || !bytes.Equal(split.Candidates[0].K2, k2) {
+		t.Fatalf("partitioned attack found %v", split.Candidates)
+	}
+	if split.Stats.TableEntries*4 != whole.Stats.TableEntries || split.Stats.TableBytes*4 != whole.Stats.TableBytes {
+		t.Errorf("table %d entries (%d bytes), want a quarter of %d (%d bytes)",
+			split.Stats.TableEntries, split.Stats.TableBytes, whole.Stats.TableEntries, whole.Stats.TableBytes)
+	}
+	if split.Stats.Decryptions != 4*whole.Stats.Decryptions {
+		t.Errorf("%d decryptions, want %d", split.Stats.Decryptions, 4*whole.Stats.Decryptions)
+	}
+}
+
+// Совпадение по первой паре, не подтверждённое остальными, отбрасывается
+func TestMITMFiltersFalsePositives(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	newCipher := func() core.SymmetricCipher { return des.NewFastDES() }
+	attack, k1, k2 := newAttack(t, newCipher, 8, 10, rng)
+	pairs := doubleEncrypt(t, newCipher, k1, k2, 2, rng)
+	pairs[1].Ciphertext[0] ^= 1
+
+	res, err := attack.Run(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Candidates) != 0 || res.Stats.FalsePositives == 0 {
+		t.Errorf("%d candidates, %d false positives; want the match rejected",
+			len(res.Candidates), res.Stats.FalsePositives)
+	}
+
+	if got := attack.ExpectedFalsePositives(1); got != 1.0/(1<<44) {
+		t.Errorf("ExpectedFalsePositives(1) = %g, want 2^-44", got)
+	}
+}
+
+func TestMITMErrors(t *testing.T) {
+	space, _ := NewKeySpace(make([]byte, 8), DESKeyBits(4))
+	newDES := func() core.SymmetricCipher { return des.NewDES() }
+	pair := []Pair{{Plaintext: make([]byte, 8), Ciphertext: make([]byte, 8)}}
+
+	if _, err := (&MITM{K1: space, K2: space}).Run(pair); err == nil {
+		t.Error("expected error without cipher constructor")
+	}
+	wide := &MITM{New: func() core.SymmetricCipher { return deal.NewDEAL128(des.NewDES()) }, K1: space, K2: space}
+	if _, err := wide.Run(pair); err == nil {
+		t.Error("expected error for 128-bit block cipher")
+	}
+	if _, err := (&MITM{New: newDES, K1: space, K2: space}).Run(nil); err == nil {
+		t.Error("expected error without pairs")
+	}
+	if _, err := (&MITM{New: newDES, K1: space, K2: space}).Run([]Pair{{Plaintext: make([]byte, 8)}}); err == nil {
+		t.Error("expected error for short ciphertext")
+	}
+
+	if _, err := NewKeySpace(make([]byte, 8), []int{64}); err == nil {
+		t.Error("expected error for key bit past the key")
+	}
+	if _, err := NewKeySpace(make([]byte, 8), []int{3, 3}); err == nil {
+		t.Error("expected error for repeated key bit")
+	}
+	if _, err := NewKeySpace(make([]byte, 8), DESKeyBits(33)); err == nil {
+		t.Error("expected error for more than 32 unknown bits")
+	}
+}
+
+func TestDESKeyBits(t *testing.T) {
+	bits := DESKeyBits(56)
+	if len(bits) != 56 {
+		t.Fatalf("%d bits, want 56", len(bits))
+	}
+	for _, pos := range bits {
+		if pos%8 == 7 {
+			t.Errorf("parity bit %d included", pos)
+		}
+	}
+	space, _ := NewKeySpace(make([]byte, 8), DESKeyBits(9))
+	key := make([]byte, 8)
+	space.Key(key, space.Size()-1)
+	if want := []byte{0, 0, 0, 0, 0, 0, 0x06, 0xFE}; !bytes.Equal(key, want) {
+		t.Errorf("last key %X, want %X", key, want)
+	}
+}
+
+func BenchmarkMITM16(b *testing.B) {
+	space, _ := NewKeySpace(make([]byte, 8), DESKeyBits(16))
+	attack := &MITM{New: func() core.SymmetricCipher { return des.NewFastDES() }, K1: space, K2: space}
+	pair := []Pair{{Plaintext: make([]byte, 8), Ciphertext: make([]byte, 8)}}
+	for i := 0; i < b.N; i++ {
+		if _, err := attack.Run(pair); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
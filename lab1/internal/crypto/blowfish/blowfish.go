@@ -0,0 +1,79 @@
+package blowfish
+
+import (
+	"errors"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core/feistel"
+)
+
+// Blowfish реализует Blowfish (Шнайер, 1993): 64-битный блок, ключ от 32
+// до 448 бит, 16 раундов сети Фейстеля с S-блоками, зависящими от ключа.
+type Blowfish struct {
+	feistelNetwork *feistel.FeistelNetwork
+	keySchedule    *KeySchedule
+}
+
+// NewBlowfish собирает Blowfish из обобщённой сети Фейстеля: исходная ветвь
+// первая, P1 и P18 — отбеливание левой половины, обмен ветвей после
+// последнего раунда отменяется (см. RoundFunction)
+func NewBlowfish() *Blowfish {
+	keySchedule := NewKeySchedule()
+	feistelNetwork, err := feistel.NewFeistelNetworkFromConfig(feistel.Config{
+		BlockSize:     8,
+		SourceFirst:   true,
+		Rounds:        16,
+		KeyExpander:   keySchedule,
+		Function:      NewRoundFunction(keySchedule),
+		PreWhitening:  feistel.XORWhitening{},
+		PostWhitening: feistel.XORWhitening{},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &Blowfish{
+		feistelNetwork: feistelNetwork,
+		keySchedule:    keySchedule,
+	}
+}
+
+// SetEncryptionKey устанавливает ключ длиной от 4 до 56 байт
+func (b *Blowfish) SetEncryptionKey(key []byte) error {
+	return b.feistelNetwork.SetEncryptionKey(key)
+}
+
+// SetDecryptionKey устанавливает ключ дешифрования
+func (b *Blowfish) SetDecryptionKey(key []byte) error {
+	return b.SetEncryptionKey(key)
+}
+
+// EncryptBlock шифрует один 64-битный блок
+func (b *Blowfish) EncryptBlock(block []byte) ([]byte, error) {
+	if len(block) != 8 {
+		return nil, errors.New("Blowfish block must be exactly 8 bytes")
+	}
+	return b.feistelNetwork.EncryptBlock(block)
+}
+
+// DecryptBlock дешифрует один 64-битный блок
+func (b *Blowfish) DecryptBlock(block []byte) ([]byte, error) {
+	if len(block) != 8 {
+		return nil, errors.New("Blowfish block must be exactly 8 bytes")
+	}
+	return b.feistelNetwork.DecryptBlock(block)
+}
+
+func (b *Blowfish) BlockSize() int {
+	return 8
+}
+
+// Destroy затирает раундовые ключи, P-массив и S-блоки
+func (b *Blowfish) Destroy() {
+	b.feistelNetwork.Destroy()
+}
+
+var (
+	_ core.SymmetricCipher = (*Blowfish)(nil)
+	_ core.Destroyer       = (*Blowfish)(nil)
+)
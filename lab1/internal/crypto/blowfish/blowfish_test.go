@@ -0,0 +1,200 @@
+package blowfish
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// Векторы Шнайера (набор Эрика Янга), сверены с OpenSSL BF_ecb_encrypt
+var ecbVectors = []struct {
+	key, plaintext, ciphertext string
+}{
+	{"0000000000000000", "0000000000000000", "4EF997456198DD78"},
+	{"FFFFFFFFFFFFFFFF", "FFFFFFFFFFFFFFFF", "51866FD5B85ECB8A"},
+	{"3000000000000000", "1000000000000001", "7D856F9A613063F2"},
+	{"1111111111111111", "1111111111111111", "2466DD878B963C9D"},
+	{"0123456789ABCDEF", "1111111111111111", "61F9C3802281B096"},
+	{"1111111111111111", "0123456789ABCDEF", "7D0CC630AFDA1EC7"},
+	{"FEDCBA9876543210", "0123456789ABCDEF", "0ACEAB0FC6A0A28D"},
+	{"7CA110454A1A6E57", "01A1D6D039776742", "59C68245EB05282B"},
+	{"0131D9619DC1376E", "5CD54CA83DEF57DA", "B1B8CC0B250F09A0"},
+	{"0123456789ABCDEF", "0000000000000000", "245946885754369A"},
+	{"FEDCBA9876543210", "FFFFFFFFFFFFFFFF", "6B5C5A9C5D9E0A5A"},
+}
+
+// Ключи переменной длины: префиксы F0E1D2C3B4A5968778695A4B3C2D1E0F0011223344556677,
+// открытый текст FEDCBA9876543210
+var variableKeyCiphertexts = map[int]string{
+	4:  "BE1E639408640F05",
+	5:  "B39E44481BDB1E6E",
+	6:  "9457AA83B1928C0D",
+	7:  "8BB77032F960629D",
+	8:  "E87A244E2CC85E82",
+	9:  "15750E7A4F4EC577",
+	10: "122BA70B3AB64AE0",
+	11: "3A833C9AFFC537F6",
+	12: "9409DA87A90F6BF2",
+	13: "884F80625060B8B4",
+	14: "1F85031C19E11968",
+	15: "79D9373A714CA34F",
+	16: "93142887EE3BE15C",
+	17: "03429E838CE2D14B",
+	18: "A4299E27469FF67B",
+	19: "AFD5AED1C1BC96A8",
+	20: "10851C0E3858DA9F",
+	21: "E6F51ED79B9DB21F",
+	22: "64A6E14AFD36B46F",
+	23: "80C7D7D45A5479AD",
+	24: "05044B62FA52D080",
+}
+
+func TestBlowfishVectors(t *testing.T) {
+	b := NewBlowfish()
+	for _, v := range ecbVectors {
+		if err := b.SetEncryptionKey(mustHex(t, v.key)); err != nil {
+			t.Fatalf("key %s: %v", v.key, err)
+		}
+		ciphertext, err := b.EncryptBlock(mustHex(t, v.plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := mustHex(t, v.ciphertext); !bytes.Equal(ciphertext, want) {
+			t.Errorf("key %s, plaintext %s: got %X, want %X", v.key, v.plaintext, ciphertext, want)
+		}
+		decrypted, err := b.DecryptBlock(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, mustHex(t, v.plaintext)) {
+			t.Errorf("key %s: decrypted %X, want %s", v.key, decrypted, v.plaintext)
+		}
+	}
+}
+
+func TestBlowfishVariableKey(t *testing.T) {
+	long := mustHex(t, "F0E1D2C3B4A5968778695A4B3C2D1E0F0011223344556677")
+	plaintext := mustHex(t, "FEDCBA9876543210")
+	b := NewBlowfish()
+	for size, want := range variableKeyCiphertexts {
+		if err := b.SetEncryptionKey(long[:size]); err != nil {
+			t.Fatalf("%d-byte key: %v", size, err)
+		}
+		ciphertext, err := b.EncryptBlock(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ciphertext, mustHex(t, want)) {
+			t.Errorf("%d-byte key: got %X, want %s", size, ciphertext, want)
+		}
+	}
+
+	// 448-битный ключ, вектор получен через OpenSSL BF_set_key
+	key := make([]byte, MaxKeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	if err := b.SetEncryptionKey(key); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := b.EncryptBlock(mustHex(t, "0123456789ABCDEF"))
+	if want := mustHex(t, "47A3ABD719E825FA"); !bytes.Equal(ciphertext, want) {
+		t.Errorf("56-byte key: got %X, want %X", ciphertext, want)
+	}
+}
+
+// Совпадает с прямой реализацией по описанию Шнайера
+func TestBlowfishMatchesSchneierRound(t *testing.T) {
+	b := NewBlowfish()
+	if err := b.SetEncryptionKey([]byte("secret key")); err != nil {
+		t.Fatal(err)
+	}
+	block := mustHex(t, "0011223344556677")
+	ciphertext, err := b.EncryptBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, r := b.keySchedule.encrypt(0x00112233, 0x44556677)
+	want := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, l), r)
+	if !bytes.Equal(ciphertext, want) {
+		t.Errorf("network %X, direct %X", ciphertext, want)
+	}
+}
+
+func TestBlowfishCipherContext(t *testing.T) {
+	b := NewBlowfish()
+	if err := b.SetEncryptionKey(mustHex(t, "0123456789ABCDEFF0E1D2C3B4A59687")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Вектор CBC Эрика Янга
+	plaintext := append([]byte("7654321 Now is the time for "), 0, 0, 0, 0)
+	ctx := core.NewCipherContext(b, core.CBC, core.PadZeros, mustHex(t, "FEDCBA9876543210"))
+	ciphertext, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	want := mustHex(t, "6B77B4D63006DEE605B156E27403979358DEB9E7154616D959F1652BD5FF92CC")
+	if !bytes.Equal(ciphertext[:len(want)], want) {
+		t.Errorf("ciphertext %X, want %X", ciphertext[:len(want)], want)
+	}
+
+	message := []byte("Blowfish works with every cipher mode of the context")
+	for _, mode := range []core.CipherMode{core.ECB, core.CBC, core.PCBC, core.CFB, core.OFB, core.CTR, core.RandomDelta} {
+		ctx := core.NewCipherContext(b, mode, core.PadPKCS7, mustHex(t, "0001020304050607"))
+		ciphertext, err := ctx.Encrypt(message)
+		if err != nil {
+			t.Fatalf("%v: Encrypt: %v", mode, err)
+		}
+		decrypted, err := ctx.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("%v: Decrypt: %v", mode, err)
+		}
+		if !bytes.Equal(decrypted, message) {
+			t.Errorf("%v: decrypted %q, want %q", mode, decrypted, message)
+		}
+	}
+}
+
+func TestBlowfishErrors(t *testing.T) {
+	b := NewBlowfish()
+	if _, err := b.EncryptBlock(make([]byte, 8)); err == nil {
+		t.Error("expected error without key")
+	}
+	for _, size := range []int{0, 3, 57} {
+		if err := b.SetEncryptionKey(make([]byte, size)); err == nil {
+			t.Errorf("expected error for %d-byte key", size)
+		}
+	}
+	if err := b.SetEncryptionKey(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.EncryptBlock(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte block")
+	}
+
+	b.Destroy()
+	for _, box := range b.keySchedule.s {
+		for _, word := range box {
+			if word != 0 {
+				t.Fatal("S-boxes were not wiped")
+			}
+		}
+	}
+	if _, err := b.EncryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock after Destroy: %v, want ErrDestroyed", err)
+	}
+}
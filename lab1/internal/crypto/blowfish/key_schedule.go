@@ -0,0 +1,136 @@
+package blowfish
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+const (
+	MinKeySize = 4  // 32 бита
+	MaxKeySize = 56 // 448 бит
+)
+
+// KeySchedule расписание ключа Blowfish: P-массив из 18 слов и четыре
+// S-блока по 256 слов, полученные из цифр π и ключа.
+//
+// Для сети Фейстеля ExpandKey возвращает ключи в порядке feistel.Config:
+// P1‖0 (предварительное отбеливание), P2…P17 (раунды) и P18‖0
+// (заключительное отбеливание). S-блоки остаются в KeySchedule и
+// используются функцией F.
+type KeySchedule struct {
+	p [18]uint32
+	s [4][256]uint32
+}
+
+func NewKeySchedule() *KeySchedule {
+	return &KeySchedule{}
+}
+
+func (ks *KeySchedule) ExpandKey(key []byte) ([][]byte, error) {
+	if len(key) < MinKeySize || len(key) > MaxKeySize {
+		return nil, errors.New("Blowfish key must be between 4 and 56 bytes")
+	}
+
+	ks.p = initialP
+	ks.s = initialS
+
+	// Ключ циклически складывается с P-массивом
+	j := 0
+	for i := range ks.p {
+		var word uint32
+		for k := 0; k < 4; k++ {
+			word = word<<8 | uint32(key[j])
+			j = (j + 1) % len(key)
+		}
+		ks.p[i] ^= word
+	}
+
+	// P и S последовательно заменяются шифртекстами нулевого блока
+	var l, r uint32
+	for i := 0; i < len(ks.p); i += 2 {
+		l, r = ks.encrypt(l, r)
+		ks.p[i], ks.p[i+1] = l, r
+	}
+	for i := range ks.s {
+		for k := 0; k < len(ks.s[i]); k += 2 {
+			l, r = ks.encrypt(l, r)
+			ks.s[i][k], ks.s[i][k+1] = l, r
+		}
+	}
+
+	subkeys := make([][]byte, 0, 18)
+	subkeys = append(subkeys, whiteningKey(ks.p[0]))
+	for _, p := range ks.p[1:17] {
+		subkeys = append(subkeys, binary.BigEndian.AppendUint32(nil, p))
+	}
+	return append(subkeys, whiteningKey(ks.p[17])), nil
+}
+
+// whiteningKey ключ отбеливания, складываемый только с левой половиной блока
+func whiteningKey(p uint32) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint32(key, p)
+	return key
+}
+
+// f раундовая функция Blowfish: ((S1[a] + S2[b]) ⊕ S3[c]) + S4[d]
+func (ks *KeySchedule) f(x uint32) uint32 {
+	return ((ks.s[0][x>>24] + ks.s[1][x>>16&0xff]) ^ ks.s[2][x>>8&0xff]) + ks.s[3][x&0xff]
+}
+
+// encrypt шифрует блок (l, r) по определению Шнайера; нужен расписанию,
+// пока P-массив и S-блоки ещё заполняются
+func (ks *KeySchedule) encrypt(l, r uint32) (uint32, uint32) {
+	for i := 0; i < 16; i++ {
+		l ^= ks.p[i]
+		r ^= ks.f(l)
+		l, r = r, l
+	}
+	l, r = r, l
+	r ^= ks.p[16]
+	l ^= ks.p[17]
+	return l, r
+}
+
+// Destroy затирает P-массив и S-блоки
+func (ks *KeySchedule) Destroy() {
+	clear(ks.p[:])
+	for i := range ks.s {
+		clear(ks.s[i][:])
+	}
+}
+
+// RoundFunction функция F сети Фейстеля для Blowfish: F(x, P_i) = f(x) ⊕ P_i.
+//
+// Раунд Шнайера (L, R) → (R ⊕ f(L ⊕ P_i), L ⊕ P_i) складывает ключ с исходной
+// ветвью. Если обозначить через a_i левую половину после сложения с P_i, то
+// a_{i+1} = a_{i-1} ⊕ f(a_i) ⊕ P_{i+1}, то есть пары (a_i, a_{i-1}) проходят
+// обычную сеть Фейстеля с этой функцией F и ключами P2…P17.
+type RoundFunction struct {
+	schedule *KeySchedule
+}
+
+func NewRoundFunction(schedule *KeySchedule) *RoundFunction {
+	return &RoundFunction{schedule: schedule}
+}
+
+func (rf *RoundFunction) F(half []byte, roundKey []byte) ([]byte, error) {
+	if len(half) != 4 || len(roundKey) != 4 {
+		return nil, errors.New("Blowfish round function: half and round key must be 4 bytes")
+	}
+	x := binary.BigEndian.Uint32(half)
+	return binary.BigEndian.AppendUint32(nil, rf.schedule.f(x)^binary.BigEndian.Uint32(roundKey)), nil
+}
+
+// Destroy затирает S-блоки расписания
+func (rf *RoundFunction) Destroy() {
+	rf.schedule.Destroy()
+}
+
+var (
+	_ core.KeyExpander     = (*KeySchedule)(nil)
+	_ core.FeistelFunction = (*RoundFunction)(nil)
+	_ core.Destroyer       = (*RoundFunction)(nil)
+)
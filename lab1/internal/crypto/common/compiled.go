@@ -0,0 +1,206 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Permutation скомпилированное правило перестановки битов. Правило проверяется
+// один раз в NewPermutation и превращается в таблицы масок: для каждого байта
+// входа и каждого из 256 его значений хранится вклад этого байта в результат.
+// Apply складывает по OR по одной маске на входной байт и не выделяет память.
+//
+// Результат совпадает с Permute для того же правила, порядка битов и
+// начального индекса. Permutation неизменяема и безопасна для параллельного
+// использования.
+type Permutation struct {
+	rule       []int // индексы источников с нуля
+	inputBits  int
+	bitOrder   BitOrder
+	startIndex StartIndex
+
+	inBytes  int
+	outBytes int
+	words    []uint64 // маски результата длиной до 8 байт (big-endian в слове)
+	masks    []byte   // маски результата длиннее 8 байт, по outBytes на запись
+}
+
+// NewPermutation компилирует правило rule для входа из inputBits бит
+func NewPermutation(rule []int, inputBits int, bitOrder BitOrder, startIndex StartIndex) (*Permutation, error) {
+	if len(rule) == 0 {
+		return nil, errors.New("Permutation: empty rule")
+	}
+	if inputBits <= 0 {
+		return nil, errors.New("Permutation: input size must be positive")
+	}
+	normalized := make([]int, len(rule))
+	for i, pos := range rule {
+		if startIndex == OneBased {
+			pos--
+		}
+		if pos < 0 || pos >= inputBits {
+			return nil, fmt.Errorf("Permutation: rule index %d out of range for %d input bits", rule[i], inputBits)
+		}
+		normalized[i] = pos
+	}
+	return compile(normalized, inputBits, bitOrder, startIndex), nil
+}
+
+// MustPermutation как NewPermutation, но паникует на некорректном правиле.
+// Предназначена для таблиц стандартов в переменных пакета.
+func MustPermutation(rule []int, inputBits int, bitOrder BitOrder, startIndex StartIndex) *Permutation {
+	p, err := NewPermutation(rule, inputBits, bitOrder, startIndex)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// compile строит таблицы масок по проверенному правилу с индексами с нуля
+func compile(rule []int, inputBits int, bitOrder BitOrder, startIndex StartIndex) *Permutation {
+	p := &Permutation{
+		rule:       rule,
+		inputBits:  inputBits,
+		bitOrder:   bitOrder,
+		startIndex: startIndex,
+		inBytes:    (inputBits + 7) / 8,
+		outBytes:   (len(rule) + 7) / 8,
+	}
+	if p.outBytes <= 8 {
+		p.words = make([]uint64, p.inBytes*256)
+	} else {
+		p.masks = make([]byte, p.inBytes*256*p.outBytes)
+	}
+
+	for out, src := range rule {
+		inByte, inBit := src/8, bitShift(src, bitOrder)
+		outByte, outBit := out/8, bitShift(out, bitOrder)
+		for v := 0; v < 256; v++ {
+			if v>>inBit&1 == 0 {
+				continue
+			}
+			entry := inByte*256 + v
+			if p.words != nil {
+				p.words[entry] |= uint64(1<<outBit) << (56 - 8*outByte)
+			} else {
+				p.masks[entry*p.outBytes+outByte] |= 1 << outBit
+			}
+		}
+	}
+	return p
+}
+
+// bitShift позиция бита с индексом i (с нуля) внутри его байта
+func bitShift(i int, bitOrder BitOrder) uint {
+	if bitOrder == LSBToMSB {
+		return uint(i % 8)
+	}
+	return uint(7 - i%8)
+}
+
+// InputBytes размер входа Apply в байтах
+func (p *Permutation) InputBytes() int {
+	return p.inBytes
+}
+
+// OutputBits число бит результата (длина правила)
+func (p *Permutation) OutputBits() int {
+	return len(p.rule)
+}
+
+// OutputBytes размер результата Apply в байтах
+func (p *Permutation) OutputBytes() int {
+	return p.outBytes
+}
+
+// Rule возвращает правило в исходной нумерации
+func (p *Permutation) Rule() []int {
+	rule := make([]int, len(p.rule))
+	for i, pos := range p.rule {
+		if p.startIndex == OneBased {
+			pos++
+		}
+		rule[i] = pos
+	}
+	return rule
+}
+
+// Apply записывает перестановку src в dst[:OutputBytes()]. Неиспользуемые
+// биты последнего байта обнуляются. dst и src не должны перекрываться.
+func (p *Permutation) Apply(dst, src []byte) error {
+	if len(src) != p.inBytes {
+		return fmt.Errorf("Permutation: input must be %d bytes, got %d", p.inBytes, len(src))
+	}
+	if len(dst) < p.outBytes {
+		return fmt.Errorf("Permutation: output must be at least %d bytes, got %d", p.outBytes, len(dst))
+	}
+
+	if p.words != nil {
+		var acc uint64
+		for i, b := range src {
+			acc |= p.words[i*256+int(b)]
+		}
+		if p.outBytes == 8 {
+			binary.BigEndian.PutUint64(dst, acc)
+			return nil
+		}
+		for j := 0; j < p.outBytes; j++ {
+			dst[j] = byte(acc >> (56 - 8*j))
+		}
+		return nil
+	}
+
+	out := dst[:p.outBytes]
+	clear(out)
+	for i, b := range src {
+		mask := p.masks[(i*256+int(b))*p.outBytes:][:p.outBytes]
+		for j := range out {
+			out[j] |= mask[j]
+		}
+	}
+	return nil
+}
+
+// Permute применяет перестановку и возвращает новый срез
+func (p *Permutation) Permute(src []byte) ([]byte, error) {
+	dst := make([]byte, p.outBytes)
+	if err := p.Apply(dst, src); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// Then возвращает композицию: сначала p, затем next. Вход next должен
+// совпадать по ширине с результатом p, а порядок битов — с порядком p.
+func (p *Permutation) Then(next *Permutation) (*Permutation, error) {
+	if next.bitOrder != p.bitOrder {
+		return nil, errors.New("Permutation: cannot compose permutations with different bit orders")
+	}
+	if next.inputBits != len(p.rule) {
+		return nil, fmt.Errorf("Permutation: cannot feed %d output bits into %d input bits", len(p.rule), next.inputBits)
+	}
+	rule := make([]int, len(next.rule))
+	for i, src := range next.rule {
+		rule[i] = p.rule[src]
+	}
+	return compile(rule, p.inputBits, p.bitOrder, p.startIndex), nil
+}
+
+// Inverse возвращает обратную перестановку. Правило должно быть биекцией:
+// каждый входной бит используется ровно один раз.
+func (p *Permutation) Inverse() (*Permutation, error) {
+	if len(p.rule) != p.inputBits {
+		return nil, errors.New("Permutation: only bijective rules are invertible")
+	}
+	inverse := make([]int, len(p.rule))
+	seen := make([]bool, len(p.rule))
+	for out, src := range p.rule {
+		if seen[src] {
+			return nil, errors.New("Permutation: only bijective rules are invertible")
+		}
+		seen[src] = true
+		inverse[src] = out
+	}
+	return compile(inverse, p.inputBits, p.bitOrder, p.startIndex), nil
+}
@@ -0,0 +1,198 @@
+package common
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// randomRule правило из outBits индексов в [0, inBits) с нужным началом отсчёта
+func randomRule(rng *rand.Rand, inBits, outBits int, startIndex StartIndex) []int {
+	rule := make([]int, outBits)
+	for i := range rule {
+		rule[i] = rng.Intn(inBits)
+		if startIndex == OneBased {
+			rule[i]++
+		}
+	}
+	return rule
+}
+
+func TestPermutationMatchesPermute(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	shapes := []struct{ in, out int }{
+		{8, 8}, {32, 48}, {64, 56}, {56, 48}, {64, 64}, {13, 5}, {128, 128}, {24, 100},
+	}
+	for _, shape := range shapes {
+		for _, order := range []BitOrder{MSBToLSB, LSBToMSB} {
+			for _, start := range []StartIndex{ZeroBased, OneBased} {
+				for trial := 0; trial < 20; trial++ {
+					rule := randomRule(rng, shape.in, shape.out, start)
+					p, err := NewPermutation(rule, shape.in, order, start)
+					if err != nil {
+						t.Fatalf("NewPermutation: %v", err)
+					}
+
+					data := make([]byte, p.InputBytes())
+					rng.Read(data)
+
+					want, err := Permute(data, rule, order, start)
+					if err != nil {
+						t.Fatalf("Permute: %v", err)
+					}
+					got, err := p.Permute(data)
+					if err != nil {
+						t.Fatalf("Permutation.Permute: %v", err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Fatalf("%d→%d bits, order %d, start %d: got %08b, want %08b",
+							shape.in, shape.out, order, start, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPermutationErrors(t *testing.T) {
+	if _, err := NewPermutation(nil, 8, MSBToLSB, OneBased); err == nil {
+		t.Error("expected error for empty rule")
+	}
+	if _, err := NewPermutation([]int{0}, 8, MSBToLSB, OneBased); err == nil {
+		t.Error("expected error for index 0 in one-based rule")
+	}
+	if _, err := NewPermutation([]int{8}, 8, MSBToLSB, ZeroBased); err == nil {
+		t.Error("expected error for index past the input")
+	}
+	if _, err := NewPermutation([]int{1}, 0, MSBToLSB, OneBased); err == nil {
+		t.Error("expected error for empty input")
+	}
+
+	p := MustPermutation([]int{2, 1}, 16, MSBToLSB, OneBased)
+	if err := p.Apply(make([]byte, 1), make([]byte, 1)); err == nil {
+		t.Error("expected error for short input")
+	}
+	if err := p.Apply(make([]byte, 0), make([]byte, 2)); err == nil {
+		t.Error("expected error for short output")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustPermutation should panic on invalid rule")
+		}
+	}()
+	MustPermutation([]int{99}, 8, MSBToLSB, OneBased)
+}
+
+func TestPermutationRule(t *testing.T) {
+	rule := []int{58, 50, 42, 34, 26, 18, 10, 2}
+	p := MustPermutation(rule, 64, MSBToLSB, OneBased)
+	if got := p.Rule(); !slices.Equal(got, rule) {
+		t.Errorf("Rule() = %v, want %v", got, rule)
+	}
+	if p.OutputBits() != 8 || p.OutputBytes() != 1 || p.InputBytes() != 8 {
+		t.Errorf("sizes: %d bits, %d bytes out, %d bytes in", p.OutputBits(), p.OutputBytes(), p.InputBytes())
+	}
+}
+
+func TestPermutationInverse(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{8, 64, 128} {
+		for _, order := range []BitOrder{MSBToLSB, LSBToMSB} {
+			p := MustPermutation(rng.Perm(n), n, order, ZeroBased)
+			inv, err := p.Inverse()
+			if err != nil {
+				t.Fatalf("Inverse: %v", err)
+			}
+
+			data := make([]byte, n/8)
+			rng.Read(data)
+			forward, _ := p.Permute(data)
+			back, _ := inv.Permute(forward)
+			if !bytes.Equal(back, data) {
+				t.Errorf("%d bits: inverse(p(x)) = %x, want %x", n, back, data)
+			}
+
+			identity, err := p.Then(inv)
+			if err != nil {
+				t.Fatalf("Then: %v", err)
+			}
+			for i, src := range identity.Rule() {
+				if src != i {
+					t.Fatalf("%d bits: p then inverse is not the identity at %d", n, i)
+				}
+			}
+		}
+	}
+
+	expansion := MustPermutation([]int{4, 1, 2, 3, 4, 1}, 4, MSBToLSB, OneBased)
+	if _, err := expansion.Inverse(); err == nil {
+		t.Error("expected error inverting an expansion")
+	}
+	repeated := MustPermutation([]int{1, 1, 2, 3}, 4, MSBToLSB, OneBased)
+	if _, err := repeated.Inverse(); err == nil {
+		t.Error("expected error inverting a rule with a repeated index")
+	}
+}
+
+func TestPermutationThen(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, order := range []BitOrder{MSBToLSB, LSBToMSB} {
+		first := MustPermutation(randomRule(rng, 64, 56, OneBased), 64, order, OneBased)
+		second := MustPermutation(randomRule(rng, 56, 48, ZeroBased), 56, order, ZeroBased)
+		composed, err := first.Then(second)
+		if err != nil {
+			t.Fatalf("Then: %v", err)
+		}
+
+		data := make([]byte, 8)
+		rng.Read(data)
+		step, _ := first.Permute(data)
+		want, _ := second.Permute(step)
+		got, _ := composed.Permute(data)
+		if !bytes.Equal(got, want) {
+			t.Errorf("order %d: composed %x, sequential %x", order, got, want)
+		}
+	}
+
+	a := MustPermutation([]int{1, 2, 3}, 8, MSBToLSB, OneBased)
+	if _, err := a.Then(MustPermutation([]int{1}, 8, MSBToLSB, OneBased)); err == nil {
+		t.Error("expected error for mismatched widths")
+	}
+	if _, err := a.Then(MustPermutation([]int{1}, 3, LSBToMSB, OneBased)); err == nil {
+		t.Error("expected error for mismatched bit orders")
+	}
+}
+
+func TestPermutationApplyNoAllocs(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, shape := range []struct{ in, out int }{{64, 64}, {32, 48}, {128, 128}} {
+		p := MustPermutation(randomRule(rng, shape.in, shape.out, ZeroBased), shape.in, MSBToLSB, ZeroBased)
+		src := make([]byte, p.InputBytes())
+		dst := make([]byte, p.OutputBytes())
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := p.Apply(dst, src); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("%d→%d bits: Apply allocates %.0f times per call", shape.in, shape.out, allocs)
+		}
+	}
+}
+
+func BenchmarkPermutationApply(b *testing.B) {
+	data := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+	rule := make([]int, 64)
+	for i := 0; i < 64; i++ {
+		rule[i] = 64 - i
+	}
+	p := MustPermutation(rule, 64, MSBToLSB, OneBased)
+	dst := make([]byte, 8)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = p.Apply(dst, data)
+	}
+}
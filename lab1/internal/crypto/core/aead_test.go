@@ -0,0 +1,322 @@
+package core
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blockCipher адаптер cipher.Block для тестов с шифрами, отличными от AES
+type blockCipher struct {
+	block cipher.Block
+}
+
+func (c *blockCipher) SetEncryptionKey([]byte) error { return nil }
+func (c *blockCipher) SetDecryptionKey([]byte) error { return nil }
+func (c *blockCipher) BlockSize() int                { return c.block.BlockSize() }
+
+func (c *blockCipher) EncryptBlock(block []byte) ([]byte, error) {
+	out := make([]byte, c.block.BlockSize())
+	c.block.Encrypt(out, block)
+	return out, nil
+}
+
+func (c *blockCipher) DecryptBlock(block []byte) ([]byte, error) {
+	out := make([]byte, c.block.BlockSize())
+	c.block.Decrypt(out, block)
+	return out, nil
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCMAC(t *testing.T) {
+	aesKey := "2b7e151628aed2a6abf7158809cf4f3c"
+	msg := "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+	tdeaKey := "8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5"
+
+	tests := []struct {
+		name string
+		tdea bool
+		key  string
+		msg  string
+		want string
+	}{
+		// RFC 4493, 4
+		{"AES empty", false, aesKey, "", "bb1d6929e95937287fa37d129b756746"},
+		{"AES 16", false, aesKey, msg[:32], "070a16b46b4d4144f79bdd9dd04a287c"},
+		{"AES 40", false, aesKey, msg[:80], "dfa66747de9ae63030ca32611497c827"},
+		{"AES 64", false, aesKey, msg, "51f0bebf7e3b9d92fc49741779363cfe"},
+		// TDEA, 64-битный блок (SP 800-38B D.4, значения сверены с OpenSSL)
+		{"TDEA empty", true, tdeaKey, "", "b7a688e122ffaf95"},
+		{"TDEA 16", true, tdeaKey, msg[:32], "286d394673448197"},
+		{"TDEA 20", true, tdeaKey, msg[:40], "743ddbe0ce2dc2ed"},
+	}
+	for _, tt := range tests {
+		var c SymmetricCipher
+		if tt.tdea {
+			block, err := des.NewTripleDESCipher(mustHex(t, tt.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c = &blockCipher{block}
+		} else {
+			c = newAESCipher(t, mustHex(t, tt.key))
+		}
+		mac, err := NewCMAC(c)
+		if err != nil {
+			t.Fatalf("%s: NewCMAC: %v", tt.name, err)
+		}
+
+		m := mustHex(t, tt.msg)
+		got, err := mac.MAC(m)
+		if err != nil {
+			t.Fatalf("%s: MAC: %v", tt.name, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s: CMAC = %x, want %s", tt.name, got, tt.want)
+		}
+		// Сообщение, переданное частями, даёт тот же тег
+		if len(m) > 3 {
+			if err := mac.Verify(got, m[:3], m[3:]); err != nil {
+				t.Errorf("%s: Verify of split message: %v", tt.name, err)
+			}
+		}
+		got[0] ^= 1
+		if err := mac.Verify(got, m); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: Verify of wrong tag: got %v", tt.name, err)
+		}
+	}
+}
+
+func TestDbl(t *testing.T) {
+	// Старший бит переносится в константу R_b своей ширины
+	for bs, rb := range cmacRb {
+		in := make([]byte, bs)
+		in[0] = 0x80
+		want := make([]byte, bs)
+		want[bs-1] = byte(rb)
+		want[bs-2] = byte(rb >> 8)
+		if got := dbl(in); !bytes.Equal(got, want) {
+			t.Errorf("block %d: dbl(10..0) = %x, want %x", bs, got, want)
+		}
+	}
+}
+
+func TestEAX(t *testing.T) {
+	// Векторы из статьи Bellare, Rogaway, Wagner "The EAX Mode of Operation", приложение
+	tests := []struct {
+		key, nonce, header, msg, ciphertext string
+	}{
+		{"233952DEE4D5ED5F9B9C6D6FF80FF478", "62EC67F9C3A4A407FCB2A8C49031A8B3", "6BFB914FD07EAE6B",
+			"", "E037830E8389F27B025A2D6527E79D01"},
+		{"91945D3F4DCBEE0BF45EF52255F095A4", "BECAF043B0A23D843194BA972C66DEBD", "FA3BFD4806EB53FA",
+			"F7FB", "19DD5C4C9331049D0BDAB0277408F67967E5"},
+		{"01F74AD64077F2E704C0F60ADA3DD523", "70C3DB4F0D26368400A10ED05D2BFF5E", "234A3463C1264AC6",
+			"1A47CB4933", "D851D5BAE03A59F238A23E39199DC9266626C40F80"},
+	}
+	for _, tt := range tests {
+		e, err := NewEAX(newAESCipher(t, mustHex(t, tt.key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, header := mustHex(t, tt.nonce), mustHex(t, tt.header)
+
+		sealed, err := e.Seal(nonce, mustHex(t, tt.msg), header)
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		if want := mustHex(t, tt.ciphertext); !bytes.Equal(sealed, want) {
+			t.Errorf("Seal = %X, want %X", sealed, want)
+		}
+		opened, err := e.Open(nonce, sealed, header)
+		if err != nil || !bytes.Equal(opened, mustHex(t, tt.msg)) {
+			t.Errorf("Open = %X, %v", opened, err)
+		}
+
+		if _, err := e.Open(nonce, sealed, append(header, 0)); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("Open with other header: got %v", err)
+		}
+		sealed[0] ^= 1
+		if _, err := e.Open(nonce, sealed, header); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("Open of modified ciphertext: got %v", err)
+		}
+	}
+}
+
+func TestEAXCounterWraps(t *testing.T) {
+	// Счётчик CTR переносится через весь блок, а не только через младшие 64 бита
+	counter := bytes.Repeat([]byte{0xFF}, 16)
+	counter[0] = 0x00
+	incrementBE(counter)
+	if want := append([]byte{0x01}, make([]byte, 15)...); !bytes.Equal(counter, want) {
+		t.Errorf("incrementBE = %x, want %x", counter, want)
+	}
+}
+
+func streamRoundTrip(t *testing.T, ctx *CipherContext, plaintext []byte, segment int) []byte {
+	t.Helper()
+	var sealed bytes.Buffer
+	if err := ctx.EncryptStreamAEAD(bytes.NewReader(plaintext), &sealed, segment); err != nil {
+		t.Fatalf("EncryptStreamAEAD: %v", err)
+	}
+	var opened bytes.Buffer
+	if err := ctx.DecryptStreamAEAD(bytes.NewReader(sealed.Bytes()), &opened); err != nil {
+		t.Fatalf("DecryptStreamAEAD (%d bytes, segment %d): %v", len(plaintext), segment, err)
+	}
+	if !bytes.Equal(opened.Bytes(), plaintext) {
+		t.Fatalf("round trip mismatch for %d bytes, segment %d", len(plaintext), segment)
+	}
+	return sealed.Bytes()
+}
+
+func TestStreamAEADRoundTrip(t *testing.T) {
+	const segment = 100
+	tdea, err := des.NewTripleDESCipher(bytes.Repeat([]byte{0x3C, 0x5A, 0x96}, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []SymmetricCipher{newAESCipher(t, testKey), &blockCipher{tdea}} {
+		for _, workers := range []int{1, 4} {
+			ctx := NewCipherContext(c, CBC, PadPKCS7, nil)
+			ctx.SetWorkers(workers)
+			for _, size := range []int{0, 1, segment - 1, segment, segment + 1, 3 * segment, 7*segment + 5} {
+				data := make([]byte, size)
+				rand.New(rand.NewSource(int64(size))).Read(data)
+				sealed := streamRoundTrip(t, ctx, data, segment)
+
+				segments := max(1, (size+segment-1)/segment)
+				if want := streamHeaderSize + size + segments*c.BlockSize(); len(sealed) != want {
+					t.Errorf("%d bytes: sealed length %d, want %d", size, len(sealed), want)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamAEADTampering(t *testing.T) {
+	const segment = 64
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, nil)
+	ctx.SetWorkers(3)
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 20) // 5 полных сегментов
+	sealed := streamRoundTrip(t, ctx, plaintext, segment)
+	sealedSegment := segment + 16 // тег EAX занимает блок AES
+
+	segmentAt := func(data []byte, i int) []byte {
+		start := streamHeaderSize + i*sealedSegment
+		return data[start : start+sealedSegment]
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+	}{
+		{"truncated at segment boundary", func(d []byte) []byte { return d[:len(d)-sealedSegment] }},
+		{"truncated inside segment", func(d []byte) []byte { return d[:len(d)-7] }},
+		{"truncated to header", func(d []byte) []byte { return d[:streamHeaderSize] }},
+		{"truncated header", func(d []byte) []byte { return d[:streamHeaderSize-3] }},
+		{"swapped segments", func(d []byte) []byte {
+			out := bytes.Clone(d)
+			copy(segmentAt(out, 1), segmentAt(d, 2))
+			copy(segmentAt(out, 2), segmentAt(d, 1))
+			return out
+		}},
+		{"duplicated segment", func(d []byte) []byte {
+			out := bytes.Clone(d)
+			copy(segmentAt(out, 3), segmentAt(d, 2))
+			return out
+		}},
+		{"flipped ciphertext bit", func(d []byte) []byte {
+			out := bytes.Clone(d)
+			segmentAt(out, 4)[5] ^= 0x20
+			return out
+		}},
+		{"changed segment size", func(d []byte) []byte {
+			out := bytes.Clone(d)
+			out[9]++
+			return out
+		}},
+		{"appended data", func(d []byte) []byte { return append(bytes.Clone(d), 0) }},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := ctx.DecryptStreamAEAD(bytes.NewReader(tt.mutate(sealed)), &out)
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: expected ErrAuthentication, got %v", tt.name, err)
+		}
+		if !bytes.HasPrefix(plaintext, out.Bytes()) {
+			t.Errorf("%s: unverified plaintext was written", tt.name)
+		}
+	}
+
+	// Сегмент из другого потока под тем же ключом: другой префикс nonce
+	other := streamRoundTrip(t, ctx, plaintext, segment)
+	spliced := bytes.Clone(sealed)
+	copy(segmentAt(spliced, 1), segmentAt(other, 1))
+	if err := ctx.DecryptStreamAEAD(bytes.NewReader(spliced), io.Discard); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("segment from another stream: expected ErrAuthentication, got %v", err)
+	}
+
+	// Проверенное начало потока выдаётся до обнаружения ошибки
+	var out bytes.Buffer
+	broken := bytes.Clone(sealed)
+	segmentAt(broken, 3)[0] ^= 1
+	ctx.SetWorkers(1)
+	if err := ctx.DecryptStreamAEAD(bytes.NewReader(broken), &out); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected ErrAuthentication, got %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), plaintext[:3*segment]) {
+		t.Errorf("verified prefix is %d bytes, want %d", out.Len(), 3*segment)
+	}
+
+	if err := ctx.DecryptStreamAEAD(bytes.NewReader([]byte("plain text")), io.Discard); !errors.Is(err, ErrNotStream) {
+		t.Errorf("expected ErrNotStream, got %v", err)
+	}
+}
+
+func TestFileAEAD(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "plain")
+	enc := filepath.Join(dir, "enc")
+	dec := filepath.Join(dir, "dec")
+	data := make([]byte, 3*DefaultSegmentSize+12345)
+	rand.New(rand.NewSource(33)).Read(data)
+	if err := os.WriteFile(in, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, nil)
+	if err := ctx.EncryptFileAEAD(in, enc); err != nil {
+		t.Fatalf("EncryptFileAEAD: %v", err)
+	}
+	if err := ctx.DecryptFileAEAD(enc, dec); err != nil {
+		t.Fatalf("DecryptFileAEAD: %v", err)
+	}
+	if got, _ := os.ReadFile(dec); !bytes.Equal(got, data) {
+		t.Fatal("file round trip mismatch")
+	}
+
+	sealed, _ := os.ReadFile(enc)
+	if err := os.WriteFile(enc, sealed[:len(sealed)-DefaultSegmentSize/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.DecryptFileAEAD(enc, dec); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("truncated file: expected ErrAuthentication, got %v", err)
+	}
+	if _, err := os.Stat(dec); !os.IsNotExist(err) {
+		t.Error("output of failed decryption was not removed")
+	}
+}
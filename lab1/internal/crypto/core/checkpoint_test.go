@@ -0,0 +1,289 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var errKilled = errors.New("killed")
+
+// killAt прерывает шифрование после записи порции с номером chunk
+func killAt(t *testing.T, chunk int) {
+	t.Helper()
+	checkpointHook = func(index int) error {
+		if index == chunk {
+			return errKilled
+		}
+		return nil
+	}
+	t.Cleanup(func() { checkpointHook = nil })
+}
+
+func writeLargeInput(t *testing.T, dir string) (string, []byte) {
+	t.Helper()
+	data := make([]byte, MaxInMemorySize+3*fileChunkSize+777)
+	rand.New(rand.NewSource(31)).Read(data)
+	path := filepath.Join(dir, "plain")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestResumeEncryptFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file test in short mode")
+	}
+	dir := t.TempDir()
+	in, plaintext := writeLargeInput(t, dir)
+	chunks := (len(plaintext) + fileChunkSize - 1) / fileChunkSize
+	iv := bytes.Repeat([]byte{0x24}, aes.BlockSize)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for _, tc := range []struct {
+		mode CipherMode
+		iv   []byte
+	}{
+		{CTR, iv},
+		{CBC, iv},
+		{OFB, nil},
+		{RandomDelta, nil},
+	} {
+		t.Run(tc.mode.String(), func(t *testing.T) {
+			ctx := NewCipherContext(newAESCipher(t, testKey), tc.mode, PadPKCS7, tc.iv)
+			ref := filepath.Join(dir, "ref")
+			if err := ctx.EncryptFile(in, ref); err != nil {
+				t.Fatalf("EncryptFile failed: %v", err)
+			}
+			want, err := os.ReadFile(ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Первое прерывание — при исходном шифровании, второе — во время продолжения
+			first := rng.Intn(chunks - 1)
+			second := first + 1 + rng.Intn(chunks-first-1)
+			t.Logf("killing at chunks %d and %d of %d", first, second, chunks)
+
+			out := filepath.Join(dir, "out")
+			killAt(t, first)
+			if err := ctx.EncryptFileWithCheckpoints(in, out, 3); !errors.Is(err, errKilled) {
+				t.Fatalf("expected interrupted encryption, got %v", err)
+			}
+			// Недописанная порция после контрольной точки
+			appendGarbage(t, out, 1000)
+
+			killAt(t, second)
+			if err := ctx.ResumeEncryptFile(in, out); !errors.Is(err, errKilled) {
+				t.Fatalf("expected interrupted resume, got %v", err)
+			}
+
+			checkpointHook = nil
+			if err := ctx.ResumeEncryptFile(in, out); err != nil {
+				t.Fatalf("ResumeEncryptFile failed: %v", err)
+			}
+			if _, err := os.Stat(CheckpointPath(out)); !os.IsNotExist(err) {
+				t.Errorf("checkpoint was not removed: %v", err)
+			}
+
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.iv != nil && !bytes.Equal(got, want) {
+				t.Fatal("resumed output differs from uninterrupted EncryptFile output")
+			}
+			if len(got) != len(want) {
+				t.Fatalf("resumed output is %d bytes, want %d", len(got), len(want))
+			}
+
+			dec := filepath.Join(dir, "dec")
+			if err := ctx.DecryptFile(out, dec); err != nil {
+				t.Fatalf("DecryptFile failed: %v", err)
+			}
+			if decrypted, _ := os.ReadFile(dec); !bytes.Equal(decrypted, plaintext) {
+				t.Fatal("round trip mismatch after resume")
+			}
+		})
+	}
+}
+
+func TestResumeValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file test in short mode")
+	}
+	dir := t.TempDir()
+	in, _ := writeLargeInput(t, dir)
+	iv := bytes.Repeat([]byte{0x24}, aes.BlockSize)
+	out := filepath.Join(dir, "out")
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, iv)
+
+	killAt(t, 7)
+	if err := ctx.EncryptFileWithCheckpoints(in, out, 2); !errors.Is(err, errKilled) {
+		t.Fatalf("expected interrupted encryption, got %v", err)
+	}
+	checkpointHook = nil
+	cp, err := ReadCheckpoint(CheckpointPath(out))
+	if err != nil {
+		t.Fatalf("ReadCheckpoint failed: %v", err)
+	}
+	if cp.NextChunk != 8 || cp.OutputOffset != 8*int64(ctx.encryptedSize(fileChunkSize)) || cp.Counter == "" || cp.LastBlock == "" {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+	partial, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey := bytes.Repeat([]byte{0x11}, 16)
+	otherIV := bytes.Repeat([]byte{0x25}, aes.BlockSize)
+	for _, bad := range []struct {
+		name string
+		ctx  *CipherContext
+	}{
+		{"key", NewCipherContext(newAESCipher(t, otherKey), CTR, PadPKCS7, iv)},
+		{"IV", NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, otherIV)},
+		{"mode", NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, iv)},
+		{"padding", NewCipherContext(newAESCipher(t, testKey), CTR, PadANSIX923, iv)},
+	} {
+		if err := bad.ctx.ResumeEncryptFile(in, out); !errors.Is(err, ErrCheckpointMismatch) {
+			t.Errorf("different %s: expected ErrCheckpointMismatch, got %v", bad.name, err)
+		}
+	}
+
+	// Частичный результат короче контрольной точки
+	if err := os.WriteFile(out, partial[:cp.OutputOffset-1], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.ResumeEncryptFile(in, out); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("truncated output: expected ErrCheckpointMismatch, got %v", err)
+	}
+
+	// Результат подменён перед контрольной точкой
+	tampered := bytes.Clone(partial)
+	tampered[cp.OutputOffset-1] ^= 0x01
+	if err := os.WriteFile(out, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.ResumeEncryptFile(in, out); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("tampered output: expected ErrCheckpointMismatch, got %v", err)
+	}
+
+	// Входной файл изменился после прерывания
+	if err := os.WriteFile(out, partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(in, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.ResumeEncryptFile(in, out); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("modified input: expected ErrCheckpointMismatch, got %v", err)
+	}
+}
+
+func TestResumeWithNonceGuard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file test in short mode")
+	}
+	dir := t.TempDir()
+	in, plaintext := writeLargeInput(t, dir)
+	iv := bytes.Repeat([]byte{0x24}, aes.BlockSize)
+	out := filepath.Join(dir, "out")
+	guard := NewNonceGuard(NewMemoryNonceStore(), testKey)
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, iv, guard)
+
+	// Прерывание после контрольной точки: порции 4 и 5 будут зашифрованы повторно
+	killAt(t, 5)
+	if err := ctx.EncryptFileWithCheckpoints(in, out, 4); !errors.Is(err, errKilled) {
+		t.Fatalf("expected interrupted encryption, got %v", err)
+	}
+	checkpointHook = nil
+	if err := ctx.ResumeEncryptFile(in, out); err != nil {
+		t.Fatalf("ResumeEncryptFile with nonce guard failed: %v", err)
+	}
+
+	dec := filepath.Join(dir, "dec")
+	if err := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, iv).DecryptFile(out, dec); err != nil {
+		t.Fatalf("DecryptFile failed: %v", err)
+	}
+	if decrypted, _ := os.ReadFile(dec); !bytes.Equal(decrypted, plaintext) {
+		t.Fatal("round trip mismatch after resume")
+	}
+
+	// Вне продолжения повтор nonce по-прежнему запрещён
+	if err := ctx.EncryptFile(in, filepath.Join(dir, "again")); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("expected ErrNonceReuse outside of resume, got %v", err)
+	}
+}
+
+// При продолжении повтор nonce допускается только для порций файла начиная с контрольной точки
+func TestResumeNonceBypassScope(t *testing.T) {
+	iv := bytes.Repeat([]byte{0x24}, aes.BlockSize)
+	other := bytes.Repeat([]byte{0x42}, aes.BlockSize)
+	store := NewMemoryNonceStore()
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, iv, NewNonceGuard(store, testKey))
+	for index := 0; index < 6; index++ {
+		if _, err := ctx.chunkContext(index).Encrypt(make([]byte, aes.BlockSize)); err != nil {
+			t.Fatalf("chunk %d: %v", index, err)
+		}
+	}
+	otherCtx := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, other, NewNonceGuard(store, testKey))
+	if _, err := otherCtx.Encrypt([]byte("another message")); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := ctx.resumedContext(&Checkpoint{NextChunk: 4})
+	for index := 4; index < 6; index++ {
+		if _, err := resumed.chunkContext(index).Encrypt(make([]byte, aes.BlockSize)); err != nil {
+			t.Errorf("re-encrypting chunk %d: %v", index, err)
+		}
+	}
+	if _, err := resumed.chunkContext(3).Encrypt(make([]byte, aes.BlockSize)); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("chunk before checkpoint: got %v, want ErrNonceReuse", err)
+	}
+	if _, err := resumed.Encrypt([]byte("not a chunk")); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("message with the file IV: got %v, want ErrNonceReuse", err)
+	}
+	resumedOther := otherCtx.resumedContext(&Checkpoint{NextChunk: 4})
+	if _, err := resumedOther.withIV(other).Encrypt([]byte("unrelated nonce")); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("unrelated nonce during resume: got %v, want ErrNonceReuse", err)
+	}
+}
+
+func TestEncryptFileWithCheckpointsSmallFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "plain")
+	out := filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte("small file is encrypted in one go"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, nil)
+	if err := ctx.EncryptFileWithCheckpoints(in, out, 1); err != nil {
+		t.Fatalf("EncryptFileWithCheckpoints failed: %v", err)
+	}
+	if _, err := os.Stat(CheckpointPath(out)); !os.IsNotExist(err) {
+		t.Errorf("checkpoint left for small file: %v", err)
+	}
+	if err := ctx.ResumeEncryptFile(in, out); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("resume without checkpoint: expected ErrNotExist, got %v", err)
+	}
+}
+
+func appendGarbage(t *testing.T, path string, n int) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(bytes.Repeat([]byte{0xEE}, n)); err != nil {
+		t.Fatal(err)
+	}
+}
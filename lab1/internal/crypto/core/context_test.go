@@ -0,0 +1,575 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// aesCipher быстрый шифр для тестов контекста на больших данных
+type aesCipher struct {
+	block cipher.Block
+}
+
+func newAESCipher(t testing.TB, key []byte) *aesCipher {
+	t.Helper()
+	c := &aesCipher{}
+	if err := c.SetEncryptionKey(key); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	return c
+}
+
+func (c *aesCipher) SetEncryptionKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	c.block = block
+	return nil
+}
+
+func (c *aesCipher) SetDecryptionKey(key []byte) error { return c.SetEncryptionKey(key) }
+
+func (c *aesCipher) EncryptBlock(block []byte) ([]byte, error) {
+	out := make([]byte, aes.BlockSize)
+	c.block.Encrypt(out, block)
+	return out, nil
+}
+
+func (c *aesCipher) DecryptBlock(block []byte) ([]byte, error) {
+	out := make([]byte, aes.BlockSize)
+	c.block.Decrypt(out, block)
+	return out, nil
+}
+
+func (c *aesCipher) BlockSize() int { return aes.BlockSize }
+
+var (
+	testKey  = bytes.Repeat([]byte{0x2B}, 16)
+	allModes = []CipherMode{ECB, CBC, PCBC, CFB, OFB, CTR, RandomDelta}
+)
+
+func TestAutoIV(t *testing.T) {
+	plaintext := []byte("the same message encrypted twice")
+
+	for _, mode := range allModes {
+		t.Run(mode.String(), func(t *testing.T) {
+			ctx := NewCipherContext(newAESCipher(t, testKey), mode, PadPKCS7, nil)
+
+			first, err := ctx.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			second, err := ctx.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+
+			if mode == ECB {
+				if !bytes.Equal(first, second) {
+					t.Error("ECB must stay deterministic")
+				}
+			} else {
+				if bytes.Equal(first, second) {
+					t.Error("two encryptions produced identical ciphertexts")
+				}
+				if want := ctx.encryptedSize(len(plaintext)); len(first) != want {
+					t.Errorf("ciphertext length = %d, want %d (IV prepended)", len(first), want)
+				}
+			}
+
+			for _, ct := range [][]byte{first, second} {
+				decrypted, err := ctx.Decrypt(ct)
+				if err != nil {
+					t.Fatalf("Decrypt failed: %v", err)
+				}
+				if !bytes.Equal(decrypted, plaintext) {
+					t.Errorf("Decrypt = %q, want %q", decrypted, plaintext)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoIVShortCiphertext(t *testing.T) {
+	ctx := NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, nil)
+	if _, err := ctx.Decrypt(nil); err == nil {
+		t.Error("expected error for ciphertext without IV")
+	}
+}
+
+func TestNonceGuard(t *testing.T) {
+	iv := bytes.Repeat([]byte{0x01}, aes.BlockSize)
+
+	for _, mode := range []CipherMode{CTR, OFB, CFB} {
+		t.Run(mode.String(), func(t *testing.T) {
+			store := NewMemoryNonceStore()
+			ctx := NewCipherContext(newAESCipher(t, testKey), mode, PadZeros, iv, NewNonceGuard(store, testKey))
+
+			if _, err := ctx.Encrypt([]byte("first message")); err != nil {
+				t.Fatalf("first Encrypt failed: %v", err)
+			}
+			if _, err := ctx.Encrypt([]byte("second message")); !errors.Is(err, ErrNonceReuse) {
+				t.Errorf("second Encrypt: got %v, want ErrNonceReuse", err)
+			}
+
+			// Новый контекст с тем же ключом и хранилищем тоже не может повторить nonce
+			again := NewCipherContext(newAESCipher(t, testKey), mode, PadZeros, iv, NewNonceGuard(store, testKey))
+			if _, err := again.Encrypt([]byte("third message")); !errors.Is(err, ErrNonceReuse) {
+				t.Errorf("Encrypt in a new context: got %v, want ErrNonceReuse", err)
+			}
+
+			// Расшифрование nonce не расходует
+			if _, err := ctx.Decrypt(make([]byte, aes.BlockSize)); err != nil {
+				t.Errorf("Decrypt must not be guarded: %v", err)
+			}
+		})
+	}
+
+	store := NewMemoryNonceStore()
+
+	t.Run("mode switch", func(t *testing.T) {
+		// Первый блок гаммы CTR и OFB одинаков — E(IV), поэтому смена режима повтор не разрешает
+		ctr := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, iv, NewNonceGuard(store, testKey))
+		if _, err := ctr.Encrypt([]byte("ctr")); err != nil {
+			t.Fatalf("CTR Encrypt failed: %v", err)
+		}
+		ofb := NewCipherContext(newAESCipher(t, testKey), OFB, PadZeros, iv, NewNonceGuard(store, testKey))
+		if _, err := ofb.Encrypt([]byte("ofb")); !errors.Is(err, ErrNonceReuse) {
+			t.Errorf("OFB after CTR: got %v, want ErrNonceReuse", err)
+		}
+	})
+
+	t.Run("different key", func(t *testing.T) {
+		otherKey := bytes.Repeat([]byte{0x7F}, 16)
+		ctx := NewCipherContext(newAESCipher(t, otherKey), CTR, PadZeros, iv, NewNonceGuard(store, otherKey))
+		if _, err := ctx.Encrypt([]byte("same nonce, other key")); err != nil {
+			t.Errorf("Encrypt under another key failed: %v", err)
+		}
+	})
+
+	t.Run("block modes are not guarded", func(t *testing.T) {
+		ctx := NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, iv, NewNonceGuard(store, testKey))
+		for i := 0; i < 2; i++ {
+			if _, err := ctx.Encrypt([]byte("cbc")); err != nil {
+				t.Fatalf("CBC Encrypt failed: %v", err)
+			}
+		}
+	})
+
+	t.Run("auto IV", func(t *testing.T) {
+		ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, nil, NewNonceGuard(store, testKey))
+		for i := 0; i < 10; i++ {
+			if _, err := ctx.Encrypt([]byte("fresh nonce every time")); err != nil {
+				t.Fatalf("Encrypt %d failed: %v", i, err)
+			}
+		}
+	})
+}
+
+// С разбиением блока на nonce и счётчик защита отмечает nonce: N‖0 и N‖5
+// разделили бы блоки гаммы начиная с пятого
+func TestNonceGuardCounterLayout(t *testing.T) {
+	nonce := bytes.Repeat([]byte{0x24}, 12)
+	withCounter := func(counter byte) []byte {
+		return append(append([]byte{}, nonce...), 0, 0, 0, counter)
+	}
+
+	store := NewMemoryNonceStore()
+	first := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, withCounter(0), Counter32, NewNonceGuard(store, testKey))
+	if _, err := first.Encrypt(make([]byte, 8*aes.BlockSize)); err != nil {
+		t.Fatalf("first Encrypt failed: %v", err)
+	}
+	second := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, withCounter(5), Counter32, NewNonceGuard(store, testKey))
+	if _, err := second.Encrypt(make([]byte, 8*aes.BlockSize)); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("same nonce, counter 5: got %v, want ErrNonceReuse", err)
+	}
+
+	// Порции одного файла делят nonce порции 0 и защитой не отклоняются
+	file := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, append(bytes.Repeat([]byte{0x25}, 12), 0, 0, 0, 0),
+		Counter32, NewNonceGuard(store, testKey))
+	for index := 0; index < 3; index++ {
+		if _, err := file.chunkContext(index).Encrypt(make([]byte, aes.BlockSize)); err != nil {
+			t.Fatalf("chunk %d: %v", index, err)
+		}
+	}
+	if _, err := file.Encrypt([]byte("again")); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("message after file with the same nonce: got %v, want ErrNonceReuse", err)
+	}
+}
+
+func TestFileNonceStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces")
+	keyID := []byte{1, 2, 3}
+	nonce := []byte{4, 5, 6}
+
+	store, err := NewFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("NewFileNonceStore failed: %v", err)
+	}
+	if err := store.MarkUsed(keyID, nonce); err != nil {
+		t.Fatalf("MarkUsed failed: %v", err)
+	}
+	if err := store.MarkUsed(keyID, nonce); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("second MarkUsed: got %v, want ErrNonceReuse", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := NewFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if err := reopened.MarkUsed(keyID, nonce); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("MarkUsed after reopen: got %v, want ErrNonceReuse", err)
+	}
+	if err := reopened.MarkUsed(keyID, []byte{7}); err != nil {
+		t.Errorf("MarkUsed for a new nonce failed: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileNonceStore(path); err == nil {
+		t.Error("expected error for malformed store file")
+	}
+}
+
+func TestCTRCounterOverflow(t *testing.T) {
+	iv := make([]byte, aes.BlockSize)
+	for i := 8; i < len(iv); i++ {
+		iv[i] = 0xFF
+	}
+	iv[len(iv)-1] = 0xFE // осталось ровно два значения счётчика
+
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, iv)
+	if _, err := ctx.Encrypt(make([]byte, 2*aes.BlockSize)); err != nil {
+		t.Errorf("two blocks must fit: %v", err)
+	}
+	if _, err := ctx.Encrypt(make([]byte, 3*aes.BlockSize)); !errors.Is(err, ErrCounterOverflow) {
+		t.Errorf("three blocks: got %v, want ErrCounterOverflow", err)
+	}
+}
+
+// Поле счётчика по умолчанию — младшие 8 байт блока (весь блок, если он короче)
+func TestDefaultCounterLayoutOverflows(t *testing.T) {
+	tests := []struct {
+		name   string
+		iv     []byte
+		blocks uint64
+		want   bool
+	}{
+		{"zero blocks", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, 0, false},
+		{"last value", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, 1, false},
+		{"wraps", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, 2, true},
+		{"from zero", make([]byte, 8), 1 << 40, false},
+		{"short block fits", []byte{0xFF, 0x00}, 256, false},
+		{"short block wraps", []byte{0xFF, 0x00}, 257, true},
+	}
+	for _, tt := range tests {
+		if got := (CounterLayout{}).overflows(tt.iv, tt.blocks); got != tt.want {
+			t.Errorf("%s: overflows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddUint64ToBEShortBlock(t *testing.T) {
+	buf := []byte{0x00, 0x00, 0xFF}
+	addUint64ToBE(buf, 0x0101)
+	if want := []byte{0x00, 0x02, 0x00}; !bytes.Equal(buf, want) {
+		t.Errorf("addUint64ToBE = %x, want %x", buf, want)
+	}
+}
+
+func TestCounterLayoutArithmetic(t *testing.T) {
+	// Прибавление 0x0102 переполняет каждый счётчик: перенос остаётся внутри поля
+	tests := []struct {
+		name     string
+		layout   CounterLayout
+		iv, want string
+	}{
+		{"default 64-bit", CounterLayout{}, "0000000000000000ffffffffffffff00", "00000000000000000000000000000002"},
+		{"96/32 big-endian", Counter32, "aaaaaaaaaaaaaaaaaaaaaaaafffffeff", "aaaaaaaaaaaaaaaaaaaaaaaa00000001"},
+		{"64/64 little-endian", Counter64LE, "aaaaaaaaaaaaaaaa00ffffffffffffff", "aaaaaaaaaaaaaaaa0200000000000000"},
+		{"counter first", CounterLayout{CounterBits: 16, CounterFirst: true}, "ffffaaaaaaaaaaaa", "0101aaaaaaaaaaaa"},
+		{"full block", FullBlockCounter, "ffffffffffffffffffffffffffffffff", "00000000000000000000000000000101"},
+	}
+	for _, tt := range tests {
+		iv, want := mustHex(t, tt.iv), mustHex(t, tt.want)
+		start, width, err := tt.layout.field(len(iv))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		got := bytes.Clone(iv)
+		tt.layout.add(got, 0x0102)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: add = %x, want %x", tt.name, got, want)
+		}
+		// nonce вне поля счётчика не меняется
+		if !bytes.Equal(got[:start], iv[:start]) || !bytes.Equal(got[start+width:], iv[start+width:]) {
+			t.Errorf("%s: nonce changed: %x -> %x", tt.name, iv, got)
+		}
+	}
+
+	counter := mustHex(t, "aaaaaaaaaaaaaaaaaaaaaaaafffffffe")
+	if Counter32.overflows(counter, 2) || !Counter32.overflows(counter, 3) {
+		t.Error("96/32: two values must remain in the counter")
+	}
+	le := mustHex(t, "aaaaaaaaaaaaaaaafeffffffffffffff")
+	if Counter64LE.overflows(le, 2) || !Counter64LE.overflows(le, 3) {
+		t.Error("64/64 LE: two values must remain in the counter")
+	}
+	wide := append([]byte{0xFF, 0xFE}, bytes.Repeat([]byte{0xFF}, 14)...)
+	if FullBlockCounter.overflows(wide, ^uint64(0)) {
+		t.Error("full block: 2^120 values remain, no overflow expected")
+	}
+
+	for _, bad := range []CounterLayout{{CounterBits: 12}, {CounterBits: 72}} {
+		if _, _, err := bad.field(8); err == nil {
+			t.Errorf("%+v: expected error for a 64-bit block", bad)
+		}
+	}
+}
+
+func TestCTRCounterLayout(t *testing.T) {
+	c := newAESCipher(t, testKey)
+	iv := mustHex(t, "0102030405060708090a0b0cfffffffe")
+	ctx := NewCipherContext(c, CTR, PadZeros, iv, Counter32)
+
+	// Гамма второго блока — E(nonce || ffffffff), без переноса в nonce
+	stream, err := ctx.Encrypt(make([]byte, 2*aes.BlockSize))
+	if err != nil {
+		t.Fatalf("two blocks must fit: %v", err)
+	}
+	want, _ := c.EncryptBlock(mustHex(t, "0102030405060708090a0b0cffffffff"))
+	if !bytes.Equal(stream[aes.BlockSize:], want) {
+		t.Errorf("second keystream block = %x, want %x", stream[aes.BlockSize:], want)
+	}
+	if _, err := ctx.Encrypt(make([]byte, 3*aes.BlockSize)); !errors.Is(err, ErrCounterOverflow) {
+		t.Errorf("three blocks: got %v, want ErrCounterOverflow", err)
+	}
+
+	// Порции файла тоже не выходят за поле счётчика
+	start := mustHex(t, "0102030405060708090a0b0c00000000")
+	file := NewCipherContext(c, CTR, PadPKCS7, start, Counter32)
+	if err := file.checkFileCounter(1 << 30); err != nil {
+		t.Errorf("1 GiB with a 32-bit counter: %v", err)
+	}
+	if err := file.checkFileCounter(1 << 37); !errors.Is(err, ErrCounterOverflow) {
+		t.Errorf("128 GiB with a 32-bit counter: got %v, want ErrCounterOverflow", err)
+	}
+
+	// Сгенерированный IV: случайный nonce и счётчик с нуля
+	auto := NewCipherContext(c, CTR, PadPKCS7, nil, Counter32)
+	sealed, err := auto.Encrypt([]byte("counter layout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sealed[12:16], []byte{0, 0, 0, 0}) {
+		t.Errorf("generated IV %x must start the counter at zero", sealed[:16])
+	}
+	if plain, err := auto.Decrypt(sealed); err != nil || string(plain) != "counter layout" {
+		t.Errorf("round trip = %q, %v", plain, err)
+	}
+
+	if _, err := NewCipherContext(c, CTR, PadZeros, iv, CounterLayout{CounterBits: 136}).Encrypt(make([]byte, 16)); err == nil {
+		t.Error("expected error for a counter wider than the block")
+	}
+}
+
+func TestParallelModesMatchStdlib(t *testing.T) {
+	block, err := aes.NewCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Младшие байты счётчика близки к переносу в старшие
+	iv := []byte{0, 1, 2, 3, 4, 5, 6, 7, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF, 0x80}
+
+	for _, blocks := range []int{1, 31, 32, 33, 257, 1000} {
+		data := make([]byte, blocks*aes.BlockSize)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		cbc := make([]byte, len(data))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(cbc, data)
+		cfb := make([]byte, len(data))
+		cipher.NewCFBEncrypter(block, iv).XORKeyStream(cfb, data)
+		ctr := make([]byte, len(data))
+		cipher.NewCTR(block, iv).XORKeyStream(ctr, data)
+
+		for _, workers := range []int{1, 3, 8} {
+			ctx := NewCipherContext(newAESCipher(t, testKey), CBC, PadPKCS7, iv)
+			ctx.SetWorkers(workers)
+			for _, tc := range []struct {
+				name string
+				fn   func([]byte) ([]byte, error)
+				in   []byte
+				want []byte
+			}{
+				{"CBC decrypt", ctx.decryptCBC, cbc, data},
+				{"CFB decrypt", ctx.decryptCFB, cfb, data},
+				{"CTR encrypt", ctx.encryptCTR, data, ctr},
+				{"CTR decrypt", ctx.decryptCTR, ctr, data},
+			} {
+				got, err := tc.fn(tc.in)
+				if err != nil {
+					t.Fatalf("%s, %d blocks, %d workers: %v", tc.name, blocks, workers, err)
+				}
+				if !bytes.Equal(got, tc.want) {
+					t.Errorf("%s, %d blocks, %d workers: result differs from crypto/cipher", tc.name, blocks, workers)
+				}
+			}
+		}
+	}
+}
+
+func TestParallelBlocks(t *testing.T) {
+	for _, n := range []int{0, 1, minParallelBlocks, 5*minParallelBlocks + 3} {
+		ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, nil)
+		ctx.SetWorkers(4)
+		visits := make([]int, n)
+		if err := ctx.parallelBlocks(n, func(from, to int) error {
+			for i := from; i < to; i++ {
+				visits[i]++
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		for i, v := range visits {
+			if v != 1 {
+				t.Fatalf("n=%d: block %d processed %d times", n, i, v)
+			}
+		}
+	}
+
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, nil)
+	ctx.SetWorkers(4)
+	errBlock := errors.New("block failed")
+	err := ctx.parallelBlocks(10*minParallelBlocks, func(from, to int) error {
+		if from <= 7*minParallelBlocks && 7*minParallelBlocks < to {
+			return errBlock
+		}
+		return nil
+	})
+	if !errors.Is(err, errBlock) {
+		t.Errorf("expected error from one of the ranges, got %v", err)
+	}
+}
+
+func TestLargeFileChunkIVs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file test in short mode")
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "plain")
+	size := MaxInMemorySize + fileChunkSize + 123
+
+	// Нулевой открытый текст: шифртекст CTR совпадает с гаммой
+	if err := os.WriteFile(in, make([]byte, size), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	iv := bytes.Repeat([]byte{0x42}, aes.BlockSize)
+	for _, tc := range []struct {
+		mode    CipherMode
+		padding PaddingMode
+		iv      []byte
+	}{
+		{CTR, PadZeros, iv},
+		{CTR, PadPKCS7, iv},
+		{CBC, PadPKCS7, iv},
+		{CBC, PadANSIX923, nil},
+		{RandomDelta, PadPKCS7, nil},
+	} {
+		t.Run(tc.mode.String()+"/"+tc.padding.String(), func(t *testing.T) {
+			ctx := NewCipherContext(newAESCipher(t, testKey), tc.mode, tc.padding, tc.iv)
+			enc := filepath.Join(dir, "enc")
+			dec := filepath.Join(dir, "dec")
+			if err := ctx.EncryptFile(in, enc); err != nil {
+				t.Fatalf("EncryptFile failed: %v", err)
+			}
+			if err := ctx.DecryptFile(enc, dec); err != nil {
+				t.Fatalf("DecryptFile failed: %v", err)
+			}
+			got, err := os.ReadFile(dec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.padding != PadZeros && !bytes.Equal(got, make([]byte, size)) {
+				t.Fatalf("round trip mismatch: got %d bytes", len(got))
+			}
+
+			encrypted, err := os.ReadFile(enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunk := ctx.encryptedSize(fileChunkSize)
+			if bytes.Equal(encrypted[:aes.BlockSize], encrypted[chunk:chunk+aes.BlockSize]) {
+				t.Error("first blocks of consecutive chunks are equal: IV reused across chunks")
+			}
+		})
+	}
+}
+
+func TestLargeFileCounterOverflow(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "plain")
+	if err := os.WriteFile(in, make([]byte, MaxInMemorySize+1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	for i := 8; i < len(iv); i++ {
+		iv[i] = 0xFF
+	}
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadZeros, iv)
+	if err := ctx.EncryptFile(in, filepath.Join(dir, "enc")); !errors.Is(err, ErrCounterOverflow) {
+		t.Errorf("EncryptFile: got %v, want ErrCounterOverflow", err)
+	}
+}
+
+// После первой ошибки порции больше не обрабатываются и не пишутся,
+// а воркеры завершаются до возврата
+func TestChunkPipelineStopsOnError(t *testing.T) {
+	ctx := NewCipherContext(newAESCipher(t, testKey), ECB, PadZeros, nil)
+	ctx.SetWorkers(2)
+
+	const chunks = 64
+	var calls atomic.Int32
+	errBroken := errors.New("broken chunk")
+	var out bytes.Buffer
+	err := ctx.chunkPipeline(bytes.NewReader(make([]byte, 16*chunks)), &out, 16, 0, func(task bufferTask) ([]byte, error) {
+		calls.Add(1)
+		if task.index == 0 {
+			return nil, errBroken
+		}
+		time.Sleep(10 * time.Millisecond)
+		return task.data, nil
+	}, nil)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v, want the chunk error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("%d bytes written after the failed first chunk", out.Len())
+	}
+
+	processed := calls.Load()
+	if processed >= chunks/4 {
+		t.Errorf("%d of %d chunks processed after the error", processed, chunks)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if later := calls.Load(); later != processed {
+		t.Errorf("workers kept running after return: %d chunks, then %d", processed, later)
+	}
+}
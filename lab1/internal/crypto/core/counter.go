@@ -0,0 +1,95 @@
+package core
+
+import "fmt"
+
+// CounterLayout задаёт разбиение блока счётчика CTR на nonce и счётчик.
+// Передаётся в NewCipherContext среди opts. По умолчанию счётчик — младшие
+// 64 бита блока в big-endian (весь блок, если он короче), остальное — nonce.
+//
+// Счётчик увеличивается только внутри своего поля: nonce не меняется, а
+// сообщение, которому не хватило бы значений счётчика, отвергается с
+// ErrCounterOverflow до начала шифрования.
+type CounterLayout struct {
+	CounterBits  int  // ширина счётчика в битах, кратна 8; 0 — по умолчанию, < 0 — весь блок
+	LittleEndian bool // счётчик записан младшим байтом вперёд
+	CounterFirst bool // счётчик в начале блока, nonce после него (по умолчанию — в конце)
+}
+
+var (
+	// Counter32 32-битный big-endian счётчик в конце блока: для 128-битного
+	// блока это 96-битный nonce и 32-битный счётчик, как в GCM
+	Counter32 = CounterLayout{CounterBits: 32}
+	// Counter64 64-битный nonce и 64-битный big-endian счётчик (для 128-битного блока)
+	Counter64 = CounterLayout{CounterBits: 64}
+	// Counter64LE 64-битный little-endian счётчик в конце блока
+	Counter64LE = CounterLayout{CounterBits: 64, LittleEndian: true}
+	// FullBlockCounter счётчик на весь блок без nonce
+	FullBlockCounter = CounterLayout{CounterBits: -1}
+)
+
+// field возвращает смещение и ширину поля счётчика в байтах для блока blockSize
+func (l CounterLayout) field(blockSize int) (start, width int, err error) {
+	switch {
+	case l.CounterBits == 0:
+		width = min(8, blockSize)
+	case l.CounterBits < 0:
+		width = blockSize
+	case l.CounterBits%8 != 0 || l.CounterBits > blockSize*8:
+		return 0, 0, fmt.Errorf("CTR counter of %d bits does not fit a %d-bit block in whole bytes", l.CounterBits, blockSize*8)
+	default:
+		width = l.CounterBits / 8
+	}
+	if !l.CounterFirst {
+		start = blockSize - width
+	}
+	return start, width, nil
+}
+
+// byteIndex позиция k-го по старшинству (0 — младший) байта поля счётчика в блоке
+func (l CounterLayout) byteIndex(start, width, k int) int {
+	if l.LittleEndian {
+		return start + k
+	}
+	return start + width - 1 - k
+}
+
+// add прибавляет v к счётчику в block; перенос за пределы поля отбрасывается
+func (l CounterLayout) add(block []byte, v uint64) {
+	start, width, _ := l.field(len(block))
+	carry := v
+	for k := 0; k < width && carry > 0; k++ {
+		i := l.byteIndex(start, width, k)
+		sum := uint64(block[i]) + carry&0xFF
+		block[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+}
+
+// overflows сообщает, выйдет ли счётчик за пределы своего поля
+// при обработке blocks блоков начиная с iv
+func (l CounterLayout) overflows(iv []byte, blocks uint64) bool {
+	if blocks == 0 {
+		return false
+	}
+	start, width, _ := l.field(len(iv))
+	// Если старшие байты широкого поля не все 0xFF, до максимума не меньше 2^64 значений
+	for k := width - 1; k >= 8; k-- {
+		if iv[l.byteIndex(start, width, k)] != 0xFF {
+			return false
+		}
+	}
+	var room uint64
+	for k := min(width, 8) - 1; k >= 0; k-- {
+		room = room<<8 | uint64(0xFF-iv[l.byteIndex(start, width, k)])
+	}
+	return blocks-1 > room
+}
+
+// startCounter обнуляет счётчик в случайном IV, оставляя nonce, чтобы сообщению
+// было доступно всё поле счётчика. Если nonce в блоке нет, IV не меняется.
+func (l CounterLayout) startCounter(iv []byte) {
+	start, width, _ := l.field(len(iv))
+	if width < len(iv) {
+		clear(iv[start : start+width])
+	}
+}
@@ -0,0 +1,57 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+// Тест: раскладки счётчика CTR на 64-битном блоке DES
+func TestCTRCounterLayoutDES(t *testing.T) {
+	c := des.NewDES()
+	if err := c.SetEncryptionKey([]byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		layout core.CounterLayout
+		// IV с двумя оставшимися значениями счётчика и второй блок счётчика
+		iv, next []byte
+	}{
+		{"32/32 big-endian", core.Counter32,
+			[]byte{1, 2, 3, 4, 0xFF, 0xFF, 0xFF, 0xFE}, []byte{1, 2, 3, 4, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"32/32 little-endian", core.CounterLayout{CounterBits: 32, LittleEndian: true},
+			[]byte{1, 2, 3, 4, 0xFE, 0xFF, 0xFF, 0xFF}, []byte{1, 2, 3, 4, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"16-bit counter first", core.CounterLayout{CounterBits: 16, CounterFirst: true},
+			[]byte{0xFF, 0xFE, 3, 4, 5, 6, 7, 8}, []byte{0xFF, 0xFF, 3, 4, 5, 6, 7, 8}},
+		{"full block", core.FullBlockCounter,
+			[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}, bytes.Repeat([]byte{0xFF}, 8)},
+	}
+
+	for _, tt := range tests {
+		ctx := core.NewCipherContext(c, core.CTR, core.PadZeros, tt.iv, tt.layout)
+		stream, err := ctx.Encrypt(make([]byte, 16))
+		if err != nil {
+			t.Fatalf("%s: two blocks must fit: %v", tt.name, err)
+		}
+		first, _ := c.EncryptBlock(tt.iv)
+		second, _ := c.EncryptBlock(tt.next)
+		if !bytes.Equal(stream, append(first, second...)) {
+			t.Errorf("%s: keystream %x, want E(%x) || E(%x)", tt.name, stream, tt.iv, tt.next)
+		}
+		if _, err := ctx.Encrypt(make([]byte, 17)); !errors.Is(err, core.ErrCounterOverflow) {
+			t.Errorf("%s: three blocks: got %v, want ErrCounterOverflow", tt.name, err)
+		}
+	}
+
+	if _, err := core.NewCipherContext(c, core.CTR, core.PadZeros, make([]byte, 8), core.Counter64LE).Encrypt(make([]byte, 8)); err != nil {
+		t.Errorf("64-bit counter covers the DES block: %v", err)
+	}
+	if _, err := core.NewCipherContext(c, core.CTR, core.PadZeros, make([]byte, 8), core.CounterLayout{CounterBits: 96}).Encrypt(make([]byte, 8)); err == nil {
+		t.Error("expected error for a 96-bit counter in a 64-bit block")
+	}
+}
@@ -2,20 +2,83 @@ package feistel
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 )
 
-// FeistelNetwork универсальная реализация сети Фейстеля
+// FeistelNetwork универсальная реализация сети Фейстеля.
+//
+// Сеть работает в одном из двух режимов. В режиме RoundEncrypter раунд
+// целиком (разбиение, смешивание и обмен ветвей) реализует RoundEncrypter,
+// а расшифрование выполняет те же раунды с обратным порядком ключей.
+// В режиме Function (см. Config) сеть сама делит блок на ветви и меняет их
+// местами, а от шифра нужна только функция F и, при необходимости,
+// операция смешивания и отбеливание.
 type FeistelNetwork struct {
 	KeyExpander    core.KeyExpander
 	RoundEncrypter core.RoundEncrypter
 	Rounds         int
 	EncryptKeys    [][]byte
 	DecryptKeys    [][]byte
+
+	config    Config
+	preKey    []byte // ключ отбеливания до первого раунда
+	postKey   []byte // ключ отбеливания после последнего раунда
+	destroyed bool
+}
+
+// Config параметры обобщённой сети Фейстеля.
+//
+// Блок из BlockSize байт делится на исходную ветвь S из SourceSize байт
+// (по умолчанию — последние байты блока, при SourceFirst — первые) и
+// целевую ветвь T из остальных байт. Раунд с ключом K:
+//
+//	T ‖ S → S ‖ Combine(T, F(S, K))   (SourceFirst: S ‖ T → Combine(T, F(S, K)) ‖ S)
+//
+// F возвращает BlockSize-SourceSize байт. При SourceSize = BlockSize/2 сеть
+// сбалансирована; иначе несбалансирована, и исходная ветвь следующего раунда
+// захватывает часть обеих ветвей предыдущего. Если FinalSwap не задан, обмен
+// ветвей после последнего раунда отменяется, как в DES.
+//
+// KeyExpander возвращает ключ PreWhitening (если тот Keyed), Rounds раундовых
+// ключей и ключ PostWhitening (если тот Keyed) — в этом порядке.
+type Config struct {
+	BlockSize   int // размер блока в байтах
+	SourceSize  int // размер исходной ветви, 0 — BlockSize/2
+	SourceFirst bool
+	Rounds      int
+	FinalSwap   bool // оставить обмен ветвей после последнего раунда
+
+	KeyExpander core.KeyExpander
+	Function    core.FeistelFunction
+	Combiner    Combiner // nil — XOR
+
+	// RoundEncrypter вместо Function: раунд целиком реализуется шифром,
+	// поля SourceSize, SourceFirst, FinalSwap и Combiner не используются
+	RoundEncrypter core.RoundEncrypter
+
+	PreWhitening  Whitening // до первого раунда, nil — нет
+	PostWhitening Whitening // после последнего раунда, nil — нет
+}
+
+// Combiner смешивает выход F с целевой ветвью; Separate обращает Combine
+type Combiner interface {
+	Combine(target, f []byte) []byte
+	Separate(target, f []byte) []byte
 }
 
-// NewFeistelNetwork создаёт новую сеть Фейстеля
+// Whitening обратимое преобразование всего блока до первого или после
+// последнего раунда. Keyed сообщает, нужен ли ключ из расписания; иначе
+// в Whiten и Unwhiten передаётся nil.
+type Whitening interface {
+	Whiten(block, key []byte) ([]byte, error)
+	Unwhiten(block, key []byte) ([]byte, error)
+	Keyed() bool
+}
+
+// NewFeistelNetwork создаёт новую сеть Фейстеля в режиме RoundEncrypter.
+// Размер блока не проверяется, BlockSize возвращает 8.
 func NewFeistelNetwork(KeyExpander core.KeyExpander, RoundEncrypter core.RoundEncrypter, Rounds int) *FeistelNetwork {
 	return &FeistelNetwork{
 		KeyExpander:    KeyExpander,
@@ -24,8 +87,50 @@ func NewFeistelNetwork(KeyExpander core.KeyExpander, RoundEncrypter core.RoundEn
 	}
 }
 
+// NewFeistelNetworkFromConfig создаёт сеть Фейстеля с произвольным размером
+// блока и ветвей
+func NewFeistelNetworkFromConfig(config Config) (*FeistelNetwork, error) {
+	if config.BlockSize <= 0 {
+		return nil, errors.New("feistel: block size must be positive")
+	}
+	if config.Rounds <= 0 {
+		return nil, errors.New("feistel: number of rounds must be positive")
+	}
+	if (config.Function == nil) == (config.RoundEncrypter == nil) {
+		return nil, errors.New("feistel: exactly one of Function and RoundEncrypter must be set")
+	}
+	if config.SourceSize == 0 {
+		if config.BlockSize%2 != 0 {
+			return nil, errors.New("feistel: odd block size requires an explicit source size")
+		}
+		config.SourceSize = config.BlockSize / 2
+	}
+	if config.SourceSize < 0 || config.SourceSize >= config.BlockSize {
+		return nil, fmt.Errorf("feistel: source size %d out of range for %d-byte block", config.SourceSize, config.BlockSize)
+	}
+	if config.Combiner == nil {
+		config.Combiner = XOR{}
+	}
+	switch config.Combiner.(type) {
+	case Add32, *Add32:
+		if target := config.BlockSize - config.SourceSize; target%4 != 0 {
+			return nil, fmt.Errorf("feistel: Add32 requires a target branch that is a multiple of 4 bytes, got %d", target)
+		}
+	}
+
+	return &FeistelNetwork{
+		KeyExpander:    config.KeyExpander,
+		RoundEncrypter: config.RoundEncrypter,
+		Rounds:         config.Rounds,
+		config:         config,
+	}, nil
+}
+
 // SetEncryptionKey устанавливает ключ шифрования и генерирует раундовые ключи
 func (fn *FeistelNetwork) SetEncryptionKey(key []byte) error {
+	if fn.destroyed {
+		return core.ErrDestroyed
+	}
 	if fn.KeyExpander == nil {
 		return errors.New("key expander not set")
 	}
@@ -35,11 +140,19 @@ func (fn *FeistelNetwork) SetEncryptionKey(key []byte) error {
 		return err
 	}
 
-	if len(subkeys) != fn.Rounds {
+	pre, post := keyed(fn.config.PreWhitening), keyed(fn.config.PostWhitening)
+	if len(subkeys) != pre+fn.Rounds+post {
 		return errors.New("number of subkeys does not match Rounds")
 	}
 
-	fn.EncryptKeys = subkeys
+	fn.preKey, fn.postKey = nil, nil
+	if pre == 1 {
+		fn.preKey = subkeys[0]
+	}
+	if post == 1 {
+		fn.postKey = subkeys[len(subkeys)-1]
+	}
+	fn.EncryptKeys = subkeys[pre : pre+fn.Rounds]
 
 	fn.DecryptKeys = make([][]byte, fn.Rounds)
 	for i := 0; i < fn.Rounds; i++ {
@@ -49,6 +162,13 @@ func (fn *FeistelNetwork) SetEncryptionKey(key []byte) error {
 	return nil
 }
 
+func keyed(w Whitening) int {
+	if w != nil && w.Keyed() {
+		return 1
+	}
+	return 0
+}
+
 // SetDecryptionKey устанавливает ключ дешифрования
 func (fn *FeistelNetwork) SetDecryptionKey(key []byte) error {
 	return fn.SetEncryptionKey(key)
@@ -56,52 +176,203 @@ func (fn *FeistelNetwork) SetDecryptionKey(key []byte) error {
 
 // EncryptBlock шифрует блок данных
 func (fn *FeistelNetwork) EncryptBlock(block []byte) ([]byte, error) {
-	if fn.RoundEncrypter == nil {
-		return nil, errors.New("round encrypter not set")
-	}
-	if fn.EncryptKeys == nil {
-		return nil, errors.New("encryption key not set")
+	if err := fn.check(block, fn.EncryptKeys, "encryption key not set"); err != nil {
+		return nil, err
 	}
 
 	result := make([]byte, len(block))
 	copy(result, block)
 
+	var err error
+	if w := fn.config.PreWhitening; w != nil {
+		if result, err = w.Whiten(result, fn.preKey); err != nil {
+			return nil, err
+		}
+	}
+
 	for i := 0; i < fn.Rounds; i++ {
-		var err error
-		result, err = fn.RoundEncrypter.EncryptRound(result, fn.EncryptKeys[i])
+		if fn.config.Function != nil {
+			result, err = fn.encryptRound(result, fn.EncryptKeys[i])
+		} else {
+			result, err = fn.RoundEncrypter.EncryptRound(result, fn.EncryptKeys[i])
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if fn.config.Function != nil && !fn.config.FinalSwap {
+		result = fn.unswap(result)
+	}
+	if w := fn.config.PostWhitening; w != nil {
+		if result, err = w.Whiten(result, fn.postKey); err != nil {
+			return nil, err
+		}
+	}
+
 	return result, nil
 }
 
 // DecryptBlock дешифрует блок данных
 func (fn *FeistelNetwork) DecryptBlock(block []byte) ([]byte, error) {
-	if fn.RoundEncrypter == nil {
-		return nil, errors.New("round encrypter not set")
-	}
-	if fn.DecryptKeys == nil {
-		return nil, errors.New("decryption key not set")
+	if err := fn.check(block, fn.DecryptKeys, "decryption key not set"); err != nil {
+		return nil, err
 	}
 
 	result := make([]byte, len(block))
 	copy(result, block)
 
+	var err error
+	if w := fn.config.PostWhitening; w != nil {
+		if result, err = w.Unwhiten(result, fn.postKey); err != nil {
+			return nil, err
+		}
+	}
+	if fn.config.Function != nil && !fn.config.FinalSwap {
+		result = fn.swap(result)
+	}
+
 	for i := 0; i < fn.Rounds; i++ {
-		var err error
-		result, err = fn.RoundEncrypter.EncryptRound(result, fn.DecryptKeys[i])
+		if fn.config.Function != nil {
+			result, err = fn.decryptRound(result, fn.DecryptKeys[i])
+		} else {
+			result, err = fn.RoundEncrypter.EncryptRound(result, fn.DecryptKeys[i])
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if w := fn.config.PreWhitening; w != nil {
+		if result, err = w.Unwhiten(result, fn.preKey); err != nil {
+			return nil, err
+		}
+	}
+
 	return result, nil
 }
 
-func (d *FeistelNetwork) BlockSize() int {
-	return 8
+func (fn *FeistelNetwork) check(block []byte, keys [][]byte, noKey string) error {
+	if fn.destroyed {
+		return core.ErrDestroyed
+	}
+	if fn.RoundEncrypter == nil && fn.config.Function == nil {
+		return errors.New("round encrypter not set")
+	}
+	if keys == nil {
+		return errors.New(noKey)
+	}
+	if fn.config.BlockSize != 0 && len(block) != fn.config.BlockSize {
+		return fmt.Errorf("feistel: block must be exactly %d bytes", fn.config.BlockSize)
+	}
+	return nil
+}
+
+// encryptRound раунд режима Function: смешивает F(S) с T и меняет ветви местами
+func (fn *FeistelNetwork) encryptRound(block, roundKey []byte) ([]byte, error) {
+	source, target := fn.branches(block)
+	f, err := fn.f(source, roundKey)
+	if err != nil {
+		return nil, err
+	}
+	mixed := fn.config.Combiner.Combine(target, f)
+	if fn.config.SourceFirst {
+		return concat(mixed, source), nil
+	}
+	return concat(source, mixed), nil
+}
+
+// decryptRound обращает encryptRound
+func (fn *FeistelNetwork) decryptRound(block, roundKey []byte) ([]byte, error) {
+	targetSize := len(block) - fn.config.SourceSize
+	var source, mixed []byte
+	if fn.config.SourceFirst {
+		mixed, source = block[:targetSize], block[targetSize:]
+	} else {
+		source, mixed = block[:fn.config.SourceSize], block[fn.config.SourceSize:]
+	}
+	f, err := fn.f(source, roundKey)
+	if err != nil {
+		return nil, err
+	}
+	target := fn.config.Combiner.Separate(mixed, f)
+	if fn.config.SourceFirst {
+		return concat(source, target), nil
+	}
+	return concat(target, source), nil
+}
+
+// branches делит блок на исходную и целевую ветви
+func (fn *FeistelNetwork) branches(block []byte) (source, target []byte) {
+	if fn.config.SourceFirst {
+		return block[:fn.config.SourceSize], block[fn.config.SourceSize:]
+	}
+	targetSize := len(block) - fn.config.SourceSize
+	return block[targetSize:], block[:targetSize]
 }
 
-var _ core.SymmetricCipher = (*FeistelNetwork)(nil)
+func (fn *FeistelNetwork) f(source, roundKey []byte) ([]byte, error) {
+	f, err := fn.config.Function.F(source, roundKey)
+	if err != nil {
+		return nil, err
+	}
+	if want := fn.config.BlockSize - fn.config.SourceSize; len(f) != want {
+		return nil, fmt.Errorf("feistel: F returned %d bytes, want %d", len(f), want)
+	}
+	return f, nil
+}
+
+// unswap отменяет обмен ветвей последнего раунда; swap повторяет его
+func (fn *FeistelNetwork) unswap(block []byte) []byte {
+	if fn.config.SourceFirst {
+		return rotate(block, len(block)-fn.config.SourceSize)
+	}
+	return rotate(block, fn.config.SourceSize)
+}
+
+func (fn *FeistelNetwork) swap(block []byte) []byte {
+	if fn.config.SourceFirst {
+		return rotate(block, fn.config.SourceSize)
+	}
+	return rotate(block, len(block)-fn.config.SourceSize)
+}
+
+// rotate циклически сдвигает блок на k байт влево
+func rotate(block []byte, k int) []byte {
+	return concat(block[k:], block[:k])
+}
+
+func concat(a, b []byte) []byte {
+	result := make([]byte, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+// BlockSize возвращает размер блока из Config; в режиме NewFeistelNetwork — 8
+func (fn *FeistelNetwork) BlockSize() int {
+	if fn.config.BlockSize == 0 {
+		return 8
+	}
+	return fn.config.BlockSize
+}
+
+// Destroy затирает раундовые ключи и, если раундовая функция хранит ключевой
+// материал (реализует core.Destroyer), стирает и его
+func (fn *FeistelNetwork) Destroy() {
+	core.WipeKeys(fn.EncryptKeys)
+	core.WipeKeys(fn.DecryptKeys)
+	core.Wipe(fn.preKey, fn.postKey)
+	fn.EncryptKeys, fn.DecryptKeys = nil, nil
+	fn.preKey, fn.postKey = nil, nil
+	for _, part := range []any{fn.RoundEncrypter, fn.config.Function} {
+		if d, ok := part.(core.Destroyer); ok {
+			d.Destroy()
+		}
+	}
+	fn.destroyed = true
+}
+
+var (
+	_ core.SymmetricCipher = (*FeistelNetwork)(nil)
+	_ core.Destroyer       = (*FeistelNetwork)(nil)
+)
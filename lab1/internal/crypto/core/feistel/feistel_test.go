@@ -2,7 +2,11 @@ package feistel
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 )
 
 type MockKeyExpander struct {
@@ -118,3 +122,244 @@ func TestFeistelNetwork_NilRoundEncrypter(t *testing.T) {
 		t.Error("Expected error with nil round encrypter, got none")
 	}
 }
+
+func TestFeistelNetwork_Destroy(t *testing.T) {
+	fn := NewFeistelNetwork(&MockKeyExpander{rounds: 4}, &MockRoundEncrypter{}, 4)
+	key := []byte{0x01, 0x02, 0x03, 0x04}
+	if err := fn.SetEncryptionKey(key); err != nil {
+		t.Fatalf("SetEncryptionKey failed: %v", err)
+	}
+	roundKeys := fn.EncryptKeys
+
+	fn.Destroy()
+	for i, k := range roundKeys {
+		if !bytes.Equal(k, make([]byte, len(k))) {
+			t.Errorf("Round key %d was not wiped: %x", i, k)
+		}
+	}
+	if _, err := fn.EncryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock after Destroy: got %v", err)
+	}
+	if _, err := fn.DecryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("DecryptBlock after Destroy: got %v", err)
+	}
+	if err := fn.SetEncryptionKey(key); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("SetEncryptionKey after Destroy: got %v", err)
+	}
+}
+
+// hashFunction F на основе SHA-256, выдающая size байт
+type hashFunction struct {
+	size int
+}
+
+func (h hashFunction) F(half []byte, roundKey []byte) ([]byte, error) {
+	sum := sha256.Sum256(append(append([]byte{}, half...), roundKey...))
+	return sum[:h.size], nil
+}
+
+// countingExpander выдаёт n разных ключей размера size
+type countingExpander struct {
+	n, size int
+}
+
+func (e countingExpander) ExpandKey(key []byte) ([][]byte, error) {
+	keys := make([][]byte, e.n)
+	for i := range keys {
+		keys[i] = make([]byte, e.size)
+		for j := range keys[i] {
+			keys[i][j] = key[j%len(key)] + byte(i)
+		}
+	}
+	return keys, nil
+}
+
+func TestFeistelNetwork_Config(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+	}{
+		{"balanced 64-bit", Config{BlockSize: 8}},
+		{"balanced 128-bit", Config{BlockSize: 16}},
+		{"balanced, final swap", Config{BlockSize: 16, FinalSwap: true}},
+		{"source first", Config{BlockSize: 16, SourceFirst: true}},
+		{"source first, final swap", Config{BlockSize: 16, SourceFirst: true, FinalSwap: true}},
+		{"source-light unbalanced", Config{BlockSize: 12, SourceSize: 4}},
+		{"source-heavy unbalanced", Config{BlockSize: 12, SourceSize: 8}},
+		{"source-heavy, source first", Config{BlockSize: 12, SourceSize: 8, SourceFirst: true}},
+		{"odd block", Config{BlockSize: 7, SourceSize: 3, FinalSwap: true}},
+		{"addition mod 2^32", Config{BlockSize: 16, Combiner: Add32{}}},
+		{"whitening", Config{BlockSize: 16, PreWhitening: XORWhitening{}, PostWhitening: XORWhitening{}}},
+		{"post-whitening only", Config{BlockSize: 16, PostWhitening: XORWhitening{}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			const rounds = 5
+			config := tc.config
+			source := config.SourceSize
+			if source == 0 {
+				source = config.BlockSize / 2
+			}
+			keys := rounds + keyed(config.PreWhitening) + keyed(config.PostWhitening)
+			config.Rounds = rounds
+			config.Function = hashFunction{size: config.BlockSize - source}
+			config.KeyExpander = countingExpander{n: keys, size: config.BlockSize}
+
+			fn, err := NewFeistelNetworkFromConfig(config)
+			if err != nil {
+				t.Fatalf("NewFeistelNetworkFromConfig failed: %v", err)
+			}
+			if fn.BlockSize() != config.BlockSize {
+				t.Errorf("BlockSize() = %d, want %d", fn.BlockSize(), config.BlockSize)
+			}
+			if err := fn.SetEncryptionKey([]byte{1, 2, 3}); err != nil {
+				t.Fatalf("SetEncryptionKey failed: %v", err)
+			}
+
+			plaintext := make([]byte, config.BlockSize)
+			for i := range plaintext {
+				plaintext[i] = byte(i * 7)
+			}
+			ciphertext, err := fn.EncryptBlock(plaintext)
+			if err != nil {
+				t.Fatalf("EncryptBlock failed: %v", err)
+			}
+			if bytes.Equal(ciphertext, plaintext) {
+				t.Error("Ciphertext equals plaintext")
+			}
+			decrypted, err := fn.DecryptBlock(ciphertext)
+			if err != nil {
+				t.Fatalf("DecryptBlock failed: %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Decryption failed: got %x, want %x", decrypted, plaintext)
+			}
+		})
+	}
+}
+
+// Один раунд по определению: T ‖ S → S ‖ T ⊕ F(S) и его варианты
+func TestFeistelNetwork_RoundLayout(t *testing.T) {
+	block := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	key := []byte{0xAA}
+	f := hashFunction{size: 4}
+	wantF, _ := f.F(block[4:], key)
+
+	testCases := []struct {
+		name   string
+		config Config
+		want   []byte
+	}{
+		{
+			name:   "source last, final swap",
+			config: Config{SourceSize: 8, FinalSwap: true},
+			want:   append(append([]byte{}, block[4:]...), xorBytes(block[:4], wantF)...),
+		},
+		{
+			name:   "source last, no final swap",
+			config: Config{SourceSize: 8},
+			want:   append(xorBytes(block[:4], wantF), block[4:]...),
+		},
+	}
+	srcFirstF, _ := f.F(block[:8], key)
+	testCases = append(testCases, struct {
+		name   string
+		config Config
+		want   []byte
+	}{
+		name:   "source first, final swap",
+		config: Config{SourceSize: 8, SourceFirst: true, FinalSwap: true},
+		want:   append(xorBytes(block[8:], srcFirstF), block[:8]...),
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			config.BlockSize, config.Rounds = 12, 1
+			config.Function = f
+			config.KeyExpander = &MockKeyExpander{rounds: 1}
+			fn, err := NewFeistelNetworkFromConfig(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn.SetEncryptionKey(key)
+			got, err := fn.EncryptBlock(block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("got %x, want %x", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFeistelNetwork_ConfigErrors(t *testing.T) {
+	f := hashFunction{size: 4}
+	re := &MockRoundEncrypter{}
+	for _, tc := range []struct {
+		name   string
+		config Config
+	}{
+		{"zero block", Config{Rounds: 1, Function: f}},
+		{"zero rounds", Config{BlockSize: 8, Function: f}},
+		{"no function", Config{BlockSize: 8, Rounds: 1}},
+		{"both function and round encrypter", Config{BlockSize: 8, Rounds: 1, Function: f, RoundEncrypter: re}},
+		{"odd block without source size", Config{BlockSize: 7, Rounds: 1, Function: f}},
+		{"source fills block", Config{BlockSize: 8, SourceSize: 8, Rounds: 1, Function: f}},
+		{"Add32 with unaligned target", Config{BlockSize: 10, SourceSize: 4, Rounds: 1, Function: f, Combiner: Add32{}}},
+	} {
+		if _, err := NewFeistelNetworkFromConfig(tc.config); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+
+	// F возвращает 4 байта вместо 8
+	fn, _ := NewFeistelNetworkFromConfig(Config{BlockSize: 16, Rounds: 2, Function: f, KeyExpander: &MockKeyExpander{rounds: 2}})
+	fn.SetEncryptionKey([]byte{1})
+	if _, err := fn.EncryptBlock(make([]byte, 16)); err == nil {
+		t.Error("Expected error for F output of wrong size")
+	}
+	if _, err := fn.EncryptBlock(make([]byte, 8)); err == nil {
+		t.Error("Expected error for wrong block size")
+	}
+
+	// Отбеливанию с ключом нужен дополнительный ключ расписания
+	fn, _ = NewFeistelNetworkFromConfig(Config{BlockSize: 8, Rounds: 2, Function: f,
+		KeyExpander: &MockKeyExpander{rounds: 2}, PreWhitening: XORWhitening{}})
+	if err := fn.SetEncryptionKey([]byte{1}); err == nil {
+		t.Error("Expected error for missing whitening key")
+	}
+}
+
+func TestFeistelNetwork_DestroyWhitening(t *testing.T) {
+	fn, err := NewFeistelNetworkFromConfig(Config{
+		BlockSize: 8, Rounds: 2, Function: hashFunction{size: 4},
+		KeyExpander:  countingExpander{n: 4, size: 8},
+		PreWhitening: XORWhitening{}, PostWhitening: XORWhitening{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fn.SetEncryptionKey([]byte{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	pre, post := fn.preKey, fn.postKey
+	fn.Destroy()
+	if !bytes.Equal(pre, make([]byte, 8)) || !bytes.Equal(post, make([]byte, 8)) {
+		t.Errorf("Whitening keys were not wiped: %x %x", pre, post)
+	}
+}
+
+func TestAdd32(t *testing.T) {
+	target := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 1}
+	f := []byte{0, 0, 0, 1, 0, 0, 0, 2}
+	mixed := Add32{}.Combine(target, f)
+	if want := []byte{0, 0, 0, 0, 0, 0, 0, 3}; !bytes.Equal(mixed, want) {
+		t.Errorf("Combine = %x, want %x", mixed, want)
+	}
+	if back := (Add32{}).Separate(mixed, f); !bytes.Equal(back, target) {
+		t.Errorf("Separate = %x, want %x", back, target)
+	}
+}
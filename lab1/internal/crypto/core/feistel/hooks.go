@@ -0,0 +1,92 @@
+package feistel
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
+)
+
+// XOR смешивание сложением по модулю 2 (классическая сеть Фейстеля)
+type XOR struct{}
+
+func (XOR) Combine(target, f []byte) []byte {
+	return xorBytes(target, f)
+}
+
+func (XOR) Separate(target, f []byte) []byte {
+	return xorBytes(target, f)
+}
+
+// Add32 смешивание сложением по модулю 2^32 слов по 4 байта (big-endian);
+// размер целевой ветви должен быть кратен 4
+type Add32 struct{}
+
+func (Add32) Combine(target, f []byte) []byte {
+	result := make([]byte, len(target))
+	for i := 0; i+4 <= len(target); i += 4 {
+		binary.BigEndian.PutUint32(result[i:], binary.BigEndian.Uint32(target[i:])+binary.BigEndian.Uint32(f[i:]))
+	}
+	return result
+}
+
+func (Add32) Separate(target, f []byte) []byte {
+	result := make([]byte, len(target))
+	for i := 0; i+4 <= len(target); i += 4 {
+		binary.BigEndian.PutUint32(result[i:], binary.BigEndian.Uint32(target[i:])-binary.BigEndian.Uint32(f[i:]))
+	}
+	return result
+}
+
+// XORWhitening отбеливание сложением блока с ключом из расписания
+type XORWhitening struct{}
+
+func (XORWhitening) Whiten(block, key []byte) ([]byte, error) {
+	if len(key) != len(block) {
+		return nil, errors.New("feistel: whitening key must match block size")
+	}
+	return xorBytes(block, key), nil
+}
+
+func (w XORWhitening) Unwhiten(block, key []byte) ([]byte, error) {
+	return w.Whiten(block, key)
+}
+
+func (XORWhitening) Keyed() bool {
+	return true
+}
+
+// PermutationWhitening бесключевая перестановка битов блока, например
+// начальная перестановка DES: Forward применяется при шифровании, Inverse —
+// при расшифровании
+type PermutationWhitening struct {
+	Forward *common.Permutation
+	Inverse *common.Permutation
+}
+
+func (w PermutationWhitening) Whiten(block, _ []byte) ([]byte, error) {
+	return w.Forward.Permute(block)
+}
+
+func (w PermutationWhitening) Unwhiten(block, _ []byte) ([]byte, error) {
+	return w.Inverse.Permute(block)
+}
+
+func (PermutationWhitening) Keyed() bool {
+	return false
+}
+
+func xorBytes(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range result {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
+
+var (
+	_ Combiner  = XOR{}
+	_ Combiner  = Add32{}
+	_ Whitening = XORWhitening{}
+	_ Whitening = PermutationWhitening{}
+)
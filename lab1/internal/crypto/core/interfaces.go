@@ -10,7 +10,15 @@ type RoundEncrypter interface {
 	EncryptRound(block []byte, roundKey []byte) ([]byte, error)
 }
 
+// FeistelFunction определяет раундовую функцию F сети Фейстеля: по исходной
+// ветви и раундовому ключу возвращает значение, смешиваемое с целевой ветвью
+type FeistelFunction interface {
+	F(half []byte, roundKey []byte) ([]byte, error)
+}
+
 // SymmetricCipher определяет функционал симметричного шифрования
+// После установки ключа EncryptBlock и DecryptBlock не должны менять состояние
+// шифра: CipherContext вызывает их одновременно из нескольких горутин.
 type SymmetricCipher interface {
 	EncryptBlock(block []byte) ([]byte, error)
 	DecryptBlock(block []byte) ([]byte, error)
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNonceReuse возвращается, если пара (ключ, nonce) уже использовалась для шифрования
+var ErrNonceReuse = errors.New("nonce reuse: this (key, nonce) pair was already used for encryption")
+
+// NonceStore хранилище использованных пар (ключ, nonce).
+// Реализации должны быть безопасны для конкурентного использования.
+type NonceStore interface {
+	// MarkUsed атомарно отмечает nonce как использованный под ключом keyID.
+	// Если пара уже встречалась, возвращает ErrNonceReuse.
+	MarkUsed(keyID, nonce []byte) error
+}
+
+// MemoryNonceStore хранит использованные nonce в памяти процесса
+type MemoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewMemoryNonceStore создаёт пустое хранилище в памяти
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{seen: make(map[string]struct{})}
+}
+
+func (s *MemoryNonceStore) MarkUsed(keyID, nonce []byte) error {
+	entry := nonceEntry(keyID, nonce)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[entry]; ok {
+		return ErrNonceReuse
+	}
+	s.seen[entry] = struct{}{}
+	return nil
+}
+
+// FileNonceStore хранит использованные nonce в файле, чтобы защита
+// переживала перезапуск процесса. Файл дописывается по одной строке
+// "<keyID hex> <nonce hex>" на каждую пару.
+type FileNonceStore struct {
+	mu   sync.Mutex
+	file *os.File
+	seen map[string]struct{}
+}
+
+// NewFileNonceStore открывает (или создаёт) файл хранилища и загружает из него уже использованные пары
+func NewFileNonceStore(path string) (*FileNonceStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			f.Close()
+			return nil, fmt.Errorf("nonce store %s: malformed line %d", path, line)
+		}
+		seen[text] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &FileNonceStore{file: f, seen: seen}, nil
+}
+
+func (s *FileNonceStore) MarkUsed(keyID, nonce []byte) error {
+	entry := nonceEntry(keyID, nonce)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("nonce store is closed")
+	}
+	if _, ok := s.seen[entry]; ok {
+		return ErrNonceReuse
+	}
+	// Пара записывается на диск до шифрования: при сбое nonce
+	// скорее окажется потерянным, чем использованным повторно
+	if _, err := s.file.WriteString(entry + "\n"); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.seen[entry] = struct{}{}
+	return nil
+}
+
+// Close закрывает файл хранилища
+func (s *FileNonceStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func nonceEntry(keyID, nonce []byte) string {
+	return hex.EncodeToString(keyID) + " " + hex.EncodeToString(nonce)
+}
+
+// NonceGuard запрещает повторное шифрование под одной парой (ключ, nonce)
+// в потоковых режимах (CTR, OFB, CFB), где повтор nonce раскрывает открытый текст.
+// Передаётся в NewCipherContext как дополнительная опция.
+type NonceGuard struct {
+	store NonceStore
+	keyID []byte
+}
+
+// NewNonceGuard создаёт защиту для ключа key. Сам ключ в хранилище не попадает:
+// пары идентифицируются по отпечатку SHA-256 ключа.
+func NewNonceGuard(store NonceStore, key []byte) *NonceGuard {
+	h := sha256.New()
+	h.Write([]byte("cryptography/core nonce guard v1\x00"))
+	h.Write(key)
+	return &NonceGuard{store: store, keyID: h.Sum(nil)[:16]}
+}
+
+// Use отмечает nonce как использованный; возвращает ErrNonceReuse при повторе
+func (g *NonceGuard) Use(nonce []byte) error {
+	return g.store.MarkUsed(g.keyID, nonce)
+}
+
+// isStreamMode сообщает, превращает ли режим блочный шифр в потоковый,
+// то есть раскрывает ли повтор nonce XOR открытых текстов
+func isStreamMode(mode CipherMode) bool {
+	return mode == CTR || mode == OFB || mode == CFB
+}
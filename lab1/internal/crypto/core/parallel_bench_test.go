@@ -0,0 +1,72 @@
+package core_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+// BenchmarkParallelModes сравнивает последовательную обработку (один воркер)
+// с разбиением на диапазоны блоков по числу CPU для режимов, допускающих распараллеливание.
+//
+//	go test -run '^$' -bench ParallelModes ./lab1/internal/crypto/core
+func BenchmarkParallelModes(b *testing.B) {
+	key := []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+	iv := bytes.Repeat([]byte{0x5A}, 8)
+	c := des.NewDES()
+	if err := c.SetEncryptionKey(key); err != nil {
+		b.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("parallel"), 64<<10/8)
+
+	for _, bc := range []struct {
+		mode    core.CipherMode
+		decrypt bool
+	}{
+		{core.CBC, true},
+		{core.CFB, true},
+		{core.CTR, false},
+		{core.CTR, true},
+	} {
+		op := "encrypt"
+		if bc.decrypt {
+			op = "decrypt"
+		}
+		for _, workers := range benchWorkers() {
+			b.Run(fmt.Sprintf("DES-%s-%s/workers=%d", bc.mode, op, workers), func(b *testing.B) {
+				ctx := core.NewCipherContext(c, bc.mode, core.PadPKCS7, iv)
+				ctx.SetWorkers(workers)
+				input := data
+				run := ctx.Encrypt
+				if bc.decrypt {
+					encrypted, err := ctx.Encrypt(data)
+					if err != nil {
+						b.Fatal(err)
+					}
+					input, run = encrypted, ctx.Decrypt
+				}
+
+				b.SetBytes(int64(len(data)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := run(input); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// benchWorkers число воркеров для сравнения: 1 — последовательная обработка
+func benchWorkers() []int {
+	workers := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workers = append(workers, n)
+	}
+	return workers
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretKeyRedacted(t *testing.T) {
+	raw := []byte{0xDE, 0xAD, 0xBE, 0xEF, 0x42}
+	key := NewSecretKey(raw)
+
+	type config struct {
+		Name   string
+		Key    SecretKey
+		hidden SecretKey
+	}
+	cfg := config{Name: "archive", Key: key, hidden: key}
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("loaded", "key", key, "config", cfg)
+
+	outputs := []string{logs.String()}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d", "%08b"} {
+		outputs = append(outputs, fmt.Sprintf(format, key), fmt.Sprintf(format, cfg), fmt.Sprintf(format, &cfg))
+	}
+	for _, out := range outputs {
+		lower := strings.ToLower(out)
+		if strings.Contains(lower, "deadbeef") || strings.Contains(out, "222 173") || strings.Contains(out, "[222") {
+			t.Errorf("key material leaked: %s", out)
+		}
+	}
+	if got := fmt.Sprint(key); got != redacted {
+		t.Errorf("Sprint = %q, want %q", got, redacted)
+	}
+
+	if _, err := json.Marshal(cfg); err == nil {
+		t.Error("json.Marshal must refuse to serialize a secret key")
+	}
+
+	// Исходный срез можно затереть: ключ хранит собственную копию
+	Wipe(raw)
+	err := key.Use(func(k []byte) error {
+		if !bytes.Equal(k, []byte{0xDE, 0xAD, 0xBE, 0xEF, 0x42}) {
+			t.Errorf("Use got %x", k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecretKeyDestroy(t *testing.T) {
+	key := NewSecretKey(testKey)
+	var stored []byte
+	key.Use(func(k []byte) error { stored = k; return nil })
+
+	c := &aesCipher{}
+	if err := key.SetOn(c); err != nil {
+		t.Fatalf("SetOn: %v", err)
+	}
+
+	copyOfKey := key
+	copyOfKey.Destroy()
+	if !bytes.Equal(stored, make([]byte, len(testKey))) {
+		t.Errorf("key bytes were not wiped: %x", stored)
+	}
+	if key.Len() != 0 {
+		t.Errorf("Len after Destroy = %d", key.Len())
+	}
+	if err := key.Use(func([]byte) error { return nil }); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("Use after Destroy: got %v", err)
+	}
+	if err := key.SetOn(c); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("SetOn after Destroy: got %v", err)
+	}
+}
+
+func TestCipherContextDestroy(t *testing.T) {
+	iv := bytes.Repeat([]byte{0x11}, 16)
+	ctx := NewCipherContext(newAESCipher(t, testKey), CTR, PadPKCS7, iv)
+	ciphertext, err := ctx.Encrypt([]byte("before destroy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx.Destroy()
+	if !bytes.Equal(iv, make([]byte, 16)) {
+		t.Errorf("IV was not wiped: %x", iv)
+	}
+	if _, err := ctx.Encrypt([]byte("after")); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("Encrypt after Destroy: got %v", err)
+	}
+	if _, err := ctx.Decrypt(ciphertext); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("Decrypt after Destroy: got %v", err)
+	}
+	if err := ctx.EncryptFile("in", "out"); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("EncryptFile after Destroy: got %v", err)
+	}
+	if err := ctx.EncryptStreamAEAD(bytes.NewReader(nil), &bytes.Buffer{}, 0); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("EncryptStreamAEAD after Destroy: got %v", err)
+	}
+
+	mac, err := NewCMAC(newAESCipher(t, testKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1 := mac.k1
+	mac.Destroy()
+	if !bytes.Equal(k1, make([]byte, 16)) {
+		t.Error("CMAC subkey was not wiped")
+	}
+	if _, err := mac.MAC([]byte("x")); !errors.Is(err, ErrDestroyed) {
+		t.Errorf("MAC after Destroy: got %v", err)
+	}
+}
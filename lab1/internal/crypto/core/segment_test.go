@@ -0,0 +1,127 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+func newDES(t *testing.T, key string) *des.DES {
+	t.Helper()
+	k, _ := hex.DecodeString(key)
+	c := des.NewDES()
+	if err := c.SetEncryptionKey(k); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDecryptionKey(k); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// Тест: CFB-s и OFB-s на примерах FIPS 81 (ключ 0123456789abcdef, "Now is the time for all ")
+func TestSegmentModesFIPS81(t *testing.T) {
+	c := newDES(t, "0123456789abcdef")
+	iv, _ := hex.DecodeString("1234567890abcdef")
+	plaintext := []byte("Now is the time for all ")
+
+	tests := []struct {
+		mode    core.CipherMode
+		segment core.SegmentSize
+		want    string
+	}{
+		{core.CFB, core.CFB1, "cd1ec959add480f11ee40c517f29fb52b282946f94765a13"},
+		{core.CFB, core.CFB8, "f31fda07011462ee187f43d80a7cd9b5b0d290da6e5b9a87"},
+		{core.CFB, core.CFB64, "f3096249c7f46e51a69e839b1a92f78403467133898ea622"},
+		{core.OFB, 64, "f3096249c7f46e5135f24a242eeb3d3f3d6d5be3255af8c3"},
+	}
+	for _, tt := range tests {
+		ctx := core.NewCipherContext(c, tt.mode, core.PadZeros, iv, tt.segment)
+		got, err := ctx.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("%s-%d: %v", tt.mode, tt.segment, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s-%d = %x, want %s", tt.mode, tt.segment, got, tt.want)
+		}
+		decrypted, err := ctx.Decrypt(got)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("%s-%d: Decrypt = %q, %v", tt.mode, tt.segment, decrypted, err)
+		}
+	}
+}
+
+// Тест: известные ответы TMOVS (NIST SP 800-20) для CFB с переменным текстом:
+// ключ 0101010101010101, IV — переменный текст, нулевой открытый текст,
+// первый сегмент шифртекста равен старшим s битам E(IV)
+func TestSegmentModesTMOVS(t *testing.T) {
+	c := newDES(t, "0101010101010101")
+	vectors := []struct{ iv, ecb string }{
+		{"8000000000000000", "95f8a5e5dd31d900"},
+		{"4000000000000000", "dd7f121ca5015619"},
+		{"2000000000000000", "2e8653104f3834ea"},
+		{"1000000000000000", "4bd388ff6cd81d4f"},
+		{"0800000000000000", "20b9e767b2fb1456"},
+	}
+	for _, v := range vectors {
+		iv, _ := hex.DecodeString(v.iv)
+		want, _ := hex.DecodeString(v.ecb)
+
+		cfb8, err := core.NewCipherContext(c, core.CFB, core.PadZeros, iv, core.CFB8).Encrypt([]byte{0})
+		if err != nil || len(cfb8) != 1 || cfb8[0] != want[0] {
+			t.Errorf("CFB8, IV %s: got %x (%v), want %02x", v.iv, cfb8, err, want[0])
+		}
+		cfb1, err := core.NewCipherContext(c, core.CFB, core.PadZeros, iv, core.CFB1).Encrypt([]byte{0})
+		if err != nil || cfb1[0]>>7 != want[0]>>7 {
+			t.Errorf("CFB1, IV %s: first bit %d (%v), want %d", v.iv, cfb1[0]>>7, err, want[0]>>7)
+		}
+		cfb64, err := core.NewCipherContext(c, core.CFB, core.PadZeros, iv, core.CFB64).Encrypt(make([]byte, 8))
+		if err != nil || !bytes.Equal(cfb64, want) {
+			t.Errorf("CFB64, IV %s: got %x (%v), want %x", v.iv, cfb64, err, want)
+		}
+	}
+}
+
+// Тест: паддинг до сегмента, OFB-s и недопустимые размеры сегмента
+func TestSegmentModesRoundTrip(t *testing.T) {
+	c := newDES(t, "133457799bbcdff1")
+	iv := bytes.Repeat([]byte{0x5A}, 8)
+	data := []byte("segment sizes smaller than the block")
+
+	for _, mode := range []core.CipherMode{core.CFB, core.OFB} {
+		for _, s := range []core.SegmentSize{1, 2, 4, 8, 16, 32, 64} {
+			ctx := core.NewCipherContext(c, mode, core.PadPKCS7, iv, s)
+			encrypted, err := ctx.Encrypt(data)
+			if err != nil {
+				t.Fatalf("%s-%d: %v", mode, s, err)
+			}
+			unit := max(1, int(s)/8)
+			if want := (len(data)/unit + 1) * unit; len(encrypted) != want {
+				t.Errorf("%s-%d: ciphertext length %d, want %d", mode, s, len(encrypted), want)
+			}
+			decrypted, err := ctx.Decrypt(encrypted)
+			if err != nil || !bytes.Equal(decrypted, data) {
+				t.Errorf("%s-%d: Decrypt = %q, %v", mode, s, decrypted, err)
+			}
+		}
+	}
+
+	// OFB-8: гамма не зависит от открытого текста
+	ofb := core.NewCipherContext(c, core.OFB, core.PadZeros, iv, core.CFB8)
+	stream, _ := ofb.Encrypt(make([]byte, len(data)))
+	encrypted, _ := ofb.Encrypt(data)
+	for i := range data {
+		if encrypted[i] != data[i]^stream[i] {
+			t.Fatalf("OFB-8 keystream depends on plaintext at byte %d", i)
+		}
+	}
+
+	for _, s := range []core.SegmentSize{3, 12, 72} {
+		if _, err := core.NewCipherContext(c, core.CFB, core.PadPKCS7, iv, s).Encrypt(data); err == nil {
+			t.Errorf("CFB-%d: expected error", s)
+		}
+	}
+}
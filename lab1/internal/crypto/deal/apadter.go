@@ -1,52 +1,184 @@
 package deal
 
 import (
+	"bytes"
 	"errors"
+	"sync"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
 )
 
+// DESAdapter раунд DEAL: (x, y) → (E_K(x) ⊕ y, x), где E_K — DES на
+// раундовом ключе
 type DESAdapter struct {
-	des core.SymmetricCipher
+	rounds *roundCiphers
 }
 
 func NewDESAdapter(des core.SymmetricCipher) *DESAdapter {
 	return &DESAdapter{
-		des: des,
+		rounds: &roundCiphers{des: des},
 	}
 }
 
 func (da *DESAdapter) EncryptRound(block []byte, roundKey []byte) ([]byte, error) {
-	blockSize := len(block)
-	if blockSize != 16 {
-		return nil, errors.New("DEAL block size must be 16 bytes (128 bits)")
+	left, right, err := split(block)
+	if err != nil {
+		return nil, err
 	}
 
-	halfSize := blockSize / 2
+	fResult, err := da.rounds.encrypt(left, roundKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 16)
+	copy(result[:8], xorBytes(right, fResult))
+	copy(result[8:], left)
+
+	return result, nil
+}
 
-	left := block[:halfSize]
-	right := block[halfSize:]
+// DecryptRound обращает EncryptRound: (x', y') → (y', E_K(y') ⊕ x')
+func (da *DESAdapter) DecryptRound(block []byte, roundKey []byte) ([]byte, error) {
+	left, right, err := split(block)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := da.des.SetEncryptionKey(roundKey); err != nil {
+	fResult, err := da.rounds.encrypt(right, roundKey)
+	if err != nil {
 		return nil, err
 	}
 
-	fResult, err := da.des.EncryptBlock(right)
+	result := make([]byte, 16)
+	copy(result[:8], right)
+	copy(result[8:], xorBytes(left, fResult))
+
+	return result, nil
+}
+
+// F возвращает E_K(half) — раундовую функцию DEAL для core.FeistelFunction
+func (da *DESAdapter) F(half []byte, roundKey []byte) ([]byte, error) {
+	return da.rounds.encrypt(half, roundKey)
+}
+
+// split проверяет размер блока и делит его на половины
+func split(block []byte) ([]byte, []byte, error) {
+	if len(block) != 16 {
+		return nil, nil, errors.New("DEAL block size must be 16 bytes (128 bits)")
+	}
+	return block[:8], block[8:], nil
+}
+
+// Destroy стирает раундовые ключи внутренних DES
+func (da *DESAdapter) Destroy() {
+	da.rounds.Destroy()
+}
+
+// legacyAdapter раунд варианта LegacySHA256: (L, R) → (L ⊕ E_K(R), R).
+// Половины не меняются местами, поэтому раунд сам себе обратен.
+type legacyAdapter struct {
+	rounds *roundCiphers
+}
+
+func (la *legacyAdapter) EncryptRound(block []byte, roundKey []byte) ([]byte, error) {
+	left, right, err := split(block)
 	if err != nil {
 		return nil, err
 	}
 
-	newLeft := xorBytes(left, fResult)
+	fResult, err := la.rounds.encrypt(right, roundKey)
+	if err != nil {
+		return nil, err
+	}
 
-	result := make([]byte, blockSize)
-	copy(result[:halfSize], newLeft)
-	copy(result[halfSize:], right)
+	result := make([]byte, 16)
+	copy(result[:8], xorBytes(left, fResult))
+	copy(result[8:], right)
 
 	return result, nil
 }
 
-func (da *DESAdapter) DecryptRound(block []byte, roundKey []byte) ([]byte, error) {
-	return da.EncryptRound(block, roundKey)
+func (la *legacyAdapter) DecryptRound(block []byte, roundKey []byte) ([]byte, error) {
+	return la.EncryptRound(block, roundKey)
+}
+
+func (la *legacyAdapter) Destroy() {
+	la.rounds.Destroy()
+}
+
+// roundCiphers вычисляет E_K для раундов DEAL. При установке ключа DEAL для
+// каждого раундового ключа заводится свой экземпляр DES (prepare), поэтому
+// раунд не меняет общего состояния и блоки DEAL можно шифровать из нескольких
+// горутин. Для неподготовленного ключа или DES неизвестной реализации общий
+// экземпляр des перенастраивается под мьютексом.
+type roundCiphers struct {
+	des     core.SymmetricCipher
+	mu      sync.Mutex
+	keys    [][]byte
+	ciphers []core.SymmetricCipher
+}
+
+// prepare заводит экземпляры DES на раундовые ключи roundKeys вместо прежних
+func (rc *roundCiphers) prepare(roundKeys [][]byte) error {
+	rc.release()
+	newDES := newDESLike(rc.des)
+	if newDES == nil {
+		return nil
+	}
+	for _, key := range roundKeys {
+		c := newDES()
+		if err := c.SetEncryptionKey(key); err != nil {
+			rc.release()
+			return err
+		}
+		rc.keys = append(rc.keys, append([]byte{}, key...))
+		rc.ciphers = append(rc.ciphers, c)
+	}
+	return nil
+}
+
+func (rc *roundCiphers) encrypt(block, roundKey []byte) ([]byte, error) {
+	for i, key := range rc.keys {
+		if bytes.Equal(key, roundKey) {
+			return rc.ciphers[i].EncryptBlock(block)
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if err := rc.des.SetEncryptionKey(roundKey); err != nil {
+		return nil, err
+	}
+	return rc.des.EncryptBlock(block)
+}
+
+// release стирает экземпляры, заведённые prepare
+func (rc *roundCiphers) release() {
+	core.WipeKeys(rc.keys)
+	for _, c := range rc.ciphers {
+		core.Destroy(c)
+	}
+	rc.keys, rc.ciphers = nil, nil
+}
+
+// Destroy стирает раундовые ключи всех экземпляров DES
+func (rc *roundCiphers) Destroy() {
+	rc.release()
+	core.Destroy(rc.des)
+}
+
+// newDESLike возвращает конструктор DES той же реализации, что и c,
+// или nil, если реализация неизвестна
+func newDESLike(c core.SymmetricCipher) func() core.SymmetricCipher {
+	switch c.(type) {
+	case *des.DES:
+		return func() core.SymmetricCipher { return des.NewDES() }
+	case *des.FastDES:
+		return func() core.SymmetricCipher { return des.NewFastDES() }
+	}
+	return nil
 }
 
 func xorBytes(a, b []byte) []byte {
@@ -61,4 +193,8 @@ func xorBytes(a, b []byte) []byte {
 	return result
 }
 
-var _ core.RoundEncrypter = (*DESAdapter)(nil)
+var (
+	_ core.RoundEncrypter  = (*DESAdapter)(nil)
+	_ core.FeistelFunction = (*DESAdapter)(nil)
+	_ core.RoundEncrypter  = (*legacyAdapter)(nil)
+)
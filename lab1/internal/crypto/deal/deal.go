@@ -5,11 +5,20 @@ import (
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core/feistel"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
 )
 
+// DEALCipher реализует DEAL (Кнудсен, 1998): сеть Фейстеля со 128-битным
+// блоком, раундовой функцией DES и 6 (DEAL-128/192) или 8 (DEAL-256) раундами.
+// Шифртекст — (x_r, y_r) после последнего раунда.
 type DEALCipher struct {
 	feistelNetwork *feistel.FeistelNetwork
+	keyExpander    *DEALKeyExpander
+	rounds         *roundCiphers
+	variant        Variant
 	blockSize      int
+	keyPolicy      des.KeyPolicy
+	keyReport      des.KeyReport
 }
 
 func NewDEAL128(desCipher core.SymmetricCipher) *DEALCipher {
@@ -24,27 +33,116 @@ func NewDEAL256(desCipher core.SymmetricCipher) *DEALCipher {
 	return newDEAL(desCipher, 32)
 }
 
-func newDEAL(desCipher core.SymmetricCipher, keySize int) *DEALCipher {
+// NewDEALVariant создаёт DEAL с ключом keySize байт (16, 24 или 32)
+// выбранного варианта. LegacySHA256 нужен только для чтения старых данных.
+func NewDEALVariant(desCipher core.SymmetricCipher, keySize int, variant Variant) (*DEALCipher, error) {
+	if keySize != 16 && keySize != 24 && keySize != 32 {
+		return nil, errors.New("DEAL supports only 128, 192, or 256-bit keys")
+	}
+	if variant != Knudsen && variant != LegacySHA256 {
+		return nil, errors.New("unknown DEAL variant")
+	}
 	blockSize := 16
 
-	desAdapter := NewDESAdapter(desCipher)
-
-	keyExpander := NewDEALKeyExpander(keySize)
-
-	feistelNetwork := feistel.NewFeistelNetwork(keyExpander, desAdapter, keyExpander.rounds)
+	keyExpander := NewDEALKeyExpanderVariant(keySize, variant)
+	adapter := NewDESAdapter(desCipher)
+
+	// Раунд DEAL (x, y) → (E_K(x) ⊕ y, x): исходная ветвь первая, обмен
+	// после последнего раунда сохраняется
+	config := feistel.Config{
+		BlockSize:   blockSize,
+		SourceFirst: true,
+		FinalSwap:   true,
+		Rounds:      keyExpander.rounds,
+		KeyExpander: keyExpander,
+		Function:    adapter,
+	}
+	if variant == LegacySHA256 {
+		config.Function = nil
+		config.RoundEncrypter = &legacyAdapter{rounds: adapter.rounds}
+	}
+	feistelNetwork, err := feistel.NewFeistelNetworkFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
 	return &DEALCipher{
 		feistelNetwork: feistelNetwork,
+		keyExpander:    keyExpander,
+		rounds:         adapter.rounds,
+		variant:        variant,
 		blockSize:      blockSize,
+	}, nil
+}
+
+// newDEAL создаёт DEAL по Кнудсену; keySize задают NewDEAL128/192/256,
+// поэтому ошибка означает ошибку в самом пакете
+func newDEAL(desCipher core.SymmetricCipher, keySize int) *DEALCipher {
+	d, err := NewDEALVariant(desCipher, keySize, Knudsen)
+	if err != nil {
+		panic(err)
 	}
+	return d
+}
+
+// Variant возвращает вариант DEAL
+func (d *DEALCipher) Variant() Variant {
+	return d.variant
 }
 
 func (d *DEALCipher) SetEncryptionKey(key []byte) error {
-	return d.feistelNetwork.SetEncryptionKey(key)
+	return d.setKey(key, d.feistelNetwork.SetEncryptionKey)
 }
 
 func (d *DEALCipher) SetDecryptionKey(key []byte) error {
-	return d.feistelNetwork.SetDecryptionKey(key)
+	return d.setKey(key, d.feistelNetwork.SetDecryptionKey)
+}
+
+// setKey проверяет раундовые ключи, заводит на них экземпляры DES раундов
+// и передаёт ключ сети Фейстеля через set
+func (d *DEALCipher) setKey(key []byte, set func([]byte) error) error {
+	roundKeys, err := d.keyExpander.ExpandKey(key)
+	if err != nil {
+		return err
+	}
+	defer core.WipeKeys(roundKeys)
+
+	if err := d.checkKey(roundKeys); err != nil {
+		return err
+	}
+	if err := d.rounds.prepare(roundKeys); err != nil {
+		return err
+	}
+	return set(key)
+}
+
+// SetKeyPolicy задаёт политику проверки ключа: StrictKeys отвергает ключ,
+// если хотя бы один раундовый ключ DES слабый, полуслабый или возможно слабый
+func (d *DEALCipher) SetKeyPolicy(policy des.KeyPolicy) {
+	d.keyPolicy = policy
+}
+
+// KeyReport возвращает недостатки раундовых ключей последнего установленного ключа
+func (d *DEALCipher) KeyReport() des.KeyReport {
+	return d.keyReport
+}
+
+// checkKey проверяет раундовые ключи, которые DEAL передаёт DES. Чётность
+// не проверяется: раундовые ключи выводятся из ключа DEAL, а не задаются вручную.
+func (d *DEALCipher) checkKey(roundKeys [][]byte) error {
+	var report des.KeyReport
+	for _, roundKey := range roundKeys {
+		r, err := des.CheckKey(roundKey)
+		if err != nil {
+			return err
+		}
+		report = report.Merge(r.Without(des.ParityError))
+	}
+	if d.keyPolicy == des.StrictKeys && !report.OK() {
+		return report.Err()
+	}
+	d.keyReport = report
+	return nil
 }
 
 func (d *DEALCipher) EncryptBlock(block []byte) ([]byte, error) {
@@ -67,4 +165,12 @@ func (d *DEALCipher) BlockSize() int {
 	return d.blockSize
 }
 
-var _ core.SymmetricCipher = (*DEALCipher)(nil)
+// Destroy затирает раундовые ключи DEAL и ключ внутреннего DES
+func (d *DEALCipher) Destroy() {
+	d.feistelNetwork.Destroy()
+}
+
+var (
+	_ core.SymmetricCipher = (*DEALCipher)(nil)
+	_ core.Destroyer       = (*DEALCipher)(nil)
+)
@@ -3,8 +3,10 @@ package deal
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/hex"
 	"testing"
 
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
 )
 
@@ -294,3 +296,248 @@ func TestDEALRoundCounts(t *testing.T) {
 		})
 	}
 }
+
+// Ключи DEAL проверяются по раундовым ключам DES, без учёта их чётности
+func TestDEALKeyPolicy(t *testing.T) {
+	for _, keySize := range []int{16, 24, 32} {
+		key := make([]byte, keySize)
+		rand.Read(key)
+
+		dealCipher := newDEAL(des.NewDES(), keySize)
+		dealCipher.SetKeyPolicy(des.StrictKeys)
+		if err := dealCipher.SetEncryptionKey(key); err != nil {
+			t.Fatalf("DEAL-%d strict SetEncryptionKey failed: %v", keySize*8, err)
+		}
+		if !dealCipher.KeyReport().OK() {
+			t.Errorf("DEAL-%d: unexpected issues %v", keySize*8, dealCipher.KeyReport().Issues)
+		}
+
+		roundKeys, _ := NewDEALKeyExpander(keySize).ExpandKey(key)
+		for i, roundKey := range roundKeys {
+			report, err := des.CheckKey(roundKey)
+			if err != nil {
+				t.Fatalf("CheckKey failed: %v", err)
+			}
+			if !report.Without(des.ParityError).OK() {
+				t.Errorf("DEAL-%d round key %d: issues %v", keySize*8, i, report.Issues)
+			}
+		}
+	}
+
+	if err := NewDEAL128(des.NewDES()).SetEncryptionKey(make([]byte, 15)); err == nil {
+		t.Error("Expected error for 15-byte key")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func sequentialKey(size int) []byte {
+	key := make([]byte, size)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}
+
+// Векторы не внешние: опубликованных KAT из пакета DEAL для AES под рукой
+// нет, поэтому они посчитаны отдельной реализацией на Python поверх DES из
+// OpenSSL (openssl enc -des-ecb) при том же прочтении спецификации:
+// RK_i = E_K(K_j ⊕ c_i ⊕ RK_(i-1)), K = 0123456789abcdef, бит i в ⟨i⟩
+// нумеруется со старшего. Тест ловит регрессии, но не ошибку в прочтении,
+// поэтому в реестре этот вариант доступен только как deal-*-knudsen.
+func TestDEALKnudsenVectors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		key        []byte
+		plaintext  string
+		roundKeys  []string
+		ciphertext string
+	}{
+		{
+			name:       "DEAL-128 zero",
+			key:        make([]byte, 16),
+			plaintext:  "00000000000000000000000000000000",
+			roundKeys:  []string{"d5d44ff720683d0d", "5661e9804fe87b77", "ffe7ded5509a4666", "3df3cc80990ac660", "e6c81e4ad91192e7", "eba67119bbf0af22"},
+			ciphertext: "37c38426079ac9de02cc32f106f43287",
+		},
+		{
+			name:       "DEAL-128",
+			key:        sequentialKey(16),
+			plaintext:  "0123456789abcdeffedcba9876543210",
+			roundKeys:  []string{"3260266c2cf202e2", "79cf70cd1dac09a5", "4d29ed01607e2a58", "70dab168c2f349b8", "6f02d327070a6578", "91dcce2439827d3e"},
+			ciphertext: "235028ca55db2e6588cead4fe1174a66",
+		},
+		{
+			name:       "DEAL-192",
+			key:        sequentialKey(24),
+			plaintext:  "0123456789abcdeffedcba9876543210",
+			roundKeys:  []string{"3260266c2cf202e2", "79cf70cd1dac09a5", "b218e9ca8b9251f2", "9dd80b7dd35d25ca", "780f554a37cdc165", "d594bad2c4216a8d"},
+			ciphertext: "557e463795a210e89ecbd795c6ff5931",
+		},
+		{
+			name:       "DEAL-256",
+			key:        sequentialKey(32),
+			plaintext:  "0123456789abcdeffedcba9876543210",
+			roundKeys:  []string{"3260266c2cf202e2", "79cf70cd1dac09a5", "b218e9ca8b9251f2", "4512cab9af4aae12", "95c6ec297d5572c4", "2653231022a9be71", "d614571deedc34b1", "b9a25b74a14f709d"},
+			ciphertext: "eafaf1a9b25175842cf58da88869e26b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			roundKeys, err := NewDEALKeyExpander(len(tc.key)).ExpandKey(tc.key)
+			if err != nil {
+				t.Fatalf("ExpandKey failed: %v", err)
+			}
+			if len(roundKeys) != len(tc.roundKeys) {
+				t.Fatalf("Expected %d round keys, got %d", len(tc.roundKeys), len(roundKeys))
+			}
+			for i, want := range tc.roundKeys {
+				if got := hex.EncodeToString(roundKeys[i]); got != want {
+					t.Errorf("Round key %d: got %s, want %s", i+1, got, want)
+				}
+			}
+
+			dealCipher := newDEAL(des.NewDES(), len(tc.key))
+			if err := dealCipher.SetEncryptionKey(tc.key); err != nil {
+				t.Fatalf("SetEncryptionKey failed: %v", err)
+			}
+			plaintext := mustHex(t, tc.plaintext)
+			ciphertext, err := dealCipher.EncryptBlock(plaintext)
+			if err != nil {
+				t.Fatalf("EncryptBlock failed: %v", err)
+			}
+			if got := hex.EncodeToString(ciphertext); got != tc.ciphertext {
+				t.Errorf("Ciphertext: got %s, want %s", got, tc.ciphertext)
+			}
+
+			if err := dealCipher.SetDecryptionKey(tc.key); err != nil {
+				t.Fatalf("SetDecryptionKey failed: %v", err)
+			}
+			decrypted, err := dealCipher.DecryptBlock(ciphertext)
+			if err != nil {
+				t.Fatalf("DecryptBlock failed: %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Decrypted %x, want %x", decrypted, plaintext)
+			}
+		})
+	}
+}
+
+// Шифртексты прежней реализации (sha256-расписание, раунд без перестановки
+// половин) должны по-прежнему расшифровываться
+func TestDEALLegacyVectors(t *testing.T) {
+	plaintext := mustHex(t, "0123456789abcdeffedcba9876543210")
+	for _, tc := range []struct {
+		keySize    int
+		ciphertext string
+	}{
+		{16, "09fafb56d902a9d9fedcba9876543210"},
+		{24, "40d1f3bb74722e30fedcba9876543210"},
+		{32, "1f229bd3ebba1375fedcba9876543210"},
+	} {
+		key := sequentialKey(tc.keySize)
+		dealCipher, err := NewDEALVariant(des.NewDES(), tc.keySize, LegacySHA256)
+		if err != nil {
+			t.Fatalf("NewDEALVariant failed: %v", err)
+		}
+		if dealCipher.Variant() != LegacySHA256 {
+			t.Errorf("Variant() = %v, want LegacySHA256", dealCipher.Variant())
+		}
+		if err := dealCipher.SetEncryptionKey(key); err != nil {
+			t.Fatalf("SetEncryptionKey failed: %v", err)
+		}
+		ciphertext, err := dealCipher.EncryptBlock(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptBlock failed: %v", err)
+		}
+		if got := hex.EncodeToString(ciphertext); got != tc.ciphertext {
+			t.Errorf("DEAL-%d legacy: got %s, want %s", tc.keySize*8, got, tc.ciphertext)
+		}
+		decrypted, err := dealCipher.DecryptBlock(mustHex(t, tc.ciphertext))
+		if err != nil {
+			t.Fatalf("DecryptBlock failed: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("DEAL-%d legacy: decrypted %x, want %x", tc.keySize*8, decrypted, plaintext)
+		}
+
+		standard := newDEAL(des.NewDES(), tc.keySize)
+		standard.SetEncryptionKey(key)
+		if other, _ := standard.EncryptBlock(plaintext); bytes.Equal(other, ciphertext) {
+			t.Errorf("DEAL-%d: legacy and Knudsen variants agree", tc.keySize*8)
+		}
+	}
+}
+
+// Параллельные режимы контекста вызывают EncryptBlock и DecryptBlock одного
+// шифра из нескольких горутин; тест имеет смысл под go test -race
+func TestDEALParallelModes(t *testing.T) {
+	legacy, err := NewDEALVariant(des.NewDES(), 24, LegacySHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphers := []struct {
+		name   string
+		cipher core.SymmetricCipher
+		key    []byte
+	}{
+		{"DES", des.NewDES(), sequentialKey(8)},
+		{"DEAL-128", NewDEAL128(des.NewDES()), sequentialKey(16)},
+		{"DEAL-256", NewDEAL256(des.NewFastDES()), sequentialKey(32)},
+		{"DEAL-192 legacy", legacy, sequentialKey(24)},
+	}
+	message := make([]byte, 8192)
+	rand.Read(message)
+
+	for _, c := range ciphers {
+		if err := c.cipher.SetEncryptionKey(c.key); err != nil {
+			t.Fatal(err)
+		}
+		iv := make([]byte, c.cipher.BlockSize())
+		for _, mode := range []core.CipherMode{core.CBC, core.CFB, core.CTR} {
+			serial := core.NewCipherContext(c.cipher, mode, core.PadPKCS7, iv)
+			serial.SetWorkers(1)
+			want, err := serial.Encrypt(message)
+			if err != nil {
+				t.Fatalf("%s %v: Encrypt: %v", c.name, mode, err)
+			}
+
+			parallel := core.NewCipherContext(c.cipher, mode, core.PadPKCS7, iv)
+			parallel.SetWorkers(8)
+			got, err := parallel.Encrypt(message)
+			if err != nil {
+				t.Fatalf("%s %v: parallel Encrypt: %v", c.name, mode, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s %v: parallel ciphertext differs from serial", c.name, mode)
+			}
+			decrypted, err := parallel.Decrypt(want)
+			if err != nil {
+				t.Fatalf("%s %v: parallel Decrypt: %v", c.name, mode, err)
+			}
+			if !bytes.Equal(decrypted, message) {
+				t.Errorf("%s %v: parallel decryption differs from message", c.name, mode)
+			}
+		}
+	}
+}
+
+func TestNewDEALVariantErrors(t *testing.T) {
+	for _, keySize := range []int{0, 8, 20, 40} {
+		if _, err := NewDEALVariant(des.NewDES(), keySize, Knudsen); err == nil {
+			t.Errorf("%d-byte key: expected error", keySize)
+		}
+	}
+	if _, err := NewDEALVariant(des.NewDES(), 16, Variant(7)); err == nil {
+		t.Error("unknown variant: expected error")
+	}
+}
@@ -5,18 +5,42 @@ import (
 	"errors"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
 )
 
+// Variant определяет расписание ключей и раунд DEAL
+type Variant int
+
+const (
+	// Knudsen DEAL по спецификации Кнудсена: блоки ключа, сложенные
+	// с константами, шифруются DES на фиксированном ключе со сцеплением CBC
+	Knudsen Variant = iota
+	// LegacySHA256 прежняя нестандартная реализация: раундовые ключи
+	// sha256(key ‖ i), а раунд не меняет половины местами. Оставлена для
+	// данных, зашифрованных старыми версиями; в реестре — deal-128/192/256.
+	LegacySHA256
+)
+
+// scheduleKey фиксированный ключ DES расписания ключей DEAL
+var scheduleKey = []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+
 type DEALKeyExpander struct {
 	keySize int
 	rounds  int
+	variant Variant
 }
 
 func NewDEALKeyExpander(keySize int) *DEALKeyExpander {
+	return NewDEALKeyExpanderVariant(keySize, Knudsen)
+}
+
+// NewDEALKeyExpanderVariant создаёт расписание ключей выбранного варианта
+func NewDEALKeyExpanderVariant(keySize int, variant Variant) *DEALKeyExpander {
 	if keySize != 16 && keySize != 24 && keySize != 32 {
 		return &DEALKeyExpander{
 			keySize: keySize,
 			rounds:  0,
+			variant: variant,
 		}
 	}
 
@@ -28,6 +52,7 @@ func NewDEALKeyExpander(keySize int) *DEALKeyExpander {
 	return &DEALKeyExpander{
 		keySize: keySize,
 		rounds:  rounds,
+		variant: variant,
 	}
 }
 
@@ -40,6 +65,53 @@ func (ke *DEALKeyExpander) ExpandKey(key []byte) ([][]byte, error) {
 		return nil, errors.New("unsupported key size for DEAL")
 	}
 
+	switch ke.variant {
+	case Knudsen:
+		return ke.expandKnudsen(key)
+	case LegacySHA256:
+		return ke.expandSHA256(key), nil
+	default:
+		return nil, errors.New("unknown DEAL variant")
+	}
+}
+
+// expandKnudsen раундовые ключи по спецификации DEAL. Ключ делится на
+// s блоков K1..Ks по 64 бита; RK_i = E_K(K_j ⊕ c_i ⊕ RK_(i-1)), где
+// j = (i-1) mod s + 1, RK_0 = 0, K = 0x0123456789abcdef. Первые s раундов
+// без константы, далее c_i — 64-битное число с единственным битом
+// номер i-s (биты нумеруются с единицы от старшего, как в DES).
+// Расписание не сверено с векторами Кнудсена или другой независимой
+// реализацией; в частности, не проверена нумерация бита в c_i.
+func (ke *DEALKeyExpander) expandKnudsen(key []byte) ([][]byte, error) {
+	cipher := des.NewDES()
+	if err := cipher.SetEncryptionKey(scheduleKey); err != nil {
+		return nil, err
+	}
+
+	parts := ke.keySize / 8
+	subkeys := make([][]byte, ke.rounds)
+	previous := make([]byte, 8)
+	for i := range subkeys {
+		j := i % parts
+		block := xorBytes(key[8*j:8*j+8], previous)
+		if i >= parts {
+			block[0] ^= 0x80 >> (i - parts)
+		}
+
+		subkey, err := cipher.EncryptBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		core.Wipe(block)
+		subkeys[i] = subkey
+		previous = subkey
+	}
+
+	return subkeys, nil
+}
+
+// expandSHA256 раундовые ключи варианта LegacySHA256: первые 8 байт sha256(key ‖ i)
+func (ke *DEALKeyExpander) expandSHA256(key []byte) [][]byte {
 	subkeys := make([][]byte, ke.rounds)
 
 	for i := 0; i < ke.rounds; i++ {
@@ -55,7 +127,7 @@ func (ke *DEALKeyExpander) ExpandKey(key []byte) ([][]byte, error) {
 		subkeys[i] = subkey
 	}
 
-	return subkeys, nil
+	return subkeys
 }
 
 func (ke *DEALKeyExpander) ExpandDecryptionKeys(key []byte) ([][]byte, error) {
@@ -76,4 +148,9 @@ func (ke *DEALKeyExpander) Rounds() int {
 	return ke.rounds
 }
 
+// Variant возвращает вариант расписания ключей
+func (ke *DEALKeyExpander) Variant() Variant {
+	return ke.variant
+}
+
 var _ core.KeyExpander = (*DEALKeyExpander)(nil)
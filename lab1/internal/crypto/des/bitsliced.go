@@ -0,0 +1,166 @@
+package des
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Побитовая (bitsliced) реализация DES: до 64 блоков обрабатываются
+// одновременно, слово i хранит (i+1)-й бит стандарта всех блоков, по биту на блок.
+// Перестановки IP, E, P и IP^-1 сводятся к перенумерации слов, а выходы
+// S-блока собираются из общих для них конъюнкций входов по таблицам истинности.
+// Обращений к памяти по адресам, зависящим от данных или ключа, нет.
+
+// BitsliceBlocks число блоков, обрабатываемых за один проход
+const BitsliceBlocks = 64
+
+// sboxTruth таблицы истинности выходов S-блоков: бит x слова [i][o] — o-й
+// (от старшего) бит S_i(x), где x — шесть входных бит, первый бит старший
+var sboxTruth [8][4]uint64
+
+func init() {
+	for i := 0; i < 8; i++ {
+		for x := 0; x < 64; x++ {
+			row := x>>4&2 | x&1
+			col := x >> 1 & 0xF
+			s := SBoxes[i][row][col]
+			for o := 0; o < 4; o++ {
+				sboxTruth[i][o] |= uint64(s>>(3-o)&1) << x
+			}
+		}
+	}
+}
+
+// EncryptBlocks шифрует в ECB все блоки src в dst побитовой реализацией.
+// Длина src должна быть кратна 8; dst и src могут совпадать.
+func (d *FastDES) EncryptBlocks(dst, src []byte) error {
+	return d.cryptBlocks(dst, src, false)
+}
+
+// DecryptBlocks дешифрует в ECB все блоки src в dst побитовой реализацией.
+func (d *FastDES) DecryptBlocks(dst, src []byte) error {
+	return d.cryptBlocks(dst, src, true)
+}
+
+func (d *FastDES) cryptBlocks(dst, src []byte, decrypt bool) error {
+	if err := d.check(8); err != nil {
+		return err
+	}
+	if len(src)%8 != 0 {
+		return errors.New("DES input is not a multiple of the block size")
+	}
+	if len(dst) < len(src) {
+		return errors.New("DES output buffer is too small")
+	}
+
+	for off := 0; off < len(src); off += 8 * BitsliceBlocks {
+		n := min(BitsliceBlocks, (len(src)-off)/8)
+		// Неполная порция дополняется нулевыми блоками, чтобы время не зависело от длины хвоста
+		var rows [64]uint64
+		for k := 0; k < n; k++ {
+			rows[k] = binary.BigEndian.Uint64(src[off+8*k:])
+		}
+		slices := transpose64(&rows)
+		out := d.cryptSlices(&slices, decrypt)
+		rows = transpose64(&out)
+		for k := 0; k < n; k++ {
+			binary.BigEndian.PutUint64(dst[off+8*k:], rows[k])
+		}
+	}
+	return nil
+}
+
+// transpose64 транспонирует битовую матрицу 64×64 (столбцы нумеруются от
+// старшего бита): бит (63-i) строки k становится битом (63-k) строки i.
+// Повторное транспонирование возвращает исходную матрицу. Блоки 32×32,
+// 16×16, ... 1×1 меняются местами по диагонали за шесть проходов.
+func transpose64(m *[64]uint64) [64]uint64 {
+	a := *m
+	mask := uint64(0x00000000FFFFFFFF)
+	for j := 32; j != 0; j >>= 1 {
+		for k := 0; k < 64; k = (k + j + 1) &^ j {
+			t := (a[k] ^ a[k+j]>>j) & mask
+			a[k] ^= t
+			a[k+j] ^= t << j
+		}
+		mask ^= mask << (j / 2)
+	}
+	return a
+}
+
+// cryptSlices выполняет DES над 64 блоками в побитовом представлении
+func (d *FastDES) cryptSlices(in *[64]uint64, decrypt bool) [64]uint64 {
+	var left, right [32]uint64
+	for j := 0; j < 32; j++ {
+		left[j] = in[IP[j]-1]
+		right[j] = in[IP[j+32]-1]
+	}
+
+	for i := 0; i < 16; i++ {
+		round := i
+		if decrypt {
+			round = 15 - i
+		}
+		f := feistelSlices(&right, &d.masks[round])
+		for j := 0; j < 32; j++ {
+			left[j] ^= f[j]
+		}
+		left, right = right, left
+	}
+
+	// После 16 раундов половины меняются местами: на вход IP^-1 идёт R16 L16
+	var pre, out [64]uint64
+	copy(pre[:32], right[:])
+	copy(pre[32:], left[:])
+	for j := 0; j < 64; j++ {
+		out[j] = pre[IPInverse[j]-1]
+	}
+	return out
+}
+
+// feistelSlices функция f(R, K) в побитовом представлении
+func feistelSlices(right *[32]uint64, key *[48]uint64) [32]uint64 {
+	var s [32]uint64
+	var e [6]uint64
+	var m [64]uint64
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 6; j++ {
+			e[j] = right[Expansion[6*i+j]-1] ^ key[6*i+j]
+		}
+		minterms(&e, &m)
+		for o := 0; o < 4; o++ {
+			s[4*i+o] = fromMinterms(sboxTruth[i][o], &m)
+		}
+	}
+	var f [32]uint64
+	for j := 0; j < 32; j++ {
+		f[j] = s[P[j]-1]
+	}
+	return f
+}
+
+// minterms строит все 64 конъюнкции шести входов: m[x] содержит единицы
+// в тех блоках, где вход S-блока равен x (первый вход — старший бит x)
+func minterms(in *[6]uint64, m *[64]uint64) {
+	m[0], m[1] = ^in[0], in[0]
+	for j, n := 1, 2; j < 6; j, n = j+1, n*2 {
+		// Сверху вниз, чтобы m[k] читался до того, как его место займут новые значения
+		for k := n - 1; k >= 0; k-- {
+			t := m[k]
+			m[2*k+1] = t & in[j]
+			m[2*k] = t &^ in[j]
+		}
+	}
+}
+
+// fromMinterms вычисляет булеву функцию по таблице истинности как дизъюнкцию
+// конъюнкций. Ветвление зависит только от константной таблицы S-блока.
+func fromMinterms(table uint64, m *[64]uint64) uint64 {
+	var v uint64
+	for x := 0; x < 64; x++ {
+		if table>>x&1 == 1 {
+			v |= m[x]
+		}
+	}
+	return v
+}
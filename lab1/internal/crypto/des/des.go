@@ -3,7 +3,6 @@ package des
 import (
 	"errors"
 
-	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 )
 
@@ -12,6 +11,7 @@ type DES struct {
 	roundFunc   *DESRoundFunction
 	encryptKeys [][]byte
 	decryptKeys [][]byte
+	destroyed   bool
 }
 
 func NewDES() *DES {
@@ -23,6 +23,9 @@ func NewDES() *DES {
 
 // SetEncryptionKey устанавливает ключ шифрования и формирует раундовые ключи.
 func (d *DES) SetEncryptionKey(key []byte) error {
+	if d.destroyed {
+		return core.ErrDestroyed
+	}
 	if len(key) != 8 {
 		return errors.New("DES key must be exactly 8 bytes")
 	}
@@ -48,11 +51,14 @@ func (d *DES) SetDecryptionKey(key []byte) error {
 
 // EncryptBlock шифрует один 64-битный блок.
 func (d *DES) EncryptBlock(block []byte) ([]byte, error) {
+	if err := d.checkKey(); err != nil {
+		return nil, err
+	}
 	if len(block) != 8 {
 		return nil, errors.New("DES block must be exactly 8 bytes")
 	}
 
-	permuted, err := common.Permute(block, IP[:], common.MSBToLSB, common.OneBased)
+	permuted, err := IPPermutation.Permute(block)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +76,7 @@ func (d *DES) EncryptBlock(block []byte) ([]byte, error) {
 	copy(swapped[:4], right)
 	copy(swapped[4:], left)
 
-	result, err := common.Permute(swapped, IPInverse[:], common.MSBToLSB, common.OneBased)
+	result, err := IPInversePermutation.Permute(swapped)
 	if err != nil {
 		return nil, err
 	}
@@ -79,11 +85,14 @@ func (d *DES) EncryptBlock(block []byte) ([]byte, error) {
 
 // DecryptBlock дешифрует один 64-битный блок.
 func (d *DES) DecryptBlock(block []byte) ([]byte, error) {
+	if err := d.checkKey(); err != nil {
+		return nil, err
+	}
 	if len(block) != 8 {
 		return nil, errors.New("DES block must be exactly 8 bytes")
 	}
 
-	permuted, err := common.Permute(block, IP[:], common.MSBToLSB, common.OneBased)
+	permuted, err := IPPermutation.Permute(block)
 	if err != nil {
 		return nil, err
 	}
@@ -101,15 +110,48 @@ func (d *DES) DecryptBlock(block []byte) ([]byte, error) {
 	copy(swapped[:4], right)
 	copy(swapped[4:], left)
 
-	result, err := common.Permute(swapped, IPInverse[:], common.MSBToLSB, common.OneBased)
+	result, err := IPInversePermutation.Permute(swapped)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// SetKeyPolicy задаёт политику проверки ключа: StrictKeys отвергает слабые,
+// полуслабые, возможно слабые ключи и ключи с ошибкой чётности
+func (d *DES) SetKeyPolicy(policy KeyPolicy) {
+	d.keySchedule.SetKeyPolicy(policy)
+}
+
+// KeyReport возвращает недостатки последнего установленного ключа
+func (d *DES) KeyReport() KeyReport {
+	return d.keySchedule.KeyReport()
+}
+
 func (d *DES) BlockSize() int {
 	return 8
 }
 
-var _ core.SymmetricCipher = (*DES)(nil)
+// Destroy затирает раундовые ключи; дальнейшие операции возвращают core.ErrDestroyed
+func (d *DES) Destroy() {
+	// decryptKeys ссылаются на те же срезы, что и encryptKeys
+	core.WipeKeys(d.encryptKeys)
+	d.encryptKeys, d.decryptKeys = nil, nil
+	d.keySchedule = NewDESKeySchedule()
+	d.destroyed = true
+}
+
+func (d *DES) checkKey() error {
+	if d.destroyed {
+		return core.ErrDestroyed
+	}
+	if d.encryptKeys == nil {
+		return errors.New("DES key not set")
+	}
+	return nil
+}
+
+var (
+	_ core.SymmetricCipher = (*DES)(nil)
+	_ core.Destroyer       = (*DES)(nil)
+)
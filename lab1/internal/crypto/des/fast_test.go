@@ -0,0 +1,210 @@
+package des
+
+import (
+	"bytes"
+	stddes "crypto/des"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// crossCheckBlocks число случайных блоков для сверки быстрых реализаций
+func crossCheckBlocks() int {
+	if testing.Short() {
+		return 1 << 16
+	}
+	return 1 << 21
+}
+
+func newFastDES(t testing.TB, key []byte) *FastDES {
+	t.Helper()
+	d := NewFastDES()
+	if err := d.SetEncryptionKey(key); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	return d
+}
+
+func TestFastDESKnownVector(t *testing.T) {
+	key, _ := hex.DecodeString("133457799BBCDFF1")
+	plaintext, _ := hex.DecodeString("0123456789ABCDEF")
+	want, _ := hex.DecodeString("85E813540F0AB405")
+
+	d := newFastDES(t, key)
+	got, err := d.EncryptBlock(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptBlock: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ciphertext = %X, want %X", got, want)
+	}
+	back, _ := d.DecryptBlock(got)
+	if !bytes.Equal(back, plaintext) {
+		t.Errorf("decrypted = %X, want %X", back, plaintext)
+	}
+
+	batch := make([]byte, 8)
+	if err := d.EncryptBlocks(batch, plaintext); err != nil {
+		t.Fatalf("EncryptBlocks: %v", err)
+	}
+	if !bytes.Equal(batch, want) {
+		t.Errorf("bitsliced ciphertext = %X, want %X", batch, want)
+	}
+}
+
+// Быстрая реализация совпадает с эталонной DES на таблицах стандарта
+func TestFastDESMatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	key := make([]byte, 8)
+	block := make([]byte, 8)
+	// Эталон медленный, поэтому сверка идёт на меньшем числе блоков
+	for i := 0; i < crossCheckBlocks()/64; i++ {
+		if i%64 == 0 {
+			rng.Read(key)
+		}
+		rng.Read(block)
+
+		ref := NewDES()
+		if err := ref.SetEncryptionKey(key); err != nil {
+			t.Fatalf("SetEncryptionKey: %v", err)
+		}
+		fast := newFastDES(t, key)
+
+		want, _ := ref.EncryptBlock(block)
+		got, _ := fast.EncryptBlock(block)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("key %X block %X: fast %X, reference %X", key, block, got, want)
+		}
+		wantPlain, _ := ref.DecryptBlock(block)
+		gotPlain, _ := fast.DecryptBlock(block)
+		if !bytes.Equal(gotPlain, wantPlain) {
+			t.Fatalf("key %X block %X: fast decrypt %X, reference %X", key, block, gotPlain, wantPlain)
+		}
+	}
+}
+
+// Табличная и побитовая реализации совпадают с crypto/des на миллионах блоков
+func TestFastDESMatchesStdlib(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	key := make([]byte, 8)
+	const perKey = 4096
+	src := make([]byte, 8*perKey)
+	want := make([]byte, len(src))
+	got := make([]byte, len(src))
+
+	for done := 0; done < crossCheckBlocks(); done += perKey {
+		rng.Read(key)
+		rng.Read(src)
+		std, err := stddes.NewCipher(key)
+		if err != nil {
+			t.Fatalf("crypto/des: %v", err)
+		}
+		fast := newFastDES(t, key)
+
+		for off := 0; off < len(src); off += 8 {
+			std.Encrypt(want[off:], src[off:off+8])
+			block, _ := fast.EncryptBlock(src[off : off+8])
+			if !bytes.Equal(block, want[off:off+8]) {
+				t.Fatalf("key %X block %X: fast %X, crypto/des %X", key, src[off:off+8], block, want[off:off+8])
+			}
+		}
+		if err := fast.EncryptBlocks(got, src); err != nil {
+			t.Fatalf("EncryptBlocks: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("key %X: bitsliced encryption differs from crypto/des", key)
+		}
+		if err := fast.DecryptBlocks(got, got); err != nil {
+			t.Fatalf("DecryptBlocks: %v", err)
+		}
+		if !bytes.Equal(got, src) {
+			t.Fatalf("key %X: bitsliced decryption does not invert encryption", key)
+		}
+	}
+}
+
+// Хвост короче порции из 64 блоков обрабатывается без выхода за границы
+func TestFastDESBlocksTail(t *testing.T) {
+	d := newFastDES(t, []byte("8bytekey"))
+	for _, n := range []int{0, 1, 63, 64, 65, 130} {
+		src := bytes.Repeat([]byte{0xA5}, 8*n)
+		dst := make([]byte, len(src))
+		if err := d.EncryptBlocks(dst, src); err != nil {
+			t.Fatalf("%d blocks: %v", n, err)
+		}
+		for off := 0; off < len(src); off += 8 {
+			want, _ := d.EncryptBlock(src[off : off+8])
+			if !bytes.Equal(dst[off:off+8], want) {
+				t.Fatalf("%d blocks: block %d differs", n, off/8)
+			}
+		}
+	}
+
+	if err := d.EncryptBlocks(make([]byte, 16), make([]byte, 12)); err == nil {
+		t.Error("expected error for partial block")
+	}
+	if err := d.EncryptBlocks(make([]byte, 8), make([]byte, 16)); err == nil {
+		t.Error("expected error for short destination")
+	}
+}
+
+func TestFastDESErrors(t *testing.T) {
+	d := NewFastDES()
+	if _, err := d.EncryptBlock(make([]byte, 8)); err == nil {
+		t.Error("expected error without key")
+	}
+	if err := d.SetEncryptionKey(make([]byte, 7)); err == nil {
+		t.Error("expected error for 7-byte key")
+	}
+	d = newFastDES(t, make([]byte, 8))
+	if _, err := d.EncryptBlock(make([]byte, 9)); err == nil {
+		t.Error("expected error for 9-byte block")
+	}
+
+	d.Destroy()
+	if d.subkeys != ([16][8]uint32{}) || d.masks != ([16][48]uint64{}) {
+		t.Error("round keys are not wiped after Destroy")
+	}
+	if _, err := d.EncryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock after Destroy: got %v, want ErrDestroyed", err)
+	}
+	if err := d.EncryptBlocks(make([]byte, 8), make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlocks after Destroy: got %v, want ErrDestroyed", err)
+	}
+}
+
+func benchmarkCipher(b *testing.B, c core.SymmetricCipher) {
+	if err := c.SetEncryptionKey([]byte("8bytekey")); err != nil {
+		b.Fatal(err)
+	}
+	block := make([]byte, 8)
+	b.SetBytes(8)
+	for i := 0; i < b.N; i++ {
+		block, _ = c.EncryptBlock(block)
+	}
+}
+
+func BenchmarkDESReference(b *testing.B) { benchmarkCipher(b, NewDES()) }
+
+func BenchmarkDESFast(b *testing.B) { benchmarkCipher(b, NewFastDES()) }
+
+func BenchmarkDESBitsliced(b *testing.B) {
+	d := newFastDES(b, []byte("8bytekey"))
+	buf := make([]byte, 8*BitsliceBlocks)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		d.EncryptBlocks(buf, buf)
+	}
+}
+
+func BenchmarkDESStdlib(b *testing.B) {
+	c, _ := stddes.NewCipher([]byte("8bytekey"))
+	block := make([]byte, 8)
+	b.SetBytes(8)
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(block, block)
+	}
+}
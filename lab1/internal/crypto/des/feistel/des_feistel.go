@@ -3,7 +3,6 @@ package feistel
 import (
 	"errors"
 
-	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core/feistel"
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
@@ -12,17 +11,68 @@ import (
 // DESFeistel реализация DES на базе универсальной сети Фейстеля
 type DESFeistel struct {
 	feistelNetwork *feistel.FeistelNetwork
+	keySchedule    *des.DESKeySchedule
 }
 
 // NewDESFeistel создаёт новый DES на базе сети Фейстеля
 func NewDESFeistel() *DESFeistel {
+	return newDESFeistel(16)
+}
+
+// NewDESFeistelRounds создаёт DES с уменьшенным числом раундов (от 1 до 16)
+// для учебного криптоанализа. Используются первые rounds раундовых ключей
+// стандартного расписания; IP, IP^-1 и обмен половин после последнего раунда
+// сохраняются.
+func NewDESFeistelRounds(rounds int) (*DESFeistel, error) {
+	if rounds < 1 || rounds > 16 {
+		return nil, errors.New("DES rounds must be between 1 and 16")
+	}
+	return newDESFeistel(rounds), nil
+}
+
+// newDESFeistel собирает DES из обобщённой сети: функция f, IP и IP^-1
+// как бесключевое отбеливание и без обмена ветвей после последнего раунда
+func newDESFeistel(rounds int) *DESFeistel {
 	keySchedule := des.NewDESKeySchedule()
-	roundFunc := des.NewDESRoundFunction()
-	feistelNetwork := feistel.NewFeistelNetwork(keySchedule, roundFunc, 16)
+	feistelNetwork, err := feistel.NewFeistelNetworkFromConfig(feistel.Config{
+		BlockSize:   8,
+		Rounds:      rounds,
+		KeyExpander: &reducedKeySchedule{keySchedule, rounds},
+		Function:    des.NewDESRoundFunction(),
+		PreWhitening: feistel.PermutationWhitening{
+			Forward: des.IPPermutation, Inverse: des.IPInversePermutation,
+		},
+		PostWhitening: feistel.PermutationWhitening{
+			Forward: des.IPInversePermutation, Inverse: des.IPPermutation,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return &DESFeistel{
 		feistelNetwork: feistelNetwork,
+		keySchedule:    keySchedule,
+	}
+}
+
+// reducedKeySchedule отдаёт первые rounds раундовых ключей DES
+type reducedKeySchedule struct {
+	*des.DESKeySchedule
+	rounds int
+}
+
+func (ks *reducedKeySchedule) ExpandKey(key []byte) ([][]byte, error) {
+	subkeys, err := ks.DESKeySchedule.ExpandKey(key)
+	if err != nil {
+		return nil, err
 	}
+	return subkeys[:ks.rounds], nil
+}
+
+// Rounds возвращает число раундов
+func (d *DESFeistel) Rounds() int {
+	return d.feistelNetwork.Rounds
 }
 
 // SetEncryptionKey устанавливает ключ шифрования
@@ -43,29 +93,7 @@ func (d *DESFeistel) EncryptBlock(block []byte) ([]byte, error) {
 	if len(block) != 8 {
 		return nil, errors.New("DES block must be exactly 8 bytes")
 	}
-
-	permuted, err := common.Permute(block, des.IP[:], common.MSBToLSB, common.OneBased)
-	if err != nil {
-		return nil, err
-	}
-
-	feistelResult, err := d.feistelNetwork.EncryptBlock(permuted)
-	if err != nil {
-		return nil, err
-	}
-
-	left := feistelResult[:4]
-	right := feistelResult[4:]
-	swapped := make([]byte, 8)
-	copy(swapped[:4], right)
-	copy(swapped[4:], left)
-
-	result, err := common.Permute(swapped, des.IPInverse[:], common.MSBToLSB, common.OneBased)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return d.feistelNetwork.EncryptBlock(block)
 }
 
 // DecryptBlock дешифрует один 64-битный блок
@@ -73,33 +101,29 @@ func (d *DESFeistel) DecryptBlock(block []byte) ([]byte, error) {
 	if len(block) != 8 {
 		return nil, errors.New("DES block must be exactly 8 bytes")
 	}
+	return d.feistelNetwork.DecryptBlock(block)
+}
 
-	permuted, err := common.Permute(block, des.IP[:], common.MSBToLSB, common.OneBased)
-	if err != nil {
-		return nil, err
-	}
-
-	feistelResult, err := d.feistelNetwork.DecryptBlock(permuted)
-	if err != nil {
-		return nil, err
-	}
-
-	left := feistelResult[:4]
-	right := feistelResult[4:]
-	swapped := make([]byte, 8)
-	copy(swapped[:4], right)
-	copy(swapped[4:], left)
-
-	result, err := common.Permute(swapped, des.IPInverse[:], common.MSBToLSB, common.OneBased)
-	if err != nil {
-		return nil, err
-	}
+// SetKeyPolicy задаёт политику проверки ключа (см. des.KeyPolicy)
+func (d *DESFeistel) SetKeyPolicy(policy des.KeyPolicy) {
+	d.keySchedule.SetKeyPolicy(policy)
+}
 
-	return result, nil
+// KeyReport возвращает недостатки последнего установленного ключа
+func (d *DESFeistel) KeyReport() des.KeyReport {
+	return d.keySchedule.KeyReport()
 }
 
 func (d *DESFeistel) BlockSize() int {
 	return 8
 }
 
-var _ core.SymmetricCipher = (*DESFeistel)(nil)
+// Destroy затирает раундовые ключи сети Фейстеля
+func (d *DESFeistel) Destroy() {
+	d.feistelNetwork.Destroy()
+}
+
+var (
+	_ core.SymmetricCipher = (*DESFeistel)(nil)
+	_ core.Destroyer       = (*DESFeistel)(nil)
+)
@@ -2,6 +2,7 @@ package feistel
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
@@ -165,3 +166,63 @@ func BenchmarkDESFeistel_Decrypt(b *testing.B) {
 		_, _ = des.DecryptBlock(ciphertext)
 	}
 }
+
+func TestDESFeistel_KeyPolicy(t *testing.T) {
+	weak := []byte{0xE0, 0xE0, 0xE0, 0xE0, 0xF1, 0xF1, 0xF1, 0xF1}
+
+	d := NewDESFeistel()
+	if err := d.SetEncryptionKey(weak); err != nil {
+		t.Fatalf("lenient SetEncryptionKey failed: %v", err)
+	}
+	if !d.KeyReport().Has(des.WeakKey) {
+		t.Errorf("weak key not reported: %v", d.KeyReport().Issues)
+	}
+
+	d = NewDESFeistel()
+	d.SetKeyPolicy(des.StrictKeys)
+	if err := d.SetEncryptionKey(weak); !errors.Is(err, des.ErrInsecureKey) {
+		t.Errorf("strict SetEncryptionKey: got %v, want ErrInsecureKey", err)
+	}
+}
+
+func TestDESFeistel_Rounds(t *testing.T) {
+	key := []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+	plaintext := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+
+	full, err := NewDESFeistelRounds(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full.SetEncryptionKey(key)
+	ciphertext, _ := full.EncryptBlock(plaintext)
+	expected := []byte{0x85, 0xE8, 0x13, 0x54, 0x0F, 0x0A, 0xB4, 0x05}
+	if !bytes.Equal(ciphertext, expected) {
+		t.Errorf("16 rounds: got %X, want %X", ciphertext, expected)
+	}
+
+	for rounds := 1; rounds < 16; rounds++ {
+		reduced, err := NewDESFeistelRounds(rounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reduced.Rounds() != rounds {
+			t.Errorf("Rounds() = %d, want %d", reduced.Rounds(), rounds)
+		}
+		reduced.SetEncryptionKey(key)
+		encrypted, _ := reduced.EncryptBlock(plaintext)
+		if bytes.Equal(encrypted, ciphertext) {
+			t.Errorf("%d rounds: ciphertext equals full DES", rounds)
+		}
+		reduced.SetDecryptionKey(key)
+		decrypted, _ := reduced.DecryptBlock(encrypted)
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("%d rounds: decrypted %X, want %X", rounds, decrypted, plaintext)
+		}
+	}
+
+	for _, rounds := range []int{0, 17} {
+		if _, err := NewDESFeistelRounds(rounds); err == nil {
+			t.Errorf("expected error for %d rounds", rounds)
+		}
+	}
+}
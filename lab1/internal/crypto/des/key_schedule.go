@@ -4,9 +4,11 @@ import (
 	"errors"
 
 	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
 )
 
 type DESKeySchedule struct {
+	keyPolicy
 	subkeys [][]byte
 }
 
@@ -20,9 +22,12 @@ func (ks *DESKeySchedule) ExpandKey(key []byte) ([][]byte, error) {
 	if len(key) != 8 {
 		return nil, errors.New("DES key must be 8 bytes")
 	}
+	if err := ks.admit(key); err != nil {
+		return nil, err
+	}
 	adjusted := adjustKeyParity(key)
 
-	pc1Bits, err := common.Permute(adjusted, PC1[:], common.MSBToLSB, common.OneBased)
+	pc1Bits, err := pc1Permutation.Permute(adjusted)
 	if err != nil {
 		return nil, err
 	}
@@ -43,13 +48,21 @@ func (ks *DESKeySchedule) ExpandKey(key []byte) ([][]byte, error) {
 		copy(cd[28:], d)
 
 		cdBytes := common.BitsToBytes(cd, common.MSBToLSB)
-		subkeyBytes, err := common.Permute(cdBytes, PC2[:], common.MSBToLSB, common.OneBased)
+		subkeyBytes, err := pc2Permutation.Permute(cdBytes)
 		if err != nil {
 			return nil, err
 		}
 		ks.subkeys[round] = subkeyBytes
+		core.Wipe(cdBytes)
+		clear(cd)
 	}
 
+	// Промежуточные состояния расписания ключей тоже являются ключевым материалом
+	core.Wipe(adjusted, pc1Bits)
+	clear(pc1Bool)
+	clear(c)
+	clear(d)
+
 	return ks.subkeys, nil
 }
 
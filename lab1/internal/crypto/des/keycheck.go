@@ -0,0 +1,235 @@
+package des
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strings"
+)
+
+// KeyIssue недостаток ключа DES, найденный CheckKey
+type KeyIssue int
+
+const (
+	// WeakKey один из 4 слабых ключей: все 16 раундовых ключей одинаковы,
+	// и шифрование совпадает с дешифрованием
+	WeakKey KeyIssue = iota + 1
+	// SemiWeakKey один из 12 полуслабых ключей: шифрование парным ключом
+	// обращает шифрование этим
+	SemiWeakKey
+	// PossiblyWeakKey один из 48 возможно слабых ключей: среди раундовых
+	// ключей только 4 различных
+	PossiblyWeakKey
+	// ParityError в каком-то байте ключа чётное число единиц
+	ParityError
+	// RepeatedKey в Triple DES K1 == K2 или K2 == K3 (без учёта битов
+	// чётности): шифр вырождается в одинарный DES
+	RepeatedKey
+)
+
+func (i KeyIssue) String() string {
+	switch i {
+	case WeakKey:
+		return "weak key"
+	case SemiWeakKey:
+		return "semi-weak key"
+	case PossiblyWeakKey:
+		return "possibly weak key"
+	case ParityError:
+		return "parity error"
+	case RepeatedKey:
+		return "repeated key"
+	default:
+		return "unknown key issue"
+	}
+}
+
+// KeyPolicy определяет, что делать с ключом, у которого есть недостатки
+type KeyPolicy int
+
+const (
+	// LenientKeys принимает любой ключ; недостатки доступны через KeyReport
+	LenientKeys KeyPolicy = iota
+	// StrictKeys отвергает ключ с любым недостатком ошибкой *KeyError
+	StrictKeys
+)
+
+// ErrInsecureKey обёрнут в каждую *KeyError
+var ErrInsecureKey = errors.New("insecure DES key")
+
+// KeyError ошибка установки ключа с недостатками в режиме StrictKeys
+type KeyError struct {
+	Issues []KeyIssue
+}
+
+func (e *KeyError) Error() string {
+	names := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		names[i] = issue.String()
+	}
+	return ErrInsecureKey.Error() + ": " + strings.Join(names, ", ")
+}
+
+func (e *KeyError) Unwrap() error {
+	return ErrInsecureKey
+}
+
+// KeyReport результат проверки ключа
+type KeyReport struct {
+	Issues []KeyIssue
+}
+
+// OK сообщает, что недостатков не найдено
+func (r KeyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Has сообщает, найден ли недостаток issue
+func (r KeyReport) Has(issue KeyIssue) bool {
+	for _, i := range r.Issues {
+		if i == issue {
+			return true
+		}
+	}
+	return false
+}
+
+// Err возвращает *KeyError с найденными недостатками или nil
+func (r KeyReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return &KeyError{Issues: append([]KeyIssue(nil), r.Issues...)}
+}
+
+// Merge объединяет отчёты без повторов
+func (r KeyReport) Merge(other KeyReport) KeyReport {
+	merged := KeyReport{Issues: append([]KeyIssue(nil), r.Issues...)}
+	for _, issue := range other.Issues {
+		merged = merged.with(issue)
+	}
+	return merged
+}
+
+// with добавляет недостаток, если его ещё нет в отчёте
+func (r KeyReport) with(issue KeyIssue) KeyReport {
+	if r.Has(issue) {
+		return r
+	}
+	return KeyReport{Issues: append(append([]KeyIssue(nil), r.Issues...), issue)}
+}
+
+// Without убирает недостаток из отчёта
+func (r KeyReport) Without(issue KeyIssue) KeyReport {
+	var kept []KeyIssue
+	for _, i := range r.Issues {
+		if i != issue {
+			kept = append(kept, i)
+		}
+	}
+	return KeyReport{Issues: kept}
+}
+
+// Значения половин C и D после PC-1, при которых расписание ключей вырождается.
+// Половина из одних нулей или единиц не меняется при сдвигах; чередующаяся
+// половина принимает два значения, а повторяющая 0011 — четыре.
+const (
+	halfZeros       = 0x0000000
+	halfOnes        = 0xFFFFFFF
+	halfAlternating = 0x5555555
+	halfPeriod4     = 0x3333333
+	halfMask        = 0xFFFFFFF
+)
+
+// halfPeriod возвращает период половины ключа при циклических сдвигах:
+// 1, 2 или 4, либо 0, если половина не вырождена
+func halfPeriod(h uint32) int {
+	switch h {
+	case halfZeros, halfOnes:
+		return 1
+	case halfAlternating, halfAlternating ^ halfMask:
+		return 2
+	}
+	for r := 0; r < 4; r++ {
+		if h == rotate28(halfPeriod4, r) {
+			return 4
+		}
+	}
+	return 0
+}
+
+func rotate28(h uint32, n int) uint32 {
+	return (h<<n | h>>(28-n)) & halfMask
+}
+
+// CheckKey ищет в 8-байтовом ключе DES слабость, полуслабость, возможную
+// слабость и ошибки чётности. Ключ другой длины возвращает ошибку.
+func CheckKey(key []byte) (KeyReport, error) {
+	if len(key) != 8 {
+		return KeyReport{}, errors.New("DES key must be exactly 8 bytes")
+	}
+
+	var report KeyReport
+	cd := permuteBits(binary.BigEndian.Uint64(key), 64, PC1[:])
+	c, d := halfPeriod(uint32(cd>>28)), halfPeriod(uint32(cd)&halfMask)
+	switch {
+	case c == 0 || d == 0:
+	case c == 1 && d == 1:
+		report = report.with(WeakKey)
+	case c <= 2 && d <= 2:
+		report = report.with(SemiWeakKey)
+	default:
+		report = report.with(PossiblyWeakKey)
+	}
+
+	for _, b := range key {
+		if bits.OnesCount8(b)%2 == 0 {
+			report = report.with(ParityError)
+			break
+		}
+	}
+	return report, nil
+}
+
+// keyPolicy хранит политику проверки ключей и отчёт о последнем ключе
+type keyPolicy struct {
+	policy KeyPolicy
+	report KeyReport
+}
+
+// SetKeyPolicy задаёт политику для следующих установок ключа
+func (k *keyPolicy) SetKeyPolicy(policy KeyPolicy) {
+	k.policy = policy
+}
+
+// KeyReport возвращает отчёт о недостатках последнего установленного ключа
+func (k *keyPolicy) KeyReport() KeyReport {
+	return k.report
+}
+
+// admit проверяет ключ и решает по политике, принимать ли его
+func (k *keyPolicy) admit(key []byte) error {
+	report, err := CheckKey(key)
+	if err != nil {
+		return err
+	}
+	if k.policy == StrictKeys && !report.OK() {
+		return report.Err()
+	}
+	k.report = report
+	return nil
+}
+
+// SameKey сообщает, задают ли два 8-байтовых ключа одно и то же расписание DES
+// (ключи могут различаться только битами чётности)
+func SameKey(a, b []byte) bool {
+	if len(a) != 8 || len(b) != 8 {
+		return false
+	}
+	for i := range a {
+		if a[i]&0xFE != b[i]&0xFE {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package des
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+var weakKeys = []string{
+	"0101010101010101", "FEFEFEFEFEFEFEFE", "E0E0E0E0F1F1F1F1", "1F1F1F1F0E0E0E0E",
+}
+
+// Полуслабые ключи парами: шифрование вторым ключом обращает шифрование первым
+var semiWeakPairs = [][2]string{
+	{"011F011F010E010E", "1F011F010E010E01"},
+	{"01E001E001F101F1", "E001E001F101F101"},
+	{"01FE01FE01FE01FE", "FE01FE01FE01FE01"},
+	{"1FE01FE00EF10EF1", "E01FE01FF10EF10E"},
+	{"1FFE1FFE0EFE0EFE", "FE1FFE1FFE0EFE0E"},
+	{"E0FEE0FEF1FEF1FE", "FEE0FEE0FEF1FEF1"},
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// keyFromHalves строит ключ с нечётной чётностью, у которого после PC-1
+// получаются половины c и d
+func keyFromHalves(c, d uint32) []byte {
+	cd := uint64(c)<<28 | uint64(d)
+	var k uint64
+	for i, src := range PC1 {
+		k |= (cd >> (55 - i) & 1) << (64 - src)
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, k)
+	for i, b := range key {
+		key[i] = b&0xFE | ^parity(b&0xFE)&1
+	}
+	return key
+}
+
+func parity(b byte) byte {
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	return b & 1
+}
+
+func distinctSubkeys(t *testing.T, key []byte) int {
+	t.Helper()
+	subkeys, err := NewDESKeySchedule().ExpandKey(key)
+	if err != nil {
+		t.Fatalf("ExpandKey: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, k := range subkeys {
+		seen[string(k)] = true
+	}
+	return len(seen)
+}
+
+func TestCheckKeyKnownKeys(t *testing.T) {
+	for _, s := range weakKeys {
+		report, err := CheckKey(mustHex(t, s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.Has(WeakKey) || len(report.Issues) != 1 {
+			t.Errorf("%s: report %v, want only weak key", s, report.Issues)
+		}
+	}
+	for _, pair := range semiWeakPairs {
+		for _, s := range pair {
+			report, _ := CheckKey(mustHex(t, s))
+			if !report.Has(SemiWeakKey) || len(report.Issues) != 1 {
+				t.Errorf("%s: report %v, want only semi-weak key", s, report.Issues)
+			}
+		}
+	}
+	for _, s := range []string{"1F1F01010E0E0101", "011F1F01010E0E01", "E0E00101F1F10101", "FEFE0101FEFE0101"} {
+		report, _ := CheckKey(mustHex(t, s))
+		if !report.Has(PossiblyWeakKey) || len(report.Issues) != 1 {
+			t.Errorf("%s: report %v, want only possibly weak key", s, report.Issues)
+		}
+	}
+	report, _ := CheckKey(mustHex(t, "133457799BBCDFF1"))
+	if !report.OK() {
+		t.Errorf("133457799BBCDFF1: unexpected issues %v", report.Issues)
+	}
+}
+
+// Перебор всех вырожденных половин даёт ровно 4 слабых, 12 полуслабых
+// и 48 возможно слабых ключей, и у них 1, 2 и 4 различных раундовых ключа
+func TestCheckKeyCounts(t *testing.T) {
+	// Половины с периодом 1, 2 и 4: повторения полубайтов 0000, 1111, 0101, 0011
+	// и их циклические сдвиги
+	unique := map[uint32]bool{}
+	for _, nibble := range []uint32{0x0, 0xF, 0x5, 0x3} {
+		h := nibble * 0x1111111
+		for r := 0; r < 4; r++ {
+			unique[rotate28(h, r)] = true
+		}
+	}
+
+	counts := map[KeyIssue]int{}
+	want := map[KeyIssue]int{WeakKey: 1, SemiWeakKey: 2, PossiblyWeakKey: 4}
+	for c := range unique {
+		for d := range unique {
+			key := keyFromHalves(c, d)
+			report, err := CheckKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Has(ParityError) {
+				t.Fatalf("%X: keyFromHalves produced a parity error", key)
+			}
+			for issue, n := range want {
+				if report.Has(issue) {
+					counts[issue]++
+					if got := distinctSubkeys(t, key); got != n {
+						t.Errorf("%X (%v): %d distinct round keys, want %d", key, issue, got, n)
+					}
+				}
+			}
+		}
+	}
+	if counts[WeakKey] != 4 || counts[SemiWeakKey] != 12 || counts[PossiblyWeakKey] != 48 {
+		t.Errorf("got %d weak, %d semi-weak, %d possibly weak; want 4, 12, 48",
+			counts[WeakKey], counts[SemiWeakKey], counts[PossiblyWeakKey])
+	}
+}
+
+func TestWeakAndSemiWeakKeysInvolution(t *testing.T) {
+	block := mustHex(t, "0123456789ABCDEF")
+	for _, s := range weakKeys {
+		d := NewDES()
+		if err := d.SetEncryptionKey(mustHex(t, s)); err != nil {
+			t.Fatal(err)
+		}
+		once, _ := d.EncryptBlock(block)
+		twice, _ := d.EncryptBlock(once)
+		if !bytes.Equal(twice, block) {
+			t.Errorf("%s: E(E(x)) = %X, want %X", s, twice, block)
+		}
+	}
+	for _, pair := range semiWeakPairs {
+		first, second := NewDES(), NewDES()
+		first.SetEncryptionKey(mustHex(t, pair[0]))
+		second.SetEncryptionKey(mustHex(t, pair[1]))
+		once, _ := first.EncryptBlock(block)
+		back, _ := second.EncryptBlock(once)
+		if !bytes.Equal(back, block) {
+			t.Errorf("%s/%s: E2(E1(x)) = %X, want %X", pair[0], pair[1], back, block)
+		}
+	}
+}
+
+func TestCheckKeyParity(t *testing.T) {
+	key := mustHex(t, "133457799BBCDFF1")
+	key[3] ^= 1
+	report, _ := CheckKey(key)
+	if !report.Has(ParityError) || len(report.Issues) != 1 {
+		t.Errorf("report %v, want only parity error", report.Issues)
+	}
+	if _, err := CheckKey(make([]byte, 7)); err == nil {
+		t.Error("expected error for 7-byte key")
+	}
+
+	if !SameKey(mustHex(t, "0101010101010101"), make([]byte, 8)) {
+		t.Error("keys differing only in parity bits must be the same")
+	}
+	if SameKey(mustHex(t, "0201010101010101"), make([]byte, 8)) {
+		t.Error("keys differing in a key bit must differ")
+	}
+}
+
+type policyCipher interface {
+	SetEncryptionKey([]byte) error
+	SetKeyPolicy(KeyPolicy)
+	KeyReport() KeyReport
+}
+
+func TestKeyPolicy(t *testing.T) {
+	ciphers := map[string]func() policyCipher{
+		"DES":     func() policyCipher { return NewDES() },
+		"FastDES": func() policyCipher { return NewFastDES() },
+	}
+	weak := mustHex(t, weakKeys[0])
+	for name, newCipher := range ciphers {
+		lenient := newCipher()
+		if err := lenient.SetEncryptionKey(weak); err != nil {
+			t.Errorf("%s lenient: %v", name, err)
+		}
+		if !lenient.KeyReport().Has(WeakKey) {
+			t.Errorf("%s lenient: weak key not reported", name)
+		}
+
+		strict := newCipher()
+		strict.SetKeyPolicy(StrictKeys)
+		err := strict.SetEncryptionKey(weak)
+		var keyErr *KeyError
+		if !errors.Is(err, ErrInsecureKey) || !errors.As(err, &keyErr) || keyErr.Issues[0] != WeakKey {
+			t.Errorf("%s strict: got %v, want weak key error", name, err)
+		}
+
+		good := mustHex(t, "133457799BBCDFF1")
+		if err := strict.SetEncryptionKey(good); err != nil {
+			t.Errorf("%s strict: good key %X rejected: %v", name, good, err)
+		}
+		if !strict.KeyReport().OK() {
+			t.Errorf("%s strict: report %v for good key", name, strict.KeyReport().Issues)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package des
+
+import "github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
+
+// Перестановки стандарта, скомпилированные один раз при загрузке пакета.
+// Общие для DES, DESFeistel и расписания ключей.
+var (
+	IPPermutation        = common.MustPermutation(IP[:], 64, common.MSBToLSB, common.OneBased)
+	IPInversePermutation = common.MustPermutation(IPInverse[:], 64, common.MSBToLSB, common.OneBased)
+
+	expansionPermutation = common.MustPermutation(Expansion[:], 32, common.MSBToLSB, common.OneBased)
+	pPermutation         = common.MustPermutation(P[:], 32, common.MSBToLSB, common.OneBased)
+	pc1Permutation       = common.MustPermutation(PC1[:], 64, common.MSBToLSB, common.OneBased)
+	pc2Permutation       = common.MustPermutation(PC2[:], 56, common.MSBToLSB, common.OneBased)
+)
@@ -1,10 +1,6 @@
 package des
 
-import (
-	"errors"
-
-	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/common"
-)
+import "errors"
 
 type DESRoundFunction struct{}
 
@@ -46,6 +42,11 @@ func (rf *DESRoundFunction) EncryptRound(block []byte, roundKey []byte) ([]byte,
 	return result, nil
 }
 
+// F возвращает f(R, K) — раундовую функцию DES для core.FeistelFunction
+func (rf *DESRoundFunction) F(half []byte, roundKey []byte) ([]byte, error) {
+	return fFunction(half, roundKey)
+}
+
 // fFunction реализует f(R, K) функцию DES.
 func fFunction(right []byte, roundKey []byte) ([]byte, error) {
 	if len(right) != 4 {
@@ -55,7 +56,7 @@ func fFunction(right []byte, roundKey []byte) ([]byte, error) {
 		return nil, errors.New("fFunction: round key must be 6 bytes (48 bits)")
 	}
 
-	expanded, err := common.Permute(right, Expansion[:], common.MSBToLSB, common.OneBased)
+	expanded, err := expansionPermutation.Permute(right)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +71,7 @@ func fFunction(right []byte, roundKey []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	result, err := common.Permute(sboxResult, P[:], common.MSBToLSB, common.OneBased)
+	result, err := pPermutation.Permute(sboxResult)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,154 @@
+package desx
+
+import (
+	"errors"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// Variant определяет, откуда берётся ключ постотбеливания K2
+type Variant int
+
+const (
+	// ExplicitWhitening ключ из 24 байт K‖K1‖K2, как у Ривеста и в OpenSSL desx
+	ExplicitWhitening Variant = iota
+	// RSAWhitening ключ из 16 байт K‖K1; K2 выводится из K и K1 по схеме
+	// RSA BSAFE (не сверено с внешней реализацией, см. DeriveRSAWhitening)
+	RSAWhitening
+)
+
+// DESX реализует DESX: C = K2 ⊕ DES_K(P ⊕ K1).
+// Отбеливание до и после шифра расширяет перебор ключа DES до 56+64 бит.
+// Вместо DES подходит любой шифр с 64-битным блоком.
+type DESX struct {
+	cipher  core.SymmetricCipher // шифр с ключом K
+	variant Variant
+
+	preWhitening  [8]byte // K1
+	postWhitening [8]byte // K2
+
+	hasKey    bool
+	destroyed bool
+}
+
+// NewDESX создаёт DESX поверх cipher с ключом из 24 байт K‖K1‖K2
+func NewDESX(cipher core.SymmetricCipher) *DESX {
+	return NewDESXVariant(cipher, ExplicitWhitening)
+}
+
+// NewDESXVariant создаёт DESX с выбранным способом получения K2
+func NewDESXVariant(cipher core.SymmetricCipher, variant Variant) *DESX {
+	return &DESX{
+		cipher:  cipher,
+		variant: variant,
+	}
+}
+
+// SetEncryptionKey устанавливает ключ K для шифра и ключи отбеливания
+func (x *DESX) SetEncryptionKey(key []byte) error {
+	if err := x.setWhitening(key); err != nil {
+		return err
+	}
+	return x.cipher.SetEncryptionKey(key[:8])
+}
+
+// SetDecryptionKey устанавливает ключ K для шифра и ключи отбеливания
+func (x *DESX) SetDecryptionKey(key []byte) error {
+	if err := x.setWhitening(key); err != nil {
+		return err
+	}
+	return x.cipher.SetDecryptionKey(key[:8])
+}
+
+func (x *DESX) setWhitening(key []byte) error {
+	if x.destroyed {
+		return core.ErrDestroyed
+	}
+	if x.cipher.BlockSize() != 8 {
+		return errors.New("DESX requires a cipher with 64-bit blocks")
+	}
+
+	switch x.variant {
+	case ExplicitWhitening:
+		if len(key) != 24 {
+			return errors.New("DESX key must be exactly 24 bytes (K, K1, K2)")
+		}
+		copy(x.preWhitening[:], key[8:16])
+		copy(x.postWhitening[:], key[16:24])
+	case RSAWhitening:
+		if len(key) != 16 {
+			return errors.New("RSA DESX key must be exactly 16 bytes (K, K1)")
+		}
+		copy(x.preWhitening[:], key[8:16])
+		x.postWhitening = DeriveRSAWhitening(key[:8], key[8:16])
+	default:
+		return errors.New("unknown DESX variant")
+	}
+	x.hasKey = true
+	return nil
+}
+
+// EncryptBlock шифрует один 64-битный блок: K2 ⊕ E_K(P ⊕ K1)
+func (x *DESX) EncryptBlock(block []byte) ([]byte, error) {
+	if err := x.check(len(block)); err != nil {
+		return nil, err
+	}
+	whitened := xor8(block, &x.preWhitening)
+	encrypted, err := x.cipher.EncryptBlock(whitened)
+	if err != nil {
+		return nil, err
+	}
+	return xor8(encrypted, &x.postWhitening), nil
+}
+
+// DecryptBlock дешифрует один 64-битный блок: K1 ⊕ D_K(C ⊕ K2)
+func (x *DESX) DecryptBlock(block []byte) ([]byte, error) {
+	if err := x.check(len(block)); err != nil {
+		return nil, err
+	}
+	whitened := xor8(block, &x.postWhitening)
+	decrypted, err := x.cipher.DecryptBlock(whitened)
+	if err != nil {
+		return nil, err
+	}
+	return xor8(decrypted, &x.preWhitening), nil
+}
+
+// BlockSize возвращает размер блока в байтах (8 байт для DESX)
+func (x *DESX) BlockSize() int {
+	return 8
+}
+
+// Destroy стирает ключи отбеливания и ключ внутреннего шифра
+func (x *DESX) Destroy() {
+	core.Destroy(x.cipher)
+	core.Wipe(x.preWhitening[:], x.postWhitening[:])
+	x.hasKey = false
+	x.destroyed = true
+}
+
+func (x *DESX) check(size int) error {
+	if x.destroyed {
+		return core.ErrDestroyed
+	}
+	if !x.hasKey {
+		return errors.New("DESX key not set")
+	}
+	if size != 8 {
+		return errors.New("DESX block must be exactly 8 bytes")
+	}
+	return nil
+}
+
+func xor8(block []byte, key *[8]byte) []byte {
+	out := make([]byte, 8)
+	for i := range out {
+		out[i] = block[i] ^ key[i]
+	}
+	return out
+}
+
+var (
+	_ core.SymmetricCipher = (*DESX)(nil)
+	_ core.Destroyer       = (*DESX)(nil)
+)
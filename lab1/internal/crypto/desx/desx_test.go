@@ -0,0 +1,192 @@
+package desx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/des"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// Векторы получены через openssl enc -desx-cbc с нулевым IV (один блок совпадает с ECB)
+var desxVectors = []struct {
+	key, plaintext, ciphertext string
+}{
+	{"0123456789ABCDEF23456789ABCDEF01456789ABCDEF0123", "4E6F772069732074", "EFE1DA6ADB3B58D2"},
+	// Без отбеливания DESX совпадает с DES
+	{"133457799BBCDFF1" + "0000000000000000" + "0000000000000000", "0123456789ABCDEF", "85E813540F0AB405"},
+	{"0101010101010101" + "FFFFFFFFFFFFFFFF" + "0000000000000000", "0000000000000000", "355550B2150E2451"},
+}
+
+func TestDESXKnownVectors(t *testing.T) {
+	ciphers := map[string]func() core.SymmetricCipher{
+		"DES":     func() core.SymmetricCipher { return des.NewDES() },
+		"FastDES": func() core.SymmetricCipher { return des.NewFastDES() },
+	}
+	for name, newCipher := range ciphers {
+		for _, v := range desxVectors {
+			x := NewDESX(newCipher())
+			if err := x.SetEncryptionKey(mustHex(t, v.key)); err != nil {
+				t.Fatalf("%s: SetEncryptionKey: %v", name, err)
+			}
+			got, err := x.EncryptBlock(mustHex(t, v.plaintext))
+			if err != nil {
+				t.Fatalf("%s: EncryptBlock: %v", name, err)
+			}
+			if want := mustHex(t, v.ciphertext); !bytes.Equal(got, want) {
+				t.Errorf("%s key %s: ciphertext %X, want %X", name, v.key, got, want)
+			}
+
+			x = NewDESX(newCipher())
+			if err := x.SetDecryptionKey(mustHex(t, v.key)); err != nil {
+				t.Fatalf("%s: SetDecryptionKey: %v", name, err)
+			}
+			back, _ := x.DecryptBlock(got)
+			if want := mustHex(t, v.plaintext); !bytes.Equal(back, want) {
+				t.Errorf("%s key %s: decrypted %X, want %X", name, v.key, back, want)
+			}
+		}
+	}
+}
+
+// DESX подключается к CipherContext как любой шифр: CBC совпадает с openssl desx-cbc
+func TestDESXCipherContext(t *testing.T) {
+	x := NewDESX(des.NewDES())
+	if err := x.SetEncryptionKey(mustHex(t, "0123456789ABCDEF23456789ABCDEF01456789ABCDEF0123")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := core.NewCipherContext(x, core.CBC, core.PadZeros, mustHex(t, "1234567890ABCDEF"))
+
+	plaintext := []byte("Now is the time for all ")
+	ciphertext, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	want := mustHex(t, "C74A62D61FB4E8B0A1F465E0EA0E20D9D0C96D20605D91BB")
+	if !bytes.Equal(ciphertext[:len(want)], want) {
+		t.Errorf("ciphertext %X, want %X", ciphertext[:len(want)], want)
+	}
+	decrypted, err := ctx.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("decrypted %q, want %q", decrypted, plaintext)
+	}
+}
+
+func TestDESXRSAWhitening(t *testing.T) {
+	key := mustHex(t, "0123456789ABCDEF")
+	pre := mustHex(t, "23456789ABCDEF01")
+
+	post := DeriveRSAWhitening(key, pre)
+	if post == ([8]byte{}) {
+		t.Fatal("derived post-whitening key is zero")
+	}
+	if DeriveRSAWhitening(key, pre) != post {
+		t.Error("derivation is not deterministic")
+	}
+	otherKey := append([]byte(nil), key...)
+	otherKey[7] ^= 0x02
+	if DeriveRSAWhitening(otherKey, pre) == post {
+		t.Error("post-whitening key does not depend on K")
+	}
+	otherPre := append([]byte(nil), pre...)
+	otherPre[0] ^= 0x80
+	if DeriveRSAWhitening(key, otherPre) == post {
+		t.Error("post-whitening key does not depend on K1")
+	}
+
+	// Вариант RSA совпадает с явным ключом K‖K1‖K2 при выведенном K2
+	rsa := NewDESXVariant(des.NewDES(), RSAWhitening)
+	if err := rsa.SetEncryptionKey(append(append([]byte(nil), key...), pre...)); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	explicit := NewDESX(des.NewDES())
+	if err := explicit.SetEncryptionKey(append(append(append([]byte(nil), key...), pre...), post[:]...)); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	block := []byte("8 bytes!")
+	got, _ := rsa.EncryptBlock(block)
+	want, _ := explicit.EncryptBlock(block)
+	if !bytes.Equal(got, want) {
+		t.Errorf("RSA variant %X, explicit key %X", got, want)
+	}
+	back, _ := rsa.DecryptBlock(got)
+	if !bytes.Equal(back, block) {
+		t.Errorf("decrypted %q, want %q", back, block)
+	}
+}
+
+// Фиксированные значения вывода K2. Эталонной реализации выведения RSA BSAFE
+// под рукой нет, поэтому K2 получены этой реализацией и защищают только от
+// регрессий; внешне проверен лишь шифртекст DESX при известном K2
+// (openssl enc -desx-cbc с нулевым IV и ключом K‖K1‖K2).
+func TestDESXRSAWhiteningVectors(t *testing.T) {
+	vectors := []struct {
+		key, pre, post string
+	}{
+		{"0123456789ABCDEF", "23456789ABCDEF01", "5E13581269D7394E"},
+		{"0000000000000000", "0000000000000000", "CD19AF754358FD4C"},
+	}
+	for _, v := range vectors {
+		post := DeriveRSAWhitening(mustHex(t, v.key), mustHex(t, v.pre))
+		if want := mustHex(t, v.post); !bytes.Equal(post[:], want) {
+			t.Errorf("K=%s K1=%s: K2 %X, want %X", v.key, v.pre, post, want)
+		}
+	}
+
+	x := NewDESXVariant(des.NewDES(), RSAWhitening)
+	if err := x.SetEncryptionKey(mustHex(t, "0123456789ABCDEF23456789ABCDEF01")); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := x.EncryptBlock([]byte("Now is t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "F4950BD37F0360BF"); !bytes.Equal(ciphertext, want) {
+		t.Errorf("ciphertext %X, want %X", ciphertext, want)
+	}
+}
+
+func TestDESXErrors(t *testing.T) {
+	x := NewDESX(des.NewDES())
+	if _, err := x.EncryptBlock(make([]byte, 8)); err == nil {
+		t.Error("expected error without key")
+	}
+	if err := x.SetEncryptionKey(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte key")
+	}
+	if err := NewDESXVariant(des.NewDES(), RSAWhitening).SetEncryptionKey(make([]byte, 24)); err == nil {
+		t.Error("expected error for 24-byte key in RSA variant")
+	}
+	if err := NewDESXVariant(des.NewDES(), Variant(7)).SetEncryptionKey(make([]byte, 24)); err == nil {
+		t.Error("expected error for unknown variant")
+	}
+
+	if err := x.SetEncryptionKey(bytes.Repeat([]byte{0x5A}, 24)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.EncryptBlock(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte block")
+	}
+
+	x.Destroy()
+	if x.preWhitening != ([8]byte{}) || x.postWhitening != ([8]byte{}) {
+		t.Error("whitening keys are not wiped after Destroy")
+	}
+	if _, err := x.EncryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock after Destroy: got %v, want ErrDestroyed", err)
+	}
+}
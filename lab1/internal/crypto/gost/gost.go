@@ -0,0 +1,198 @@
+package gost
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core/feistel"
+)
+
+// Variant определяет порядок байтов ключа и блока
+type Variant int
+
+const (
+	// GOST28147 ГОСТ 28147-89 (RFC 5830): ключ и половины блока читаются как
+	// 32-битные слова little-endian, N1 — первые 4 байта блока
+	GOST28147 Variant = iota
+	// Magma ГОСТ Р 34.12-2015 (RFC 8891): ключ и блок читаются big-endian,
+	// a0 — последние 4 байта блока
+	Magma
+)
+
+// GOST реализует ГОСТ 28147-89 и «Магму»: 64-битный блок, 256-битный ключ,
+// 32 раунда сети Фейстеля с функцией g[k](a) = t(a + k mod 2^32) <<< 11.
+// Оба варианта различаются только порядком байтов: блок ГОСТ 28147-89,
+// прочитанный задом наперёд, совпадает с блоком «Магмы».
+type GOST struct {
+	feistelNetwork *feistel.FeistelNetwork
+	variant        Variant
+}
+
+// NewGOST28147 создаёт ГОСТ 28147-89 с узлом замены sbox
+func NewGOST28147(sbox *SBox) *GOST {
+	return NewGOSTVariant(sbox, GOST28147)
+}
+
+// NewMagma создаёт «Магму» с подстановками ГОСТ Р 34.12-2015
+func NewMagma() *GOST {
+	return NewGOSTVariant(&TC26Z, Magma)
+}
+
+// NewGOSTVariant создаёт шифр с узлом замены sbox и порядком байтов variant.
+// Сеть работает с блоком «Магмы» a1 ‖ a0, где a0 — исходная ветвь; после
+// последнего раунда ветви не меняются местами.
+func NewGOSTVariant(sbox *SBox, variant Variant) *GOST {
+	config := feistel.Config{
+		BlockSize:   8,
+		Rounds:      32,
+		KeyExpander: NewKeySchedule(variant),
+		Function:    NewRoundFunction(sbox),
+	}
+	if variant == GOST28147 {
+		config.PreWhitening = byteReversal{}
+		config.PostWhitening = byteReversal{}
+	}
+	feistelNetwork, err := feistel.NewFeistelNetworkFromConfig(config)
+	if err != nil {
+		panic(err)
+	}
+
+	return &GOST{
+		feistelNetwork: feistelNetwork,
+		variant:        variant,
+	}
+}
+
+// Variant возвращает порядок байтов шифра
+func (c *GOST) Variant() Variant {
+	return c.variant
+}
+
+// SetEncryptionKey устанавливает 256-битный ключ
+func (c *GOST) SetEncryptionKey(key []byte) error {
+	return c.feistelNetwork.SetEncryptionKey(key)
+}
+
+// SetDecryptionKey устанавливает ключ дешифрования
+func (c *GOST) SetDecryptionKey(key []byte) error {
+	return c.SetEncryptionKey(key)
+}
+
+// EncryptBlock шифрует один 64-битный блок
+func (c *GOST) EncryptBlock(block []byte) ([]byte, error) {
+	if len(block) != 8 {
+		return nil, errors.New("GOST block must be exactly 8 bytes")
+	}
+	return c.feistelNetwork.EncryptBlock(block)
+}
+
+// DecryptBlock дешифрует один 64-битный блок
+func (c *GOST) DecryptBlock(block []byte) ([]byte, error) {
+	if len(block) != 8 {
+		return nil, errors.New("GOST block must be exactly 8 bytes")
+	}
+	return c.feistelNetwork.DecryptBlock(block)
+}
+
+func (c *GOST) BlockSize() int {
+	return 8
+}
+
+// Destroy затирает раундовые ключи
+func (c *GOST) Destroy() {
+	c.feistelNetwork.Destroy()
+}
+
+// KeySchedule расписание ключа: ключ делится на восемь 32-битных слов
+// K1 … K8, раунды 1–24 используют их по кругу, раунды 25–32 — в обратном
+// порядке K8 … K1. Раундовые ключи возвращаются big-endian.
+type KeySchedule struct {
+	variant Variant
+}
+
+func NewKeySchedule(variant Variant) *KeySchedule {
+	return &KeySchedule{variant: variant}
+}
+
+func (ks *KeySchedule) ExpandKey(key []byte) ([][]byte, error) {
+	if len(key) != 32 {
+		return nil, errors.New("GOST key must be exactly 32 bytes")
+	}
+
+	var words [8]uint32
+	for i := range words {
+		if ks.variant == GOST28147 {
+			words[i] = binary.LittleEndian.Uint32(key[4*i:])
+		} else {
+			words[i] = binary.BigEndian.Uint32(key[4*i:])
+		}
+	}
+
+	subkeys := make([][]byte, 32)
+	for i := range subkeys {
+		j := i % 8
+		if i >= 24 {
+			j = 31 - i
+		}
+		subkeys[i] = binary.BigEndian.AppendUint32(nil, words[j])
+	}
+	return subkeys, nil
+}
+
+// RoundFunction функция g[k](a) = t(a + k mod 2^32) <<< 11, где t —
+// подстановка по тетрадам узлом замены. Узел замены копируется, так что
+// последующие изменения переданной таблицы на функцию не влияют.
+type RoundFunction struct {
+	sbox SBox
+}
+
+func NewRoundFunction(sbox *SBox) *RoundFunction {
+	return &RoundFunction{sbox: *sbox}
+}
+
+func (rf *RoundFunction) F(half []byte, roundKey []byte) ([]byte, error) {
+	if len(half) != 4 || len(roundKey) != 4 {
+		return nil, errors.New("GOST round function: half and round key must be 4 bytes")
+	}
+	a := binary.BigEndian.Uint32(half) + binary.BigEndian.Uint32(roundKey)
+	return binary.BigEndian.AppendUint32(nil, bits.RotateLeft32(rf.t(a), 11)), nil
+}
+
+// t заменяет каждую тетраду слова, начиная с младшей
+func (rf *RoundFunction) t(a uint32) uint32 {
+	var result uint32
+	for i := 0; i < 8; i++ {
+		result |= uint32(rf.sbox[i][a>>(4*i)&0xF]) << (4 * i)
+	}
+	return result
+}
+
+// byteReversal переводит блок ГОСТ 28147-89 (N1, N2 little-endian) в блок
+// «Магмы» (a1 ‖ a0 big-endian) и обратно
+type byteReversal struct{}
+
+func (byteReversal) Whiten(block, _ []byte) ([]byte, error) {
+	result := make([]byte, len(block))
+	for i, b := range block {
+		result[len(block)-1-i] = b
+	}
+	return result, nil
+}
+
+func (w byteReversal) Unwhiten(block, _ []byte) ([]byte, error) {
+	return w.Whiten(block, nil)
+}
+
+func (byteReversal) Keyed() bool {
+	return false
+}
+
+var (
+	_ core.SymmetricCipher = (*GOST)(nil)
+	_ core.Destroyer       = (*GOST)(nil)
+	_ core.KeyExpander     = (*KeySchedule)(nil)
+	_ core.FeistelFunction = (*RoundFunction)(nil)
+	_ feistel.Whitening    = byteReversal{}
+)
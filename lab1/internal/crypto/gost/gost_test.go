@@ -0,0 +1,229 @@
+package gost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+const rfc8891Key = "ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"
+
+// RFC 8891, A.1 и A.2: преобразования t и g
+func TestMagmaRoundFunction(t *testing.T) {
+	rf := NewRoundFunction(&TC26Z)
+	for _, v := range [][2]uint32{
+		{0xfdb97531, 0x2a196f34},
+		{0x2a196f34, 0xebd9f03a},
+		{0xebd9f03a, 0xb039bb3d},
+		{0xb039bb3d, 0x68695433},
+	} {
+		if got := rf.t(v[0]); got != v[1] {
+			t.Errorf("t(%08x) = %08x, want %08x", v[0], got, v[1])
+		}
+	}
+
+	for _, v := range [][3]uint32{
+		{0x87654321, 0xfedcba98, 0xfdcbc20c},
+		{0xfdcbc20c, 0x87654321, 0x7e791a4b},
+		{0x7e791a4b, 0xfdcbc20c, 0xc76549ec},
+		{0xc76549ec, 0x7e791a4b, 0x9791c849},
+	} {
+		key := binary.BigEndian.AppendUint32(nil, v[0])
+		half := binary.BigEndian.AppendUint32(nil, v[1])
+		got, err := rf.F(half, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if binary.BigEndian.Uint32(got) != v[2] {
+			t.Errorf("g[%08x](%08x) = %x, want %08x", v[0], v[1], got, v[2])
+		}
+	}
+}
+
+// RFC 8891, A.3: развёртывание ключа
+func TestMagmaKeySchedule(t *testing.T) {
+	subkeys, err := NewKeySchedule(Magma).ExpandKey(mustHex(t, rfc8891Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ffeeddcc", "bbaa9988", "77665544", "33221100",
+		"f0f1f2f3", "f4f5f6f7", "f8f9fafb", "fcfdfeff",
+	}
+	for i, w := range want {
+		for _, round := range []int{i, i + 8, i + 16, 31 - i} {
+			if got := hex.EncodeToString(subkeys[round]); got != w {
+				t.Errorf("K%d = %s, want %s", round+1, got, w)
+			}
+		}
+	}
+}
+
+// RFC 8891, A.4: шифрование блока
+func TestMagmaVector(t *testing.T) {
+	c := NewMagma()
+	if err := c.SetEncryptionKey(mustHex(t, rfc8891Key)); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := mustHex(t, "fedcba9876543210")
+	ciphertext, err := c.EncryptBlock(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "4ee901e5c2d8ca3d"); !bytes.Equal(ciphertext, want) {
+		t.Errorf("got %x, want %x", ciphertext, want)
+	}
+	decrypted, err := c.DecryptBlock(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("decrypted %x, want %x", decrypted, plaintext)
+	}
+}
+
+// Векторы ГОСТ 28147-89 (ECB) для всех наборов S-блоков получены этой
+// реализацией и защищают от регрессий; внешне проверен только набор
+// id-tc26-Z через совпадение с «Магмой» из RFC 8891
+func TestGOST28147ParamSets(t *testing.T) {
+	keys := []string{rfc8891Key, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"}
+	plaintexts := []string{"fedcba9876543210", "0123456789abcdef"}
+	vectors := []struct {
+		oid         string
+		ciphertexts [2]string
+	}{
+		{"1.2.643.2.2.31.0", [2]string{"241a8378a7c39dc3", "dac55e3545163d9e"}},
+		{"1.2.643.2.2.30.0", [2]string{"f9393352f83fe2ed", "619a8ed3209c803a"}},
+		{"1.2.643.2.2.31.1", [2]string{"acb6976aef4116ab", "dc07fee3d5498d32"}},
+		{"1.2.643.2.2.31.2", [2]string{"30413b8de1c81a30", "c063ab5dd2df228f"}},
+		{"1.2.643.2.2.31.3", [2]string{"b95691ede068affc", "7a734cbe4cb8ca10"}},
+		{"1.2.643.2.2.31.4", [2]string{"6df54cbe5cbf34a7", "8ff4e28ee49053a3"}},
+		{"1.2.643.7.1.2.5.1.1", [2]string{"8fc6feb891514c37", "165200920b0bfadc"}},
+	}
+
+	for _, v := range vectors {
+		sbox, ok := ParamSet(v.oid)
+		if !ok {
+			t.Fatalf("no parameter set %s", v.oid)
+		}
+		c := NewGOST28147(&sbox)
+		for i := range keys {
+			if err := c.SetEncryptionKey(mustHex(t, keys[i])); err != nil {
+				t.Fatal(err)
+			}
+			ciphertext, err := c.EncryptBlock(mustHex(t, plaintexts[i]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(ciphertext); got != v.ciphertexts[i] {
+				t.Errorf("%s, vector %d: got %s, want %s", v.oid, i, got, v.ciphertexts[i])
+			}
+			decrypted, err := c.DecryptBlock(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(decrypted); got != plaintexts[i] {
+				t.Errorf("%s, vector %d: decrypted %s", v.oid, i, got)
+			}
+		}
+	}
+}
+
+// ГОСТ 28147-89 с набором id-tc26-Z отличается от «Магмы» только порядком байтов
+func TestGOST28147MatchesMagma(t *testing.T) {
+	key := mustHex(t, rfc8891Key)
+	reversedWords := make([]byte, 32)
+	for i := 0; i < 32; i += 4 {
+		binary.LittleEndian.PutUint32(reversedWords[i:], binary.BigEndian.Uint32(key[i:]))
+	}
+	c := NewGOST28147(&TC26Z)
+	if err := c.SetEncryptionKey(reversedWords); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := c.EncryptBlock(mustHex(t, "1032547698badcfe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "3dcad8c2e501e94e"); !bytes.Equal(ciphertext, want) {
+		t.Errorf("got %x, want %x", ciphertext, want)
+	}
+}
+
+func TestGOSTCipherContext(t *testing.T) {
+	message := []byte("GOST 28147-89 and Magma in every mode of the cipher context")
+	for _, c := range []*GOST{NewMagma(), NewGOST28147(&CryptoProA)} {
+		if err := c.SetEncryptionKey(mustHex(t, rfc8891Key)); err != nil {
+			t.Fatal(err)
+		}
+		for _, mode := range []core.CipherMode{core.ECB, core.CBC, core.PCBC, core.CFB, core.OFB, core.CTR, core.RandomDelta} {
+			ctx := core.NewCipherContext(c, mode, core.PadPKCS7, mustHex(t, "1234567890abcdef"))
+			ciphertext, err := ctx.Encrypt(message)
+			if err != nil {
+				t.Fatalf("%v: Encrypt: %v", mode, err)
+			}
+			decrypted, err := ctx.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("%v: Decrypt: %v", mode, err)
+			}
+			if !bytes.Equal(decrypted, message) {
+				t.Errorf("%v: decrypted %q, want %q", mode, decrypted, message)
+			}
+		}
+	}
+}
+
+func TestGOSTErrors(t *testing.T) {
+	c := NewMagma()
+	if _, err := c.EncryptBlock(make([]byte, 8)); err == nil {
+		t.Error("expected error without key")
+	}
+	if err := c.SetEncryptionKey(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte key")
+	}
+	if err := c.SetEncryptionKey(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.EncryptBlock(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte block")
+	}
+
+	c.Destroy()
+	if _, err := c.EncryptBlock(make([]byte, 8)); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock after Destroy: %v, want ErrDestroyed", err)
+	}
+}
+
+func TestParamSetCopy(t *testing.T) {
+	sbox, ok := ParamSet("1.2.643.2.2.31.1")
+	if !ok {
+		t.Fatal("no parameter set 1.2.643.2.2.31.1")
+	}
+	sbox[0][0] ^= 0xF
+	if again, _ := ParamSet("1.2.643.2.2.31.1"); again != CryptoProA {
+		t.Error("changing a returned parameter set changed the table")
+	}
+	if _, ok := ParamSet("1.2.643.2.2.31.9"); ok {
+		t.Error("expected no parameter set for unknown OID")
+	}
+
+	table := TestParamSet
+	rf := NewRoundFunction(&table)
+	want := rf.t(0x12345678)
+	table[0][8] ^= 0xF
+	if got := rf.t(0x12345678); got != want {
+		t.Errorf("round function follows changes of the S-box it was built from: %08x, want %08x", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package kdf
+
+import (
+	"crypto/sha256"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+// MACKeySize длина ключа MAC в Subkeys (HMAC-SHA256)
+const MACKeySize = sha256.Size
+
+// Subkeys независимые ключи для CipherContext, выведенные из одного мастер-секрета
+type Subkeys struct {
+	Encryption []byte // ключ шифра, registry.Descriptor.KeySize байт
+	MAC        []byte // ключ HMAC-SHA256, MACKeySize байт
+	IV         []byte // вектор инициализации, registry.Descriptor.BlockSize байт
+}
+
+// info контекст HKDF: имя шифра и назначение ключа разделяют выходы
+// для разных шифров и подключей
+func info(cipherName, purpose string) []byte {
+	return []byte("cryptography/kdf " + cipherName + " " + purpose)
+}
+
+// CipherKey выводит из master ключ длины, требуемой зарегистрированным шифром
+// cipherName. Разные purpose дают независимые ключи.
+func CipherKey(master, salt []byte, cipherName, purpose string) ([]byte, error) {
+	d, err := registry.Lookup(cipherName)
+	if err != nil {
+		return nil, err
+	}
+	return HKDF(sha256.New, master, salt, info(d.Name, purpose), d.KeySize)
+}
+
+// DeriveSubkeys выводит ключ шифрования, ключ MAC и IV для шифра cipherName
+func DeriveSubkeys(master, salt []byte, cipherName string) (*Subkeys, error) {
+	d, err := registry.Lookup(cipherName)
+	if err != nil {
+		return nil, err
+	}
+
+	prk := Extract(sha256.New, master, salt)
+	keys := &Subkeys{}
+	for _, sk := range []struct {
+		purpose string
+		length  int
+		dst     *[]byte
+	}{
+		{"encryption", d.KeySize, &keys.Encryption},
+		{"mac", MACKeySize, &keys.MAC},
+		{"iv", d.BlockSize, &keys.IV},
+	} {
+		if *sk.dst, err = Expand(sha256.New, prk, info(d.Name, sk.purpose), sk.length); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,50 @@
+// Package kdf выводит независимые подключи (шифрования, MAC, IV) из одного
+// мастер-секрета: HKDF (RFC 5869) и KDF в режимах счётчика и обратной связи
+// из NIST SP 800-108 поверх HMAC или CMAC любого SymmetricCipher.
+package kdf
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+)
+
+// ErrOutputTooLong возвращается, если запрошено больше данных, чем допускает KDF
+var ErrOutputTooLong = errors.New("kdf: requested output is too long")
+
+// Extract первый шаг HKDF: PRK = HMAC(salt, secret). Пустая соль заменяется
+// нулевой строкой длины хеша.
+func Extract(h func() hash.Hash, secret, salt []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, h().Size())
+	}
+	mac := hmac.New(h, salt)
+	mac.Write(secret)
+	return mac.Sum(nil)
+}
+
+// Expand второй шаг HKDF: length байт из PRK с контекстом info.
+// T(i) = HMAC(PRK, T(i-1) || info || i), длина не больше 255 блоков хеша.
+func Expand(h func() hash.Hash, prk, info []byte, length int) ([]byte, error) {
+	mac := hmac.New(h, prk)
+	if length < 0 || length > 255*mac.Size() {
+		return nil, ErrOutputTooLong
+	}
+
+	out := make([]byte, 0, length+mac.Size())
+	var t []byte
+	for i := 1; len(out) < length; i++ {
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{byte(i)})
+		t = mac.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:length], nil
+}
+
+// HKDF выполняет Extract и Expand
+func HKDF(h func() hash.Hash, secret, salt, info []byte, length int) ([]byte, error) {
+	return Expand(h, Extract(h, secret, salt), info, length)
+}
@@ -0,0 +1,197 @@
+package kdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// stdBlock адаптер блочного шифра стандартной библиотеки к core.SymmetricCipher
+type stdBlock struct {
+	encrypt func(dst, src []byte)
+	size    int
+}
+
+func (b *stdBlock) SetEncryptionKey([]byte) error { return nil }
+func (b *stdBlock) SetDecryptionKey([]byte) error { return nil }
+func (b *stdBlock) BlockSize() int                { return b.size }
+func (b *stdBlock) DecryptBlock([]byte) ([]byte, error) {
+	return nil, errors.New("not used")
+}
+func (b *stdBlock) EncryptBlock(in []byte) ([]byte, error) {
+	out := make([]byte, b.size)
+	b.encrypt(out, in)
+	return out, nil
+}
+
+func newCMAC(t *testing.T, algorithm string, key []byte) PRF {
+	t.Helper()
+	var c *stdBlock
+	switch algorithm {
+	case "AES":
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c = &stdBlock{block.Encrypt, block.BlockSize()}
+	case "TDEA":
+		block, err := des.NewTripleDESCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c = &stdBlock{block.Encrypt, block.BlockSize()}
+	}
+	mac, err := NewCMAC(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mac
+}
+
+func TestHKDF(t *testing.T) {
+	// RFC 5869, приложение A (случаи 1, 3 и 4)
+	tests := []struct {
+		name            string
+		h               func() hash.Hash
+		ikm, salt, info string
+		length          int
+		prk, okm        string
+	}{
+		{"A.1 SHA-256", sha256.New,
+			"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b", "000102030405060708090a0b0c", "f0f1f2f3f4f5f6f7f8f9", 42,
+			"077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+			"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"},
+		{"A.3 SHA-256 empty salt and info", sha256.New,
+			"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b", "", "", 42,
+			"19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+			"8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8"},
+		{"A.4 SHA-1", sha1.New,
+			"0b0b0b0b0b0b0b0b0b0b0b", "000102030405060708090a0b0c", "f0f1f2f3f4f5f6f7f8f9", 42,
+			"9b6c18c432a7bf8f0e71c8eb88f4b30baa2ba243",
+			"085a01ea1b10f36933068b56efa5ad81a4f14b822f5b091568a9cdd4f155fda2c22e422478d305f3f896"},
+	}
+	for _, tt := range tests {
+		prk := Extract(tt.h, mustHex(t, tt.ikm), mustHex(t, tt.salt))
+		if hex.EncodeToString(prk) != tt.prk {
+			t.Errorf("%s: PRK = %x, want %s", tt.name, prk, tt.prk)
+		}
+		okm, err := HKDF(tt.h, mustHex(t, tt.ikm), mustHex(t, tt.salt), mustHex(t, tt.info), tt.length)
+		if err != nil {
+			t.Fatalf("%s: HKDF: %v", tt.name, err)
+		}
+		if hex.EncodeToString(okm) != tt.okm {
+			t.Errorf("%s: OKM = %x, want %s", tt.name, okm, tt.okm)
+		}
+	}
+
+	if _, err := Expand(sha256.New, make([]byte, 32), nil, 255*32+1); !errors.Is(err, ErrOutputTooLong) {
+		t.Errorf("Expand beyond 255 blocks: got %v", err)
+	}
+}
+
+func TestSP800108(t *testing.T) {
+	// Значения сверены с OpenSSL 3 (EVP_KDF KBKDF): Label = "label", Context = "context"
+	key := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+	label, context := []byte("label"), []byte("context")
+
+	tests := []struct {
+		name string
+		prf  PRF
+		iv   string // пусто — режим счётчика
+		want string
+	}{
+		{"counter HMAC-SHA256", NewHMAC(sha256.New, key), "",
+			"ea7d2f723c7c89aff21be0deb82b56a4a8245b01afe4a26f5bd4cf6bafd59f0337835beb381a6598"},
+		{"feedback HMAC-SHA256", NewHMAC(sha256.New, key),
+			"a0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebf",
+			"92c2d7725b65996662fb6c12c98e46cf0ccdb640988218917c142df60c99a1ef6d550bf3d820e4e1"},
+		{"counter CMAC-AES128", newCMAC(t, "AES", key), "",
+			"3fc9b552ad320ef843abf45fe0209ce553353235b587ffa35dfd387b410da1c1a60066f8b9f805ce"},
+		{"feedback CMAC-AES128", newCMAC(t, "AES", key), "f0e0d0c0b0a090807060504030201000",
+			"3a74b470557e37ba04cd524f1fc44a5260949e1d5d78b1ccdad9848a4146f9ec3ad4cac741dc859a"},
+		{"counter CMAC-TDEA", newCMAC(t, "TDEA", mustHex(t, "8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5")), "",
+			"657f0834119cd5a0ad405b261250be2ecf051f03"},
+	}
+	for _, tt := range tests {
+		want := mustHex(t, tt.want)
+		var got []byte
+		var err error
+		if tt.iv == "" {
+			got, err = CounterKDF(tt.prf, label, context, len(want))
+		} else {
+			got, err = FeedbackKDF(tt.prf, mustHex(t, tt.iv), label, context, len(want))
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, want)
+		}
+	}
+}
+
+func TestDeriveSubkeys(t *testing.T) {
+	master := []byte("master secret")
+	salt := []byte("salt")
+
+	for _, name := range registry.Names() {
+		d, _ := registry.Lookup(name)
+		keys, err := DeriveSubkeys(master, salt, name)
+		if err != nil {
+			t.Fatalf("%s: DeriveSubkeys: %v", name, err)
+		}
+		if len(keys.Encryption) != d.KeySize || len(keys.MAC) != MACKeySize || len(keys.IV) != d.BlockSize {
+			t.Errorf("%s: subkey lengths %d/%d/%d", name, len(keys.Encryption), len(keys.MAC), len(keys.IV))
+		}
+		if bytes.Equal(keys.Encryption, keys.MAC[:d.KeySize]) {
+			t.Errorf("%s: encryption and MAC keys are not independent", name)
+		}
+
+		key, err := CipherKey(master, salt, name, "encryption")
+		if err != nil || !bytes.Equal(key, keys.Encryption) {
+			t.Errorf("%s: CipherKey differs from DeriveSubkeys (%v)", name, err)
+		}
+
+		// Выведенный ключ подходит шифру: работает в CipherContext с выведенным IV
+		c, err := registry.NewCipher(name, keys.Encryption)
+		if err != nil {
+			t.Fatalf("%s: NewCipher: %v", name, err)
+		}
+		ctx := core.NewCipherContext(c, core.CBC, core.PadPKCS7, keys.IV)
+		ciphertext, err := ctx.Encrypt([]byte("derived keys"))
+		if err != nil {
+			t.Fatalf("%s: Encrypt: %v", name, err)
+		}
+		if plaintext, err := ctx.Decrypt(ciphertext); err != nil || string(plaintext) != "derived keys" {
+			t.Errorf("%s: round trip failed: %q, %v", name, plaintext, err)
+		}
+	}
+
+	// Подключи разных шифров не совпадают даже при равной длине
+	a, _ := CipherKey(master, salt, "des", "encryption")
+	b, _ := CipherKey(master, salt, "des-feistel", "encryption")
+	if bytes.Equal(a, b) {
+		t.Error("keys for different ciphers must differ")
+	}
+	if _, err := CipherKey(master, salt, "rot13", "encryption"); err == nil {
+		t.Error("expected error for unknown cipher")
+	}
+}
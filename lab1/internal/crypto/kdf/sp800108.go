@@ -0,0 +1,108 @@
+package kdf
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+	"math"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// PRF псевдослучайная функция для KDF из SP 800-108 (NewHMAC или NewCMAC)
+type PRF interface {
+	// Size длина выхода в байтах
+	Size() int
+	// MAC вычисляет значение PRF от конкатенации частей msg
+	MAC(msg ...[]byte) ([]byte, error)
+}
+
+type hmacPRF struct {
+	h   func() hash.Hash
+	key []byte
+}
+
+// NewHMAC возвращает PRF HMAC с функцией хеширования h и ключом key
+func NewHMAC(h func() hash.Hash, key []byte) PRF {
+	return &hmacPRF{h: h, key: append([]byte(nil), key...)}
+}
+
+func (p *hmacPRF) Size() int {
+	return p.h().Size()
+}
+
+func (p *hmacPRF) MAC(msg ...[]byte) ([]byte, error) {
+	mac := hmac.New(p.h, p.key)
+	for _, part := range msg {
+		mac.Write(part)
+	}
+	return mac.Sum(nil), nil
+}
+
+// NewCMAC возвращает PRF CMAC поверх шифра c с установленным ключом шифрования;
+// ключ KDF — ключ этого шифра
+func NewCMAC(c core.SymmetricCipher) (PRF, error) {
+	return core.NewCMAC(c)
+}
+
+// fixedInput строка Label || 0x00 || Context || [L]_32, где L длина выхода в битах
+func fixedInput(label, context []byte, length int) []byte {
+	data := make([]byte, 0, len(label)+1+len(context)+4)
+	data = append(data, label...)
+	data = append(data, 0)
+	data = append(data, context...)
+	return binary.BigEndian.AppendUint32(data, uint32(length)*8)
+}
+
+// checkLength проверяет, что длина выхода в битах помещается в поле [L]_32;
+// тогда и число итераций заведомо помещается в 32-битный счётчик
+func checkLength(length int) error {
+	if length < 0 || uint64(length)*8 > math.MaxUint32 {
+		return ErrOutputTooLong
+	}
+	return nil
+}
+
+// CounterKDF KDF в режиме счётчика (SP 800-108, 4.1):
+// K(i) = PRF(K_I, [i]_32 || Label || 0x00 || Context || [L]_32).
+func CounterKDF(prf PRF, label, context []byte, length int) ([]byte, error) {
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+	fixed := fixedInput(label, context, length)
+
+	out := make([]byte, 0, length+prf.Size())
+	var counter [4]byte
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		block, err := prf.MAC(counter[:], fixed)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, block...)
+	}
+	return out[:length], nil
+}
+
+// FeedbackKDF KDF в режиме обратной связи (SP 800-108, 4.2) с итерационным счётчиком:
+// K(i) = PRF(K_I, K(i-1) || [i]_32 || Label || 0x00 || Context || [L]_32), K(0) = iv.
+func FeedbackKDF(prf PRF, iv, label, context []byte, length int) ([]byte, error) {
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+	fixed := fixedInput(label, context, length)
+
+	out := make([]byte, 0, length+prf.Size())
+	prev := iv
+	var counter [4]byte
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		block, err := prf.MAC(prev, counter[:], fixed)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, block...)
+		prev = block
+	}
+	return out[:length], nil
+}
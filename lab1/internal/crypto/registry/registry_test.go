@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+func TestRegistryBuiltinCiphers(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			d, err := Lookup(name)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+
+			key := make([]byte, d.KeySize)
+			rand.Read(key)
+
+			c, err := NewCipher(name, key)
+			if err != nil {
+				t.Fatalf("NewCipher failed: %v", err)
+			}
+			if c.BlockSize() != d.BlockSize {
+				t.Errorf("BlockSize() = %d, descriptor says %d", c.BlockSize(), d.BlockSize)
+			}
+
+			block := make([]byte, d.BlockSize)
+			rand.Read(block)
+
+			encrypted, err := c.EncryptBlock(block)
+			if err != nil {
+				t.Fatalf("EncryptBlock failed: %v", err)
+			}
+			decrypted, err := c.DecryptBlock(encrypted)
+			if err != nil {
+				t.Fatalf("DecryptBlock failed: %v", err)
+			}
+			if !bytes.Equal(block, decrypted) {
+				t.Errorf("Decryption failed: got %x, want %x", decrypted, block)
+			}
+		})
+	}
+}
+
+func TestRegistryUnknownCipher(t *testing.T) {
+	if _, err := Lookup("rot13"); err == nil {
+		t.Error("Expected error for unknown cipher, got none")
+	}
+}
+
+func TestRegistryInvalidKeySize(t *testing.T) {
+	if _, err := NewCipher("des", make([]byte, 7)); err == nil {
+		t.Error("Expected error for invalid key size, got none")
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	if err := Register(Descriptor{Name: "broken", KeySize: 8, BlockSize: 8}); err == nil {
+		t.Error("Expected error for nil constructor, got none")
+	}
+	if err := Register(Descriptor{KeySize: 8, BlockSize: 8}); err == nil {
+		t.Error("Expected error for empty name, got none")
+	}
+}
+
+func TestRegistryCiphersDestroy(t *testing.T) {
+	for _, name := range Names() {
+		d, _ := Lookup(name)
+		key := make([]byte, d.KeySize)
+		rand.Read(key)
+
+		c, err := NewCipher(name, key)
+		if err != nil {
+			t.Fatalf("%s: NewCipher failed: %v", name, err)
+		}
+		if !core.Destroy(c) {
+			t.Errorf("%s does not implement core.Destroyer", name)
+			continue
+		}
+
+		block := make([]byte, d.BlockSize)
+		if _, err := c.EncryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+			t.Errorf("%s: EncryptBlock after Destroy: got %v", name, err)
+		}
+		if _, err := c.DecryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+			t.Errorf("%s: DecryptBlock after Destroy: got %v", name, err)
+		}
+		if err := c.SetEncryptionKey(key); !errors.Is(err, core.ErrDestroyed) {
+			t.Errorf("%s: SetEncryptionKey after Destroy: got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package randomness
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+// CipherOutput шифрует nBits/8 нулевых байт одним вызовом ctx.Encrypt
+// и возвращает первые nBits бит шифртекста без записанного перед ним
+// IV или delta (см. CipherContext.PrefixSize). Для потоковых режимов
+// (OFB, CTR) это в точности гамма, для остальных — выход шифра на нулевом открытом тексте.
+func CipherOutput(ctx *core.CipherContext, nBits int) (Bits, error) {
+	if nBits <= 0 {
+		return nil, errors.New("randomness: number of bits must be positive")
+	}
+	plaintext := make([]byte, (nBits+7)/8)
+	ciphertext, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("randomness: encrypting zero plaintext: %w", err)
+	}
+	ciphertext = ciphertext[ctx.PrefixSize():]
+	if len(ciphertext)*8 < nBits {
+		return nil, ErrInsufficientData
+	}
+	return BitsFromBytes(ciphertext)[:nBits], nil
+}
+
+// EvaluateCipher прогоняет батарею SP 800-22 на выходе контекста шифрования
+func EvaluateCipher(ctx *core.CipherContext, nBits int, cfg Config) ([]Result, error) {
+	bits, err := CipherOutput(ctx, nBits)
+	if err != nil {
+		return nil, err
+	}
+	return RunBits(bits, cfg), nil
+}
@@ -0,0 +1,33 @@
+package randomness
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// DiscreteFourierTransform спектральный тест, SP 800-22 §2.6.
+// Ищет периодические составляющие по числу пиков спектра выше порога 95%.
+func DiscreteFourierTransform(bits Bits) (float64, error) {
+	n := len(bits)
+	if n < 2 {
+		return 0, ErrInsufficientData
+	}
+
+	x := make([]float64, n)
+	for i, b := range bits {
+		x[i] = 2*float64(b) - 1
+	}
+	spectrum := dft(x)
+
+	threshold := math.Sqrt(math.Log(1/0.05) * float64(n))
+	below := 0
+	for i := 0; i < n/2; i++ {
+		if cmplx.Abs(spectrum[i]) < threshold {
+			below++
+		}
+	}
+
+	n0 := 0.95 * float64(n) / 2
+	d := (float64(below) - n0) / math.Sqrt(float64(n)*0.95*0.05/4)
+	return math.Erfc(math.Abs(d) / math.Sqrt2), nil
+}
@@ -0,0 +1,91 @@
+package randomness
+
+import (
+	"errors"
+	"math"
+)
+
+// linearComplexityProbs теоретические вероятности классов T (SP 800-22 §3.10)
+var linearComplexityProbs = []float64{0.010417, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+
+// LinearComplexity тест линейной сложности в блоках длины m, SP 800-22 §2.10
+func LinearComplexity(bits Bits, m int) (float64, error) {
+	if m < 2 {
+		return 0, errors.New("randomness: block length must be at least 2")
+	}
+	blocks := len(bits) / m
+	if blocks == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	fm := float64(m)
+	sign := 1.0
+	if m%2 == 1 {
+		sign = -1.0
+	}
+	mu := fm/2 + (9+(-sign))/36 - (fm/3+2.0/9)/math.Pow(2, fm)
+
+	k := len(linearComplexityProbs) - 1
+	counts := make([]int, k+1)
+	for i := 0; i < blocks; i++ {
+		l := BerlekampMassey(bits[i*m : (i+1)*m])
+		t := sign*(float64(l)-mu) + 2.0/9
+
+		switch {
+		case t <= -2.5:
+			counts[0]++
+		case t <= -1.5:
+			counts[1]++
+		case t <= -0.5:
+			counts[2]++
+		case t <= 0.5:
+			counts[3]++
+		case t <= 1.5:
+			counts[4]++
+		case t <= 2.5:
+			counts[5]++
+		default:
+			counts[6]++
+		}
+	}
+
+	chi2 := 0.0
+	for i, c := range counts {
+		expected := float64(blocks) * linearComplexityProbs[i]
+		chi2 += sq(float64(c)-expected) / expected
+	}
+	return igamc(float64(k)/2, chi2/2), nil
+}
+
+// BerlekampMassey возвращает линейную сложность последовательности —
+// длину кратчайшего РСЛОС, который её порождает
+func BerlekampMassey(s Bits) int {
+	n := len(s)
+	c := make(Bits, n+1)
+	b := make(Bits, n+1)
+	c[0], b[0] = 1, 1
+
+	l, m := 0, -1
+	for i := 0; i < n; i++ {
+		d := s[i]
+		for j := 1; j <= l; j++ {
+			d ^= c[j] & s[i-j]
+		}
+		if d == 0 {
+			continue
+		}
+
+		t := make(Bits, n+1)
+		copy(t, c)
+		shift := i - m
+		for j := 0; j+shift <= n; j++ {
+			c[j+shift] ^= b[j]
+		}
+		if 2*l <= i {
+			l = i + 1 - l
+			m = i
+			b = t
+		}
+	}
+	return l
+}
@@ -0,0 +1,86 @@
+package randomness
+
+import "math"
+
+const rankMatrixSize = 32
+
+// Rank тест рангов двоичных матриц 32x32, SP 800-22 §2.5
+func Rank(bits Bits) (float64, error) {
+	const q = rankMatrixSize
+	matrices := len(bits) / (q * q)
+	if matrices == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	fullRank, fullRankMinus1 := 0, 0
+	rows := make([]uint32, q)
+	for k := 0; k < matrices; k++ {
+		offset := k * q * q
+		for i := 0; i < q; i++ {
+			var row uint32
+			for j := 0; j < q; j++ {
+				row = row<<1 | uint32(bits[offset+i*q+j])
+			}
+			rows[i] = row
+		}
+
+		switch binaryRank(rows) {
+		case q:
+			fullRank++
+		case q - 1:
+			fullRankMinus1++
+		}
+	}
+
+	p32 := rankProbability(q, q, q)
+	p31 := rankProbability(q, q, q-1)
+	p30 := 1 - p32 - p31
+
+	n := float64(matrices)
+	rest := float64(matrices - fullRank - fullRankMinus1)
+	chi2 := sq(float64(fullRank)-p32*n)/(p32*n) +
+		sq(float64(fullRankMinus1)-p31*n)/(p31*n) +
+		sq(rest-p30*n)/(p30*n)
+
+	return math.Exp(-chi2 / 2), nil
+}
+
+// binaryRank вычисляет ранг матрицы над GF(2); строки изменяются на месте
+func binaryRank(rows []uint32) int {
+	rank := 0
+	for bit := 31; bit >= 0 && rank < len(rows); bit-- {
+		mask := uint32(1) << uint(bit)
+		pivot := -1
+		for i := rank; i < len(rows); i++ {
+			if rows[i]&mask != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[rank], rows[pivot] = rows[pivot], rows[rank]
+		for i := 0; i < len(rows); i++ {
+			if i != rank && rows[i]&mask != 0 {
+				rows[i] ^= rows[rank]
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+// rankProbability вероятность того, что случайная матрица m x q имеет ранг r
+func rankProbability(m, q, r int) float64 {
+	product := 1.0
+	for i := 0; i < r; i++ {
+		product *= (1 - math.Pow(2, float64(i-q))) * (1 - math.Pow(2, float64(i-m))) /
+			(1 - math.Pow(2, float64(i-r)))
+	}
+	return math.Pow(2, float64(r*(q+m-r)-m*q)) * product
+}
+
+func sq(x float64) float64 {
+	return x * x
+}
@@ -0,0 +1,215 @@
+// Package tree зеркалирует дерево каталогов в зашифрованный каталог.
+//
+// Каждый файл шифруется отдельно через CipherContext.EncryptFile со своим
+// случайным IV. Рядом с файлами хранится зашифрованный и подписанный HMAC
+// манифест: исходный путь → SHA-256 содержимого, IV, размер, права, время
+// изменения и имя зашифрованного файла. При повторном запуске файлы с
+// неизменившимся хешем не перешифровываются; при расшифровании содержимое
+// сверяется с хешами манифеста.
+package tree
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+const (
+	// ManifestName имя файла манифеста в зашифрованном каталоге
+	ManifestName = ".treecrypt-manifest"
+	// storedExt расширение зашифрованных файлов; у манифеста его нет, поэтому имена не пересекаются
+	storedExt = ".enc"
+
+	manifestMagic   = "CRTM"
+	manifestVersion = 1
+)
+
+var (
+	// ErrAuthentication возвращается, если манифест не прошёл проверку HMAC (неверный ключ или подмена)
+	ErrAuthentication = errors.New("tree: manifest authentication failed (wrong key or corrupted manifest)")
+	// ErrIntegrity возвращается, если расшифрованный файл не совпал с хешем из манифеста
+	ErrIntegrity = errors.New("tree: file does not match manifest")
+)
+
+// Options параметры шифрования дерева
+type Options struct {
+	Cipher       string           // имя шифра из реестра
+	Mode         core.CipherMode  // режим шифрования; ECB не допускается
+	Padding      core.PaddingMode // режим паддинга; PadZeros не допускается
+	EncryptNames bool             // скрывать имена файлов и структуру каталогов
+}
+
+// FileEntry запись манифеста об одном файле
+type FileEntry struct {
+	Path    string      `json:"path"`   // путь в исходном дереве, через "/"
+	Stored  string      `json:"stored"` // путь зашифрованного файла относительно каталога назначения
+	Hash    string      `json:"sha256"` // SHA-256 открытого содержимого, hex
+	IV      string      `json:"iv"`     // IV, с которым зашифрован файл, hex
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+}
+
+// Manifest содержимое манифеста
+type Manifest struct {
+	Version int                  `json:"version"`
+	Options Options              `json:"options"`
+	Files   map[string]FileEntry `json:"files"`
+}
+
+// Stats итоги операции над деревом
+type Stats struct {
+	Encrypted int // зашифровано новых или изменившихся файлов
+	Unchanged int // пропущено неизменившихся файлов
+	Removed   int // удалено зашифрованных файлов, исчезнувших из источника
+	Decrypted int // расшифровано и проверено файлов
+}
+
+// keys ключи, выводимые из ключа шифрования
+type keys struct {
+	mac  []byte // HMAC манифеста
+	name []byte // шифрование имён
+}
+
+func deriveKeys(key []byte) keys {
+	derive := func(label string) []byte {
+		m := hmac.New(sha256.New, key)
+		m.Write([]byte(label))
+		return m.Sum(nil)
+	}
+	return keys{
+		mac:  derive("cryptography/tree v1 manifest mac key"),
+		name: derive("cryptography/tree v1 file name key"),
+	}
+}
+
+// storedPath имя зашифрованного файла. При шифровании имён это детерминированный
+// HMAC пути, поэтому при повторном запуске файл попадает на то же место,
+// а сам путь восстанавливается только из манифеста.
+func (k keys) storedPath(path string, encryptNames bool) string {
+	if !encryptNames {
+		return path + storedExt
+	}
+	m := hmac.New(sha256.New, k.name)
+	m.Write([]byte(path))
+	return hex.EncodeToString(m.Sum(nil)[:16]) + storedExt
+}
+
+// manifestContext контекст шифрования манифеста; IV генерируется при каждой записи
+func manifestContext(opts Options, key []byte) (*core.CipherContext, error) {
+	c, err := registry.NewCipher(opts.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	return core.NewCipherContext(c, opts.Mode, opts.Padding, nil), nil
+}
+
+// writeManifest шифрует манифест и атомарно записывает его в каталог dir.
+// Формат файла: "CRTM" | параметры шифра (JSON, длина в 2 байтах) | HMAC | шифртекст.
+func writeManifest(dir string, key []byte, m *Manifest) error {
+	params, err := json.Marshal(m.Options)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	ctx, err := manifestContext(m.Options, key)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := ctx.Encrypt(encoded)
+	if err != nil {
+		return fmt.Errorf("tree: encrypting manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(manifestMagic)
+	buf.Write([]byte{byte(len(params) >> 8), byte(len(params))})
+	buf.Write(params)
+	buf.Write(manifestTag(deriveKeys(key).mac, params, ciphertext))
+	buf.Write(ciphertext)
+
+	return writeFileAtomic(filepath.Join(dir, ManifestName), buf.Bytes(), 0600)
+}
+
+// readManifest читает и проверяет манифест каталога dir.
+// Если манифеста нет, возвращается ошибка, для которой errors.Is(err, fs.ErrNotExist).
+func readManifest(dir string, key []byte) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(manifestMagic)+2 || string(data[:len(manifestMagic)]) != manifestMagic {
+		return nil, fmt.Errorf("tree: %s is not a manifest", ManifestName)
+	}
+	data = data[len(manifestMagic):]
+	n := int(data[0])<<8 | int(data[1])
+	data = data[2:]
+	if len(data) < n+sha256.Size {
+		return nil, fmt.Errorf("tree: manifest is truncated")
+	}
+	params, tag, ciphertext := data[:n], data[n:n+sha256.Size], data[n+sha256.Size:]
+	if !hmac.Equal(tag, manifestTag(deriveKeys(key).mac, params, ciphertext)) {
+		return nil, ErrAuthentication
+	}
+
+	var opts Options
+	if err := json.Unmarshal(params, &opts); err != nil {
+		return nil, fmt.Errorf("tree: malformed manifest: %w", err)
+	}
+	ctx, err := manifestContext(opts, key)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := ctx.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("tree: decrypting manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		return nil, fmt.Errorf("tree: malformed manifest: %w", err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("tree: unsupported manifest version %d", m.Version)
+	}
+	if m.Options != opts {
+		return nil, errors.New("tree: manifest parameters do not match its header")
+	}
+	return &m, nil
+}
+
+// ReadManifest расшифровывает и проверяет манифест зашифрованного каталога dir
+func ReadManifest(dir string, key []byte) (*Manifest, error) {
+	return readManifest(dir, key)
+}
+
+func manifestTag(macKey, params, ciphertext []byte) []byte {
+	m := hmac.New(sha256.New, macKey)
+	m.Write([]byte(manifestMagic))
+	m.Write(params)
+	m.Write(ciphertext)
+	return m.Sum(nil)
+}
+
+// writeFileAtomic записывает файл через временный файл и переименование,
+// чтобы прерванная запись не оставила наполовину записанный файл
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
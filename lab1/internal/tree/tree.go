@@ -0,0 +1,268 @@
+package tree
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/registry"
+)
+
+// Encrypt зеркалирует дерево src в зашифрованный каталог dst.
+// Если в dst уже есть манифест с теми же параметрами, перешифровываются только
+// новые и изменившиеся файлы, а файлы, удалённые из src, удаляются из dst.
+// Символические ссылки и специальные файлы пропускаются.
+func Encrypt(src, dst string, key []byte, opts Options) (Stats, error) {
+	var stats Stats
+	if opts.Mode == core.ECB {
+		return stats, errors.New("tree: ECB mode reveals repeated blocks and is not supported")
+	}
+	if opts.Padding == core.PadZeros {
+		return stats, errors.New("tree: zero padding is ambiguous and is not supported")
+	}
+	desc, err := registry.Lookup(opts.Cipher)
+	if err != nil {
+		return stats, err
+	}
+	c, err := registry.NewCipher(opts.Cipher, key)
+	if err != nil {
+		return stats, err
+	}
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return stats, err
+	}
+
+	old, err := readManifest(dst, key)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		old = &Manifest{}
+	case err != nil:
+		// Не перезаписываем каталог, зашифрованный другим ключом
+		return stats, err
+	}
+	// При смене параметров прежние шифртексты непригодны: шифруем всё заново
+	reuse := old.Options == opts
+
+	k := deriveKeys(key)
+	m := &Manifest{Version: manifestVersion, Options: opts, Files: make(map[string]FileEntry)}
+
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hash, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+
+		entry := FileEntry{
+			Path:    rel,
+			Stored:  k.storedPath(rel, opts.EncryptNames),
+			Hash:    hash,
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime().UTC(),
+		}
+		if prev, ok := old.Files[rel]; reuse && ok && prev.Hash == hash && prev.Stored == entry.Stored &&
+			fileExists(filepath.Join(dst, filepath.FromSlash(prev.Stored))) {
+			entry.IV = prev.IV
+			m.Files[rel] = entry
+			stats.Unchanged++
+			return nil
+		}
+
+		// Новый IV для каждого шифрования: перешифрованный файл не повторяет IV старой версии
+		iv := make([]byte, desc.BlockSize)
+		if _, err := rand.Read(iv); err != nil {
+			return err
+		}
+		entry.IV = hex.EncodeToString(iv)
+
+		out := filepath.Join(dst, filepath.FromSlash(entry.Stored))
+		if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+			return err
+		}
+		ctx := core.NewCipherContext(c, opts.Mode, opts.Padding, iv)
+		if err := ctx.EncryptFile(p, out+".tmp"); err != nil {
+			os.Remove(out + ".tmp")
+			return fmt.Errorf("tree: encrypting %s: %w", rel, err)
+		}
+		if err := os.Rename(out+".tmp", out); err != nil {
+			return err
+		}
+		m.Files[rel] = entry
+		stats.Encrypted++
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	// Удаляем шифртексты файлов, которых больше нет (или которые сменили имя хранения)
+	kept := make(map[string]bool, len(m.Files))
+	for _, e := range m.Files {
+		kept[e.Stored] = true
+	}
+	for _, e := range old.Files {
+		if kept[e.Stored] {
+			continue
+		}
+		if err := removeStored(dst, e.Stored); err != nil {
+			return stats, err
+		}
+		stats.Removed++
+	}
+
+	return stats, writeManifest(dst, key, m)
+}
+
+// Decrypt восстанавливает дерево из зашифрованного каталога src в dst и сверяет
+// каждый файл с хешем из манифеста. Файл, не прошедший проверку, не остаётся в dst.
+func Decrypt(src, dst string, key []byte) (Stats, error) {
+	return walkManifest(src, key, func(e FileEntry, ctx *core.CipherContext) error {
+		out := filepath.Join(dst, filepath.FromSlash(e.Path))
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		if err := decryptEntry(src, e, ctx, out+".tmp"); err != nil {
+			os.Remove(out + ".tmp")
+			return err
+		}
+		if err := os.Rename(out+".tmp", out); err != nil {
+			return err
+		}
+		if err := os.Chmod(out, e.Mode); err != nil {
+			return err
+		}
+		return os.Chtimes(out, e.ModTime, e.ModTime)
+	})
+}
+
+// Verify расшифровывает все файлы каталога src во временный каталог и сверяет их с манифестом
+func Verify(src string, key []byte) (Stats, error) {
+	tmp, err := os.MkdirTemp("", "treecrypt-verify-")
+	if err != nil {
+		return Stats{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	out := filepath.Join(tmp, "file")
+	return walkManifest(src, key, func(e FileEntry, ctx *core.CipherContext) error {
+		defer os.Remove(out)
+		return decryptEntry(src, e, ctx, out)
+	})
+}
+
+// walkManifest проверяет манифест src и вызывает fn для каждого файла в порядке путей
+func walkManifest(src string, key []byte, fn func(FileEntry, *core.CipherContext) error) (Stats, error) {
+	var stats Stats
+	m, err := readManifest(src, key)
+	if err != nil {
+		return stats, err
+	}
+	c, err := registry.NewCipher(m.Options.Cipher, key)
+	if err != nil {
+		return stats, err
+	}
+
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		e := m.Files[p]
+		if e.Path != p || !validPath(e.Path) || !validPath(e.Stored) {
+			return stats, fmt.Errorf("tree: malformed manifest entry %q", p)
+		}
+		iv, err := hex.DecodeString(e.IV)
+		if err != nil {
+			return stats, fmt.Errorf("tree: malformed IV for %s: %w", p, err)
+		}
+		if err := fn(e, core.NewCipherContext(c, m.Options.Mode, m.Options.Padding, iv)); err != nil {
+			return stats, err
+		}
+		stats.Decrypted++
+	}
+	return stats, nil
+}
+
+// decryptEntry расшифровывает файл e в out и проверяет размер и хеш
+func decryptEntry(src string, e FileEntry, ctx *core.CipherContext, out string) error {
+	in := filepath.Join(src, filepath.FromSlash(e.Stored))
+	if !fileExists(in) {
+		return fmt.Errorf("%w: %s: encrypted file %s is missing", ErrIntegrity, e.Path, e.Stored)
+	}
+	if err := ctx.DecryptFile(in, out); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrIntegrity, e.Path, err)
+	}
+	hash, err := hashFile(out)
+	if err != nil {
+		return err
+	}
+	if hash != e.Hash {
+		return fmt.Errorf("%w: %s: content hash mismatch", ErrIntegrity, e.Path)
+	}
+	return nil
+}
+
+// removeStored удаляет зашифрованный файл и опустевшие после этого каталоги
+func removeStored(dst, stored string) error {
+	p := filepath.Join(dst, filepath.FromSlash(stored))
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for dir := filepath.Dir(p); dir != filepath.Clean(dst); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// validPath не даёт записи манифеста выйти за пределы каталога
+func validPath(p string) bool {
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(p)))
+	return p != "" && clean == p && !filepath.IsAbs(filepath.FromSlash(p)) &&
+		clean != ".." && !strings.HasPrefix(clean, "../")
+}
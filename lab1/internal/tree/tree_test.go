@@ -0,0 +1,293 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab1/internal/crypto/core"
+)
+
+var testKey = []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+
+var testOptions = Options{Cipher: "des", Mode: core.CBC, Padding: core.PadPKCS7}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	found := 0
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		found++
+		rel, _ := filepath.Rel(root, p)
+		want, ok := files[filepath.ToSlash(rel)]
+		got, _ := os.ReadFile(p)
+		if !ok || string(got) != want {
+			t.Errorf("%s = %q, want %q (expected: %t)", rel, got, want, ok)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != len(files) {
+		t.Errorf("tree has %d files, want %d", found, len(files))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"README.md":        "# project\n",
+		"src/main.go":      "package main\n\nfunc main() {}\n",
+		"src/util/util.go": strings.Repeat("package util\n", 50),
+		"data/empty.bin":   "",
+		"data/blocks.bin":  string(bytes.Repeat([]byte{0}, 64)),
+		"manifest.enc":     "source file whose name looks like a stored file",
+		ManifestName + "x": "source file whose name looks like a manifest",
+	}
+
+	for _, encryptNames := range []bool{false, true} {
+		t.Run(fmt.Sprintf("EncryptNames=%t", encryptNames), func(t *testing.T) {
+			src, enc, out := t.TempDir(), t.TempDir(), t.TempDir()
+			writeTree(t, src, files)
+			mtime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+			if err := os.Chtimes(filepath.Join(src, "README.md"), mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := testOptions
+			opts.EncryptNames = encryptNames
+			stats, err := Encrypt(src, enc, testKey, opts)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if stats.Encrypted != len(files) || stats.Unchanged != 0 {
+				t.Errorf("Encrypt stats = %+v", stats)
+			}
+
+			if encryptNames {
+				entries, err := os.ReadDir(enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range entries {
+					if e.IsDir() || strings.Contains(e.Name(), "main") || strings.Contains(e.Name(), "README") {
+						t.Errorf("encrypted directory reveals name %q", e.Name())
+					}
+				}
+			} else if !fileExists(filepath.Join(enc, "src", "main.go.enc")) {
+				t.Error("src/main.go.enc is missing")
+			}
+
+			stats, err = Decrypt(enc, out, testKey)
+			if err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			if stats.Decrypted != len(files) {
+				t.Errorf("Decrypt stats = %+v", stats)
+			}
+			checkTree(t, out, files)
+
+			info, err := os.Stat(filepath.Join(out, "README.md"))
+			if err != nil || !info.ModTime().Equal(mtime) {
+				t.Errorf("README.md mtime was not restored: %v, %v", info.ModTime(), err)
+			}
+		})
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	src, enc := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"a.txt":      "first",
+		"b.txt":      "second",
+		"dir/c.txt":  "third",
+		"gone/d.txt": "will be deleted",
+	}
+	writeTree(t, src, files)
+
+	if _, err := Encrypt(src, enc, testKey, testOptions); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	before, err := ReadManifest(enc, testKey)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	stats, err := Encrypt(src, enc, testKey, testOptions)
+	if err != nil {
+		t.Fatalf("second Encrypt failed: %v", err)
+	}
+	if stats != (Stats{Unchanged: len(files)}) {
+		t.Errorf("unchanged tree: stats = %+v", stats)
+	}
+
+	writeTree(t, src, map[string]string{"b.txt": "second, edited", "new.txt": "added"})
+	if err := os.RemoveAll(filepath.Join(src, "gone")); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = Encrypt(src, enc, testKey, testOptions)
+	if err != nil {
+		t.Fatalf("third Encrypt failed: %v", err)
+	}
+	if stats != (Stats{Encrypted: 2, Unchanged: 2, Removed: 1}) {
+		t.Errorf("changed tree: stats = %+v", stats)
+	}
+	if _, err := os.Stat(filepath.Join(enc, "gone")); !os.IsNotExist(err) {
+		t.Errorf("directory of deleted file was not removed: %v", err)
+	}
+
+	after, err := ReadManifest(enc, testKey)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if after.Files["a.txt"].IV != before.Files["a.txt"].IV {
+		t.Error("unchanged file was re-encrypted")
+	}
+	if after.Files["b.txt"].IV == before.Files["b.txt"].IV {
+		t.Error("changed file was re-encrypted under the same IV")
+	}
+
+	out := t.TempDir()
+	if _, err := Decrypt(enc, out, testKey); err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	checkTree(t, out, map[string]string{
+		"a.txt":     "first",
+		"b.txt":     "second, edited",
+		"dir/c.txt": "third",
+		"new.txt":   "added",
+	})
+
+	// Смена параметров перешифровывает всё и убирает старые имена
+	opts := testOptions
+	opts.EncryptNames = true
+	stats, err = Encrypt(src, enc, testKey, opts)
+	if err != nil {
+		t.Fatalf("Encrypt with new options failed: %v", err)
+	}
+	if stats != (Stats{Encrypted: 4, Removed: 4}) {
+		t.Errorf("options change: stats = %+v", stats)
+	}
+	if fileExists(filepath.Join(enc, "a.txt.enc")) {
+		t.Error("file with plain name was left behind")
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	src, enc := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": strings.Repeat("payload ", 20), "b.txt": "other"})
+	if _, err := Encrypt(src, enc, testKey, testOptions); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if stats, err := Verify(enc, testKey); err != nil || stats.Decrypted != 2 {
+		t.Fatalf("Verify of intact tree: %+v, %v", stats, err)
+	}
+
+	stored := filepath.Join(enc, "a.txt.enc")
+	data, err := os.ReadFile(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[3] ^= 0x40
+	if err := os.WriteFile(stored, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(enc, testKey); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("tampered file: expected ErrIntegrity, got %v", err)
+	}
+
+	out := t.TempDir()
+	if _, err := Decrypt(enc, out, testKey); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("Decrypt of tampered file: expected ErrIntegrity, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "a.txt")); !os.IsNotExist(err) {
+		t.Error("file that failed verification was left in the output")
+	}
+
+	if err := os.Remove(stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(enc, testKey); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("missing file: expected ErrIntegrity, got %v", err)
+	}
+}
+
+func TestManifestAuthentication(t *testing.T) {
+	src, enc := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "secret"})
+	if _, err := Encrypt(src, enc, testKey, testOptions); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	wrong := bytes.Clone(testKey)
+	wrong[7] ^= 0x10
+	if _, err := Decrypt(enc, t.TempDir(), wrong); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("wrong key: expected ErrAuthentication, got %v", err)
+	}
+	// Чужой ключ не должен перезаписать уже зашифрованный каталог
+	if _, err := Encrypt(src, enc, wrong, testOptions); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("Encrypt with wrong key: expected ErrAuthentication, got %v", err)
+	}
+
+	path := filepath.Join(enc, ManifestName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0x01
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(enc, testKey); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("tampered manifest: expected ErrAuthentication, got %v", err)
+	}
+}
+
+func TestEncryptRejects(t *testing.T) {
+	src := t.TempDir()
+	for _, opts := range []Options{
+		{Cipher: "des", Mode: core.ECB, Padding: core.PadPKCS7},
+		{Cipher: "des", Mode: core.CBC, Padding: core.PadZeros},
+		{Cipher: "no-such-cipher", Mode: core.CBC, Padding: core.PadPKCS7},
+	} {
+		if _, err := Encrypt(src, t.TempDir(), testKey, opts); err == nil {
+			t.Errorf("Encrypt(%+v): expected error", opts)
+		}
+	}
+}
+
+func TestValidPath(t *testing.T) {
+	for p, want := range map[string]bool{
+		"a.txt":         true,
+		"dir/a.txt.enc": true,
+		"":              false,
+		"../a":          false,
+		"/etc/passwd":   false,
+		"a/../../b":     false,
+		"a//b":          false,
+	} {
+		if got := validPath(p); got != want {
+			t.Errorf("validPath(%q) = %t, want %t", p, got, want)
+		}
+	}
+}
@@ -71,6 +71,8 @@ func (kg *KeyGenerator) GenerateKeyPair() (*PrivateKey, error) {
 		pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
 		qMinus1 := new(big.Int).Sub(q, big.NewInt(1))
 		phi := new(big.Int).Mul(pMinus1, qMinus1)
+		wipeInt(pMinus1)
+		wipeInt(qMinus1)
 
 		// Выбираем открытую экспоненту e
 		e := big.NewInt(65537) // Стандартное значение
@@ -84,6 +86,7 @@ func (kg *KeyGenerator) GenerateKeyPair() (*PrivateKey, error) {
 		// Вычисляем закрытую экспоненту d: d ≡ e^(-1) (mod φ(n))
 		result := kg.ntService.ExtendedGCD(e, phi)
 		d := new(big.Int).Set(result.X)
+		wipeInt(result.X)
 
 		// Нормализуем d в положительное значение
 		if d.Cmp(big.NewInt(0)) < 0 {
@@ -1,6 +1,7 @@
 package rsa
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 )
@@ -86,3 +87,34 @@ func TestKeyGeneration(t *testing.T) {
 		t.Error("P * Q != N")
 	}
 }
+
+func TestPrivateKeyDestroy(t *testing.T) {
+	service := NewService(TestTypeMillerRabin, 0.99, 256)
+	if err := service.GenerateKeys(); err != nil {
+		t.Fatalf("Failed to generate keys: %v", err)
+	}
+
+	publicKey, _ := service.GetPublicKey()
+	privateKey, _ := service.GetPrivateKey()
+	ciphertext, err := service.EncryptBytes([]byte("secret"), publicKey)
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	service.Destroy()
+
+	for name, x := range map[string]*big.Int{"D": privateKey.D, "P": privateKey.P, "Q": privateKey.Q, "Phi": privateKey.Phi} {
+		if x.Sign() != 0 {
+			t.Errorf("%s is not wiped after Destroy", name)
+		}
+	}
+	if !privateKey.Destroyed() {
+		t.Error("Destroyed() = false after Destroy")
+	}
+	if _, err := service.DecryptBytes(ciphertext, privateKey); !errors.Is(err, ErrKeyDestroyed) {
+		t.Errorf("Decrypt after Destroy: got %v, want ErrKeyDestroyed", err)
+	}
+	if _, err := service.GetPrivateKey(); err == nil {
+		t.Error("GetPrivateKey after Destroy should fail")
+	}
+}
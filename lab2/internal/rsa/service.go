@@ -51,6 +51,14 @@ func (s *Service) GetPrivateKey() (*PrivateKey, error) {
 	return s.currentKey, nil
 }
 
+// Destroy уничтожает текущий закрытый ключ сервиса
+func (s *Service) Destroy() {
+	if s.currentKey != nil {
+		s.currentKey.Destroy()
+		s.currentKey = nil
+	}
+}
+
 // Encrypt шифрует данные открытым ключом
 // Формула: c = m^e mod n
 func (s *Service) Encrypt(message *big.Int, publicKey *PublicKey) (*big.Int, error) {
@@ -67,6 +75,10 @@ func (s *Service) Encrypt(message *big.Int, publicKey *PublicKey) (*big.Int, err
 // Decrypt дешифрует данные закрытым ключом
 // Формула: m = c^d mod n
 func (s *Service) Decrypt(ciphertext *big.Int, privateKey *PrivateKey) (*big.Int, error) {
+	if privateKey.destroyed {
+		return nil, ErrKeyDestroyed
+	}
+
 	// Проверяем, что шифртекст меньше модуля
 	if ciphertext.Cmp(privateKey.N) >= 0 {
 		return nil, fmt.Errorf("шифртекст должен быть меньше модуля n")
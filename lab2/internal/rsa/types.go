@@ -1,6 +1,12 @@
 package rsa
 
-import "math/big"
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrKeyDestroyed возвращается при использовании закрытого ключа после Destroy
+var ErrKeyDestroyed = errors.New("закрытый ключ уничтожен")
 
 // TestType представляет тип теста простоты (nested enum)
 type TestType int
@@ -36,9 +42,35 @@ type PublicKey struct {
 
 // PrivateKey представляет закрытый ключ RSA
 type PrivateKey struct {
-	PublicKey        // Встроенный открытый ключ
+	PublicKey          // Встроенный открытый ключ
 	D         *big.Int // Закрытая экспонента
 	P         *big.Int // Простое число p
 	Q         *big.Int // Простое число q
 	Phi       *big.Int // Функция Эйлера φ(n) = (p-1)(q-1)
+
+	destroyed bool
+}
+
+// Destroy перезаписывает нулями D, P, Q и φ(n). Открытая часть ключа
+// остаётся пригодной, а расшифрование этим ключом возвращает ErrKeyDestroyed.
+func (k *PrivateKey) Destroy() {
+	for _, x := range []*big.Int{k.D, k.P, k.Q, k.Phi} {
+		wipeInt(x)
+	}
+	k.destroyed = true
+}
+
+// Destroyed сообщает, был ли ключ уничтожен
+func (k *PrivateKey) Destroyed() bool {
+	return k.destroyed
+}
+
+// wipeInt затирает слова числа x, включая ёмкость за пределами длины
+func wipeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	clear(words[:cap(words)])
+	x.SetInt64(0)
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/rijndael"
+	"github.com/NikitaKoros/cryptography/lab3/internal/gf256"
+	"github.com/NikitaKoros/cryptography/lab3/internal/randomness"
+)
+
+func main() {
+	modulusStr := flag.String("modulus", "0x1B", "модуль GF(2^8) без старшего бита x^8")
+	allModuli := flag.Bool("all-moduli", false, "прогнать все неприводимые модули и вывести сводку")
+	blockSize := flag.Int("block", 16, "размер блока в байтах (16, 24, 32)")
+	keySize := flag.Int("keysize", 16, "размер ключа в байтах (16, 24, 32)")
+	modeName := flag.String("mode", "CTR", "режим шифрования (ECB, CBC, PCBC, CFB, OFB, CTR, RandomDelta)")
+	paddingName := flag.String("padding", "Zeros", "режим паддинга (Zeros, ANSIX923, PKCS7, ISO10126)")
+	nBits := flag.Int("bits", 1_000_000, "длина проверяемой последовательности в битах")
+	alpha := flag.Float64("alpha", randomness.DefaultAlpha, "уровень значимости")
+	keyHex := flag.String("key", "", "ключ в hex (по умолчанию случайный)")
+	ivHex := flag.String("iv", "", "IV в hex (по умолчанию случайный)")
+	flag.Parse()
+
+	mode, err := core.ParseCipherMode(*modeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	padding, err := core.ParsePaddingMode(*paddingName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := hexOrRandom(*keyHex, *keySize)
+	if err != nil {
+		log.Fatalf("Ошибка разбора ключа: %v", err)
+	}
+	iv, err := hexOrRandom(*ivHex, *blockSize)
+	if err != nil {
+		log.Fatalf("Ошибка разбора IV: %v", err)
+	}
+
+	fmt.Printf("Rijndael-%d/%d, режим: %s, паддинг: %s\n", *blockSize*8, *keySize*8, mode, padding)
+	fmt.Printf("Ключ: %x\nIV:   %x\n", key, iv)
+	fmt.Printf("Длина последовательности: %d бит, α = %g\n\n", *nBits, *alpha)
+
+	evaluate := func(modulus byte) []randomness.Result {
+		c, err := rijndael.NewRijndael(*blockSize, *keySize, modulus)
+		if err != nil {
+			log.Fatalf("Ошибка создания Rijndael: %v", err)
+		}
+		if err := c.SetEncryptionKey(key); err != nil {
+			log.Fatalf("SetEncryptionKey error: %v", err)
+		}
+		if err := c.SetDecryptionKey(key); err != nil {
+			log.Fatalf("SetDecryptionKey error: %v", err)
+		}
+		ctx := core.NewCipherContext(c, mode, padding, iv)
+		results, err := randomness.EvaluateCipher(ctx, *nBits, randomness.DefaultConfig())
+		if err != nil {
+			log.Fatal(err)
+		}
+		return results
+	}
+
+	if *allModuli {
+		totalFailed := 0
+		fmt.Printf("%-8s %-10s %s\n", "Модуль", "Провалено", "Непройденные тесты")
+		for _, modulus := range gf256.GetAllIrreduciblePolynomials() {
+			failedNames := failedTests(evaluate(modulus), *alpha)
+			totalFailed += len(failedNames)
+			fmt.Printf("0x%02X     %-10d %v\n", modulus, len(failedNames), failedNames)
+		}
+		if totalFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	modulus, err := strconv.ParseUint(*modulusStr, 0, 8)
+	if err != nil {
+		log.Fatalf("Ошибка разбора модуля: %v", err)
+	}
+	fmt.Printf("Модуль: 0x%02X = %s\n\n", modulus, gf256.PolyToString(byte(modulus)))
+
+	results := evaluate(byte(modulus))
+	fmt.Printf("%-26s %-10s %-10s %s\n", "Тест", "min P", "Кол-во P", "Результат")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%-26s %-10s %-10s %s (%v)\n", r.Name, "-", "-", "НЕПРИМЕНИМ", r.Err)
+			continue
+		}
+		status := "OK"
+		if !r.Passed(*alpha) {
+			status = "FAIL"
+		}
+		fmt.Printf("%-26s %-10.6f %-10d %s\n", r.Name, r.MinPValue(), len(r.PValues), status)
+	}
+
+	failed := failedTests(results, *alpha)
+	fmt.Printf("\nНе пройдено тестов: %d из %d\n", len(failed), len(results))
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// failedTests возвращает имена применимых тестов, не прошедших на уровне alpha
+func failedTests(results []randomness.Result, alpha float64) []string {
+	var names []string
+	for _, r := range results {
+		if r.Err == nil && !r.Passed(alpha) {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// hexOrRandom разбирает hex-строку заданной длины или генерирует случайные байты
+func hexOrRandom(s string, size int) ([]byte, error) {
+	if s == "" {
+		buf := make([]byte, size)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) != size {
+		return nil, fmt.Errorf("ожидалось %d байт, получено %d", size, len(buf))
+	}
+	return buf, nil
+}
@@ -151,11 +151,8 @@ func main() {
 		log.Fatalf("Ошибка установки ключа дешифрования: %v", err)
 	}
 
-	// IV для режимов, требующих его
-	iv := make([]byte, 16)
-	if _, err := rand.Read(iv); err != nil {
-		log.Fatalf("Ошибка генерации IV: %v", err)
-	}
+	// IV не задаётся: контекст генерирует случайный IV для каждого сообщения
+	// и записывает его перед шифртекстом, поэтому сообщения не делят один IV
 
 	modes := []core.CipherMode{
 		core.ECB,
@@ -177,7 +174,7 @@ func main() {
 		for _, padding := range paddingModes {
 			fmt.Printf("\nРежим: %v, Паддинг: %v\n", mode, padding)
 
-			ctx := core.NewCipherContext(cipher, mode, padding, iv)
+			ctx := core.NewCipherContext(cipher, mode, padding, nil)
 
 			encrypted, err := ctx.Encrypt(testData)
 			if err != nil {
@@ -212,7 +209,7 @@ func main() {
 	if err := os.WriteFile(testInputFile, testFileData, 0644); err != nil {
 		log.Printf("Ошибка создания тестового файла: %v", err)
 	} else {
-		ctx := core.NewCipherContext(cipher, core.CBC, core.PadPKCS7, iv)
+		ctx := core.NewCipherContext(cipher, core.CBC, core.PadPKCS7, nil)
 
 		fmt.Println("Шифрование файла...")
 		if err := ctx.EncryptFile(testInputFile, testEncryptedFile); err != nil {
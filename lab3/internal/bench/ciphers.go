@@ -0,0 +1,139 @@
+package bench
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/rijndael"
+)
+
+// aesModulus стандартный модуль AES: x^8 + x^4 + x^3 + x + 1
+const aesModulus = 0x1B
+
+// descriptor описывает шифр, доступный в матрице
+type descriptor struct {
+	keySize   int
+	blockSize int
+	newCipher func(key []byte) (core.SymmetricCipher, error)
+}
+
+// ciphers все варианты Rijndael (табличные и с постоянным временем)
+// и эталонный AES стандартной библиотеки
+var ciphers = func() map[string]descriptor {
+	m := make(map[string]descriptor)
+	for _, blockSize := range []int{16, 24, 32} {
+		for _, keySize := range []int{16, 24, 32} {
+			for prefix, impl := range map[string]rijndael.Implementation{"rijndael": rijndael.Reference, "rijndael-ct": rijndael.ConstantTime} {
+				blockSize, keySize, impl := blockSize, keySize, impl
+				name := fmt.Sprintf("%s-b%d-k%d", prefix, blockSize*8, keySize*8)
+				m[name] = descriptor{keySize: keySize, blockSize: blockSize, newCipher: func(key []byte) (core.SymmetricCipher, error) {
+					r, err := rijndael.NewRijndael(blockSize, keySize, aesModulus, impl)
+					if err != nil {
+						return nil, err
+					}
+					if err := r.SetEncryptionKey(key); err != nil {
+						return nil, err
+					}
+					if err := r.SetDecryptionKey(key); err != nil {
+						return nil, err
+					}
+					return r, nil
+				}}
+			}
+		}
+		keySize := blockSize
+		m[fmt.Sprintf("stdlib-aes-%d", keySize*8)] = descriptor{keySize: keySize, blockSize: aes.BlockSize, newCipher: newStdAES}
+	}
+	return m
+}()
+
+// newStdAES создаёт эталонный AES стандартной библиотеки
+func newStdAES(key []byte) (core.SymmetricCipher, error) {
+	s := &stdCipher{newBlock: aes.NewCipher, blockSize: aes.BlockSize}
+	if err := s.SetEncryptionKey(key); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CipherNames возвращает имена всех шифров матрицы
+func CipherNames() []string {
+	names := make([]string, 0, len(ciphers))
+	for name := range ciphers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCipher создаёт шифр по имени с установленным ключом
+func NewCipher(name string, key []byte) (core.SymmetricCipher, error) {
+	d, ok := ciphers[name]
+	if !ok {
+		return nil, fmt.Errorf("bench: unknown cipher %q", name)
+	}
+	if len(key) != d.keySize {
+		return nil, fmt.Errorf("bench: %s key must be %d bytes, got %d", name, d.keySize, len(key))
+	}
+	return d.newCipher(key)
+}
+
+func cipherSizes(name string) (keySize, blockSize int, err error) {
+	d, ok := ciphers[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("bench: unknown cipher %q", name)
+	}
+	return d.keySize, d.blockSize, nil
+}
+
+// stdCipher адаптирует cipher.Block к core.SymmetricCipher
+type stdCipher struct {
+	newBlock  func(key []byte) (cipher.Block, error)
+	block     cipher.Block
+	blockSize int
+}
+
+func (s *stdCipher) SetEncryptionKey(key []byte) error {
+	block, err := s.newBlock(key)
+	if err != nil {
+		return err
+	}
+	s.block = block
+	return nil
+}
+
+func (s *stdCipher) SetDecryptionKey(key []byte) error {
+	return s.SetEncryptionKey(key)
+}
+
+func (s *stdCipher) EncryptBlock(block []byte) ([]byte, error) {
+	if s.block == nil {
+		return nil, errors.New("key not set")
+	}
+	if len(block) != s.blockSize {
+		return nil, fmt.Errorf("block must be %d bytes", s.blockSize)
+	}
+	out := make([]byte, s.blockSize)
+	s.block.Encrypt(out, block)
+	return out, nil
+}
+
+func (s *stdCipher) DecryptBlock(block []byte) ([]byte, error) {
+	if s.block == nil {
+		return nil, errors.New("key not set")
+	}
+	if len(block) != s.blockSize {
+		return nil, fmt.Errorf("block must be %d bytes", s.blockSize)
+	}
+	out := make([]byte, s.blockSize)
+	s.block.Decrypt(out, block)
+	return out, nil
+}
+
+func (s *stdCipher) BlockSize() int {
+	return s.blockSize
+}
@@ -0,0 +1,144 @@
+package bench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoResults возвращается при сравнении отчётов без общих измерений
+var ErrNoResults = errors.New("bench: reports have no measurements in common")
+
+// WriteJSON записывает отчёт в формате JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON читает отчёт, ранее записанный WriteJSON
+func ReadJSON(rd io.Reader) (*Report, error) {
+	var r Report
+	if err := json.NewDecoder(rd).Decode(&r); err != nil {
+		return nil, fmt.Errorf("bench: decoding report: %w", err)
+	}
+	return &r, nil
+}
+
+// WriteMarkdown записывает результаты Markdown-таблицей
+func WriteMarkdown(w io.Writer, r *Report) error {
+	if _, err := fmt.Fprintf(w, "%s %s/%s, %d CPU, %s\n\n",
+		r.GoVersion, r.GOOS, r.GOARCH, r.NumCPU, r.Started.Format("2006-01-02 15:04:05 MST")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "| Cipher | API | Op | Mode | Padding | Workers | Size | ns/op | MB/s | allocs/op | B/op |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "|---|---|---|---|---|---:|---:|---:|---:|---:|---:|"); err != nil {
+		return err
+	}
+	for _, res := range r.Results {
+		var err error
+		if res.Err != "" {
+			_, err = fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | error: %s | | | |\n",
+				res.Cipher, res.API, res.Op, res.Mode, res.Padding, workersLabel(res.Workers), sizeLabel(res.Size), res.Err)
+		} else {
+			_, err = fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %d | %.2f | %d | %d |\n",
+				res.Cipher, res.API, res.Op, res.Mode, res.Padding, workersLabel(res.Workers), sizeLabel(res.Size),
+				res.NsPerOp, res.MBPerSec, res.AllocsPerOp, res.BytesPerOp)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Regression измерение, ставшее медленнее базового больше допустимого
+type Regression struct {
+	Key      string  `json:"key"`
+	BaseNs   int64   `json:"base_ns_per_op"`
+	Current  int64   `json:"current_ns_per_op"`
+	Slowdown float64 `json:"slowdown"` // относительное замедление: 0.25 означает +25% ко времени
+}
+
+// Compare сравнивает текущий отчёт с базовым и возвращает измерения,
+// время которых выросло больше чем на tolerance (доля, например 0.1 = 10%).
+func Compare(base, current *Report, tolerance float64) ([]Regression, error) {
+	baseByKey := make(map[string]Result, len(base.Results))
+	for _, r := range base.Results {
+		if r.Err == "" {
+			baseByKey[r.Key()] = r
+		}
+	}
+
+	common := 0
+	var regressions []Regression
+	for _, r := range current.Results {
+		b, ok := baseByKey[r.Key()]
+		if !ok || r.Err != "" || b.NsPerOp == 0 {
+			continue
+		}
+		common++
+		slowdown := float64(r.NsPerOp-b.NsPerOp) / float64(b.NsPerOp)
+		if slowdown > tolerance {
+			regressions = append(regressions, Regression{
+				Key:      r.Key(),
+				BaseNs:   b.NsPerOp,
+				Current:  r.NsPerOp,
+				Slowdown: slowdown,
+			})
+		}
+	}
+	if common == 0 {
+		return nil, ErrNoResults
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].Slowdown > regressions[j].Slowdown
+	})
+	return regressions, nil
+}
+
+func workersLabel(n int) string {
+	if n == 0 {
+		return "default"
+	}
+	return fmt.Sprint(n)
+}
+
+func sizeLabel(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKiB", n>>10)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// ParseSize разбирает размер вида "512", "64KiB" или "4MiB"
+func ParseSize(s string) (int, error) {
+	multiplier := 1
+	num := strings.TrimSpace(s)
+	for _, suffix := range []struct {
+		name  string
+		value int
+	}{{"MiB", 1 << 20}, {"KiB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(num, suffix.name) {
+			num = strings.TrimSuffix(num, suffix.name)
+			multiplier = suffix.value
+			break
+		}
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("bench: invalid size %q", s)
+	}
+	return n * multiplier, nil
+}
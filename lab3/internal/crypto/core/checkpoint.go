@@ -0,0 +1,326 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultCheckpointInterval через сколько порций (по 1 МБ) сохраняется контрольная точка
+const DefaultCheckpointInterval = 64
+
+const checkpointVersion = 1
+
+// ErrCheckpointMismatch возвращается, если контрольная точка не соответствует
+// входному файлу, частичному результату или параметрам контекста
+var ErrCheckpointMismatch = errors.New("checkpoint does not match the input, the partial output or the cipher context")
+
+// Checkpoint состояние прерываемого шифрования большого файла.
+// Хранится в JSON-файле рядом с результатом (см. CheckpointPath).
+//
+// Порции файла шифруются как независимые сообщения, поэтому для продолжения
+// достаточно номера следующей порции: её IV (счётчик CTR) выводится из номера.
+// Цепочечное состояние сохраняется, чтобы при возобновлении проверить, что
+// частичный результат и контекст те же, что были при записи контрольной точки.
+type Checkpoint struct {
+	Version      int       `json:"version"`
+	Mode         string    `json:"mode"`
+	Padding      string    `json:"padding"`
+	BlockSize    int       `json:"block_size"`
+	KeyCheck     string    `json:"key_check"`    // начало E_K(0...0): ключ при возобновлении должен совпасть
+	IV           string    `json:"iv,omitempty"` // фиксированный IV контекста; пусто, если IV случайный для каждой порции
+	InputSize    int64     `json:"input_size"`
+	InputModTime time.Time `json:"input_mtime"`
+	Interval     int       `json:"interval"`
+
+	NextChunk    int    `json:"next_chunk"`           // номер следующей порции
+	OutputOffset int64  `json:"output_offset"`        // длина записанного и сброшенного на диск начала результата
+	LastBlock    string `json:"last_block,omitempty"` // последний блок шифртекста перед OutputOffset
+	Counter      string `json:"counter,omitempty"`    // IV (счётчик CTR) следующей порции при фиксированном IV
+}
+
+// CheckpointPath путь файла контрольной точки для результата outPath
+func CheckpointPath(outPath string) string {
+	return outPath + ".ckpt"
+}
+
+// checkpointHook вызывается после записи каждой порции; тесты используют его,
+// чтобы прервать шифрование на границе порции
+var checkpointHook func(index int) error
+
+// EncryptFileWithCheckpoints шифрует файл как EncryptFile, но каждые interval порций
+// сохраняет контрольную точку, по которой ResumeEncryptFile продолжит работу после сбоя.
+// Результат побайтно совпадает с EncryptFile (при фиксированном IV и детерминированном паддинге).
+// После успешного завершения файл контрольной точки удаляется.
+func (ctx *CipherContext) EncryptFileWithCheckpoints(inPath, outPath string, interval int) error {
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	info, err := os.Stat(inPath)
+	if err != nil {
+		return err
+	}
+	// Маленький файл шифруется одним сообщением, прерывать там нечего
+	if info.Size() <= MaxInMemorySize {
+		return ctx.EncryptFile(inPath, outPath)
+	}
+	if err := ctx.checkFileCounter(info.Size()); err != nil {
+		return err
+	}
+
+	keyCheck, err := ctx.keyCheck()
+	if err != nil {
+		return err
+	}
+	cp := &Checkpoint{
+		Version:      checkpointVersion,
+		Mode:         ctx.mode.String(),
+		Padding:      ctx.padding.String(),
+		BlockSize:    ctx.blockSize,
+		KeyCheck:     keyCheck,
+		IV:           hex.EncodeToString(ctx.iv),
+		InputSize:    info.Size(),
+		InputModTime: info.ModTime().UTC(),
+		Interval:     interval,
+	}
+	ctx.advanceCheckpoint(cp, 0, 0, nil)
+
+	inFile, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	outFile, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	// Начальная точка: даже сбой до первого интервала можно продолжить
+	if err := writeCheckpoint(CheckpointPath(outPath), cp); err != nil {
+		return err
+	}
+	return ctx.runCheckpointed(inFile, outFile, outPath, cp)
+}
+
+// ResumeEncryptFile продолжает шифрование, прерванное во время EncryptFileWithCheckpoints.
+// Контекст должен быть создан с тем же шифром, ключом, режимом, паддингом и IV.
+// Перед продолжением проверяются входной файл и частичный результат; всё, что было
+// записано после контрольной точки, отбрасывается.
+func (ctx *CipherContext) ResumeEncryptFile(inPath, outPath string) error {
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
+	cpPath := CheckpointPath(outPath)
+	cp, err := ReadCheckpoint(cpPath)
+	if err != nil {
+		return err
+	}
+	if err := ctx.validateCheckpoint(cp, inPath); err != nil {
+		return err
+	}
+
+	outFile, err := os.OpenFile(outPath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	if err := validatePartialOutput(outFile, cp); err != nil {
+		return err
+	}
+	if err := outFile.Truncate(cp.OutputOffset); err != nil {
+		return err
+	}
+	if _, err := outFile.Seek(cp.OutputOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	inFile, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+	if _, err := inFile.Seek(int64(cp.NextChunk)*fileChunkSize, io.SeekStart); err != nil {
+		return err
+	}
+
+	return ctx.resumedContext(cp).runCheckpointed(inFile, outFile, outPath, cp)
+}
+
+// resumedContext возвращает копию контекста для продолжения файла с контрольной
+// точкой cp: порции начиная с cp.NextChunk могли быть зашифрованы до сбоя, и
+// повтор их nonce допускается (см. chunkContext)
+func (ctx *CipherContext) resumedContext(cp *Checkpoint) *CipherContext {
+	resumed := *ctx
+	resumed.resuming = true
+	resumed.resumeFrom = cp.NextChunk
+	return &resumed
+}
+
+// runCheckpointed шифрует остаток файла, обновляя контрольную точку каждые cp.Interval порций
+func (ctx *CipherContext) runCheckpointed(in io.Reader, out *os.File, outPath string, cp *Checkpoint) error {
+	cpPath := CheckpointPath(outPath)
+	offset := cp.OutputOffset
+
+	err := ctx.encryptChunks(in, out, cp.NextChunk, func(index int, data []byte) error {
+		offset += int64(len(data))
+		if (index+1)%cp.Interval == 0 {
+			// Сначала результат должен оказаться на диске, иначе точка опередит данные
+			if err := out.Sync(); err != nil {
+				return err
+			}
+			ctx.advanceCheckpoint(cp, index+1, offset, data)
+			if err := writeCheckpoint(cpPath, cp); err != nil {
+				return err
+			}
+		}
+		if checkpointHook != nil {
+			return checkpointHook(index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return os.Remove(cpPath)
+}
+
+// advanceCheckpoint переносит контрольную точку на начало порции next
+func (ctx *CipherContext) advanceCheckpoint(cp *Checkpoint, next int, offset int64, lastChunk []byte) {
+	cp.NextChunk = next
+	cp.OutputOffset = offset
+	cp.LastBlock = ""
+	if len(lastChunk) >= ctx.blockSize {
+		cp.LastBlock = hex.EncodeToString(lastChunk[len(lastChunk)-ctx.blockSize:])
+	}
+	cp.Counter = ""
+	if ctx.iv != nil && ctx.mode.usesIV() {
+		cp.Counter = hex.EncodeToString(ctx.chunkContext(next).iv)
+	}
+}
+
+// validateCheckpoint проверяет, что контрольная точка записана для этого входного файла и контекста
+func (ctx *CipherContext) validateCheckpoint(cp *Checkpoint, inPath string) error {
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d", cp.Version)
+	}
+	info, err := os.Stat(inPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() != cp.InputSize || !info.ModTime().Equal(cp.InputModTime) {
+		return fmt.Errorf("%w: input file has changed", ErrCheckpointMismatch)
+	}
+
+	keyCheck, err := ctx.keyCheck()
+	if err != nil {
+		return err
+	}
+	if cp.Mode != ctx.mode.String() || cp.Padding != ctx.padding.String() || cp.BlockSize != ctx.blockSize ||
+		cp.KeyCheck != keyCheck || cp.IV != hex.EncodeToString(ctx.iv) {
+		return fmt.Errorf("%w: cipher, key, mode, padding or IV differ", ErrCheckpointMismatch)
+	}
+
+	if cp.Interval <= 0 || cp.NextChunk < 0 || cp.OutputOffset < 0 ||
+		int64(cp.NextChunk)*fileChunkSize > cp.InputSize {
+		return fmt.Errorf("%w: malformed checkpoint", ErrCheckpointMismatch)
+	}
+	if ctx.iv != nil && ctx.mode.usesIV() && cp.Counter != hex.EncodeToString(ctx.chunkContext(cp.NextChunk).iv) {
+		return fmt.Errorf("%w: counter does not match chunk %d", ErrCheckpointMismatch, cp.NextChunk)
+	}
+	// Все порции до контрольной точки полные, поэтому длина результата известна заранее
+	if want := int64(cp.NextChunk) * int64(ctx.encryptedSize(fileChunkSize)); cp.OutputOffset != want {
+		return fmt.Errorf("%w: output offset %d, want %d", ErrCheckpointMismatch, cp.OutputOffset, want)
+	}
+	return nil
+}
+
+// validatePartialOutput проверяет, что частичный результат не короче контрольной точки
+// и заканчивается на сохранённом блоке шифртекста
+func validatePartialOutput(out *os.File, cp *Checkpoint) error {
+	info, err := out.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < cp.OutputOffset {
+		return fmt.Errorf("%w: partial output is %d bytes, checkpoint expects at least %d",
+			ErrCheckpointMismatch, info.Size(), cp.OutputOffset)
+	}
+	if cp.OutputOffset == 0 {
+		return nil
+	}
+
+	want, err := hex.DecodeString(cp.LastBlock)
+	if err != nil || len(want) != cp.BlockSize {
+		return fmt.Errorf("%w: malformed last block", ErrCheckpointMismatch)
+	}
+	got := make([]byte, len(want))
+	if _, err := out.ReadAt(got, cp.OutputOffset-int64(len(want))); err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("%w: partial output does not end with the checkpointed block", ErrCheckpointMismatch)
+	}
+	return nil
+}
+
+// keyCheck контрольное значение ключа: первые байты шифртекста нулевого блока
+func (ctx *CipherContext) keyCheck() (string, error) {
+	block, err := ctx.cipher.EncryptBlock(make([]byte, ctx.blockSize))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(block[:min(4, len(block))]), nil
+}
+
+// ReadCheckpoint читает файл контрольной точки
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCheckpointMismatch, err)
+	}
+	return &cp, nil
+}
+
+// writeCheckpoint атомарно записывает контрольную точку: через временный файл,
+// его fsync и переименование
+func writeCheckpoint(path string, cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -42,13 +44,26 @@ type CipherContext struct {
 	blockSize   int
 	iv          []byte // optional
 	modeOptions []interface{}
+	workers     int // число воркеров файлового конвейера, 0 — по числу CPU
+	guard       *NonceGuard
+	resuming    bool           // продолжение прерванного шифрования файла (см. ResumeEncryptFile)
+	resumeFrom  int            // первая порция, шифруемая при продолжении
+	fileChunk   int            // номер порции файла, которую шифрует контекст (см. chunkContext)
+	reencrypt   bool           // порция продолжаемого файла: её nonce мог быть отмечен до сбоя
+	counter     *CounterLayout // разбиение блока счётчика CTR, nil — по умолчанию
+	segment     int            // размер сегмента CFB/OFB в битах, 0 — блок
+	destroyed   bool           // ключ и IV стёрты (см. Destroy)
 }
 
-// NewCipherContext создаёт контекст. iv может быть nil для режимов ECB.
+// NewCipherContext создаёт контекст.
+// Если iv равен nil, для режимов с IV на каждое сообщение генерируется
+// случайный IV, который записывается перед шифртекстом (в ECB и RandomDelta IV не используется).
+// Среди opts можно передать *NonceGuard для защиты от повторного nonce в CTR, OFB и CFB
+// и CounterLayout для разбиения блока CTR на nonce и счётчик, SegmentSize — для CFB-s и OFB-s.
 func NewCipherContext(c SymmetricCipher, mode CipherMode, padding PaddingMode, iv []byte, opts ...interface{}) *CipherContext {
 	blockSize := c.BlockSize()
 
-	return &CipherContext{
+	ctx := &CipherContext{
 		cipher:      c,
 		mode:        mode,
 		padding:     padding,
@@ -56,6 +71,59 @@ func NewCipherContext(c SymmetricCipher, mode CipherMode, padding PaddingMode, i
 		iv:          iv,
 		modeOptions: opts,
 	}
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case *NonceGuard:
+			ctx.guard = opt
+		case CounterLayout:
+			ctx.counter = &opt
+		case SegmentSize:
+			ctx.segment = int(opt)
+		}
+	}
+	return ctx
+}
+
+// usesIV сообщает, нужен ли режиму IV/nonce
+func (m CipherMode) usesIV() bool {
+	return m != ECB && m != RandomDelta
+}
+
+// autoIV сообщает, генерирует ли контекст IV для каждого сообщения сам
+func (ctx *CipherContext) autoIV() bool {
+	return ctx.iv == nil && ctx.mode.usesIV()
+}
+
+// counterLayout возвращает разбиение блока счётчика CTR
+func (ctx *CipherContext) counterLayout() CounterLayout {
+	if ctx.counter != nil {
+		return *ctx.counter
+	}
+	return CounterLayout{}
+}
+
+// withIV возвращает копию контекста с другим IV
+func (ctx *CipherContext) withIV(iv []byte) *CipherContext {
+	c := *ctx
+	c.iv = iv
+	return &c
+}
+
+// SetWorkers задаёт число воркеров, параллельно обрабатывающих порции файла
+// в EncryptFile/DecryptFile. n <= 0 возвращает значение по умолчанию (runtime.NumCPU()).
+func (ctx *CipherContext) SetWorkers(n int) {
+	if n < 0 {
+		n = 0
+	}
+	ctx.workers = n
+}
+
+// Workers возвращает фактическое число воркеров файлового конвейера
+func (ctx *CipherContext) Workers() int {
+	if ctx.workers > 0 {
+		return ctx.workers
+	}
+	return runtime.NumCPU()
 }
 
 // --- Padding helpers ---
@@ -128,11 +196,47 @@ func xorBytes(a, b []byte) []byte {
 	return out
 }
 
+// minParallelBlocks минимальное число блоков на горутину: на более коротких
+// диапазонах накладные расходы на запуск горутин съедают выигрыш
+const minParallelBlocks = 32
+
+// parallelBlocks делит блоки [0, n) на непрерывные диапазоны и обрабатывает их
+// в ctx.Workers() горутинах. При одном воркере или коротком сообщении fn
+// вызывается один раз в текущей горутине. Возвращает первую ошибку по порядку диапазонов.
+func (ctx *CipherContext) parallelBlocks(n int, fn func(from, to int) error) error {
+	workers := min(ctx.Workers(), (n+minParallelBlocks-1)/minParallelBlocks)
+	if workers <= 1 {
+		return fn(0, n)
+	}
+
+	per := (n + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		from, to := w*per, min((w+1)*per, n)
+		if from >= to {
+			break
+		}
+		wg.Add(1)
+		go func(w, from, to int) {
+			defer wg.Done()
+			errs[w] = fn(from, to)
+		}(w, from, to)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // --- High-level Encrypt/Decrypt ---
 
 func (ctx *CipherContext) Encrypt(plaintext []byte) ([]byte, error) {
-	if ctx.cipher == nil {
-		return nil, errors.New("cipher not set")
+	if err := ctx.checkUsable(); err != nil {
+		return nil, err
 	}
 
 	// Канал для результата padding
@@ -143,7 +247,7 @@ func (ctx *CipherContext) Encrypt(plaintext []byte) ([]byte, error) {
 
 	// Асинхронно применяем padding
 	go func() {
-		padded, err := applyPadding(plaintext, ctx.blockSize, ctx.padding)
+		padded, err := applyPadding(plaintext, ctx.padUnit(), ctx.padding)
 		paddingCh <- struct {
 			data []byte
 			err  error
@@ -164,27 +268,7 @@ func (ctx *CipherContext) Encrypt(plaintext []byte) ([]byte, error) {
 
 	// Асинхронно шифруем
 	go func() {
-		var result []byte
-		var err error
-
-		switch ctx.mode {
-		case ECB:
-			result, err = ctx.encryptECB(paddingResult.data)
-		case CBC:
-			result, err = ctx.encryptCBC(paddingResult.data)
-		case PCBC:
-			result, err = ctx.encryptPCBC(paddingResult.data)
-		case CFB:
-			result, err = ctx.encryptCFB(paddingResult.data)
-		case OFB:
-			result, err = ctx.encryptOFB(paddingResult.data)
-		case CTR:
-			result, err = ctx.encryptCTR(paddingResult.data)
-		case RandomDelta:
-			result, err = ctx.encryptRandomDelta(paddingResult.data)
-		default:
-			err = errors.New("unsupported mode")
-		}
+		result, err := ctx.encryptMessage(paddingResult.data)
 
 		encryptCh <- struct {
 			data []byte
@@ -197,11 +281,117 @@ func (ctx *CipherContext) Encrypt(plaintext []byte) ([]byte, error) {
 	return encryptResult.data, encryptResult.err
 }
 
+// encryptMessage шифрует дополненное сообщение: при необходимости генерирует IV,
+// сверяет nonce с NonceGuard и дописывает сгенерированный IV перед шифртекстом
+func (ctx *CipherContext) encryptMessage(padded []byte) ([]byte, error) {
+	active := ctx
+	var prefix []byte
+	if ctx.autoIV() {
+		iv := make([]byte, ctx.blockSize)
+		if _, err := rand.Read(iv); err != nil {
+			return nil, err
+		}
+		if ctx.mode == CTR && ctx.counter != nil {
+			ctx.counter.startCounter(iv)
+		}
+		prefix = iv
+		active = ctx.withIV(iv)
+	}
+
+	if ctx.guard != nil && isStreamMode(ctx.mode) {
+		if len(active.iv) != ctx.blockSize {
+			return nil, fmt.Errorf("%s requires IV of block size", ctx.mode)
+		}
+		// При возобновлении файла порции после контрольной точки повторно шифруют
+		// те же данные под теми же счётчиками: шифртекст совпадает, утечки нет.
+		// Повтор допускается только для IV этих порций, а не для любого nonce.
+		if err := ctx.useNonce(active.iv); err != nil && !(ctx.reencrypt && errors.Is(err, ErrNonceReuse)) {
+			return nil, err
+		}
+	}
+
+	result, err := active.encryptMode(padded)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != nil {
+		result = append(prefix, result...)
+	}
+	return result, nil
+}
+
+// useNonce отмечает IV сообщения в NonceGuard. Если CounterLayout делит блок
+// на nonce и счётчик, отмечается только nonce: сообщения N‖0 и N‖5 разделяли
+// бы блоки гаммы 5, 6, …, поэтому второй раз тот же nonce не принимается ни
+// с каким начальным счётчиком. Порции файла после первой шифруются под nonce
+// порции 0 с непересекающимися счётчиками (см. chunkContext) и не отмечаются.
+// Без разбиения (в том числе FullBlockCounter) отмечается весь IV.
+func (ctx *CipherContext) useNonce(iv []byte) error {
+	if ctx.mode != CTR || ctx.counter == nil {
+		return ctx.guard.Use(iv)
+	}
+	start, width, err := ctx.counter.field(len(iv))
+	if err != nil {
+		return err
+	}
+	if width == len(iv) {
+		return ctx.guard.Use(iv)
+	}
+	if ctx.fileChunk > 0 {
+		return nil
+	}
+	nonce := append([]byte{}, iv...)
+	clear(nonce[start : start+width])
+	return ctx.guard.Use(nonce)
+}
+
+func (ctx *CipherContext) encryptMode(padded []byte) ([]byte, error) {
+	switch ctx.mode {
+	case ECB:
+		return ctx.encryptECB(padded)
+	case CBC:
+		return ctx.encryptCBC(padded)
+	case PCBC:
+		return ctx.encryptPCBC(padded)
+	case CFB:
+		return ctx.encryptCFB(padded)
+	case OFB:
+		return ctx.encryptOFB(padded)
+	case CTR:
+		return ctx.encryptCTR(padded)
+	case RandomDelta:
+		return ctx.encryptRandomDelta(padded)
+	default:
+		return nil, errors.New("unsupported mode")
+	}
+}
+
+func (ctx *CipherContext) decryptMode(ciphertext []byte) ([]byte, error) {
+	switch ctx.mode {
+	case ECB:
+		return ctx.decryptECB(ciphertext)
+	case CBC:
+		return ctx.decryptCBC(ciphertext)
+	case PCBC:
+		return ctx.decryptPCBC(ciphertext)
+	case CFB:
+		return ctx.decryptCFB(ciphertext)
+	case OFB:
+		return ctx.decryptOFB(ciphertext)
+	case CTR:
+		return ctx.decryptCTR(ciphertext)
+	case RandomDelta:
+		return ctx.decryptRandomDelta(ciphertext)
+	default:
+		return nil, errors.New("unsupported mode")
+	}
+}
+
 func (ctx *CipherContext) Decrypt(ciphertext []byte) ([]byte, error) {
-	if ctx.cipher == nil {
-		return nil, errors.New("cipher not set")
+	if err := ctx.checkUsable(); err != nil {
+		return nil, err
 	}
-	if len(ciphertext)%ctx.blockSize != 0 {
+	if len(ciphertext)%ctx.padUnit() != 0 {
 		return nil, errors.New("ciphertext not multiple of block size")
 	}
 
@@ -216,23 +406,15 @@ func (ctx *CipherContext) Decrypt(ciphertext []byte) ([]byte, error) {
 		var plain []byte
 		var err error
 
-		switch ctx.mode {
-		case ECB:
-			plain, err = ctx.decryptECB(ciphertext)
-		case CBC:
-			plain, err = ctx.decryptCBC(ciphertext)
-		case PCBC:
-			plain, err = ctx.decryptPCBC(ciphertext)
-		case CFB:
-			plain, err = ctx.decryptCFB(ciphertext)
-		case OFB:
-			plain, err = ctx.decryptOFB(ciphertext)
-		case CTR:
-			plain, err = ctx.decryptCTR(ciphertext)
-		case RandomDelta:
-			plain, err = ctx.decryptRandomDelta(ciphertext)
-		default:
-			err = errors.New("unsupported mode")
+		if ctx.autoIV() {
+			// IV был сгенерирован при шифровании и записан первым блоком
+			if len(ciphertext) < ctx.blockSize {
+				err = errors.New("ciphertext too short to contain IV")
+			} else {
+				plain, err = ctx.withIV(ciphertext[:ctx.blockSize]).decryptMode(ciphertext[ctx.blockSize:])
+			}
+		} else {
+			plain, err = ctx.decryptMode(ciphertext)
 		}
 
 		decryptCh <- struct {
@@ -255,7 +437,7 @@ func (ctx *CipherContext) Decrypt(ciphertext []byte) ([]byte, error) {
 
 	// Асинхронно удаляем padding
 	go func() {
-		unpadded, err := removePadding(decryptResult.data, ctx.blockSize, ctx.padding)
+		unpadded, err := removePadding(decryptResult.data, ctx.padUnit(), ctx.padding)
 		unpaddingCh <- struct {
 			data []byte
 			err  error
@@ -338,56 +520,38 @@ func (ctx *CipherContext) decryptCBC(ciphertext []byte) ([]byte, error) {
 		return nil, errors.New("CBC requires IV of block size")
 	}
 
-	blocksCount := len(ciphertext) / ctx.blockSize
+	// P_i = D(C_i) xor C_{i-1}: все блоки шифртекста известны заранее, поэтому диапазоны независимы
+	bs := ctx.blockSize
 	out := make([]byte, len(ciphertext))
-	decrypted := make([][]byte, blocksCount)
-
-	type result struct {
-		index int
-		data  []byte
-		err   error
-	}
-
-	results := make(chan result, blocksCount)
-
-	for i := 0; i < blocksCount; i++ {
-		go func(blockIndex int) {
-			start := blockIndex * ctx.blockSize
-			block := ciphertext[start : start+ctx.blockSize]
-
-			d, err := ctx.cipher.DecryptBlock(block)
-			results <- result{blockIndex, d, err}
-		}(i)
-	}
-
-	for i := 0; i < blocksCount; i++ {
-		res := <-results
-		if res.err != nil {
-			return nil, res.err
+	err := ctx.parallelBlocks(len(ciphertext)/bs, func(from, to int) error {
+		for i := from; i < to; i++ {
+			start := i * bs
+			d, err := ctx.cipher.DecryptBlock(ciphertext[start : start+bs])
+			if err != nil {
+				return err
+			}
+			prev := ctx.iv
+			if i > 0 {
+				prev = ciphertext[start-bs : start]
+			}
+			copy(out[start:], xorBytes(d, prev))
 		}
-		decrypted[res.index] = res.data
-	}
-
-	prev := make([]byte, ctx.blockSize)
-	copy(prev, ctx.iv)
-
-	for i := 0; i < blocksCount; i++ {
-		start := i * ctx.blockSize
-		block := ciphertext[start : start+ctx.blockSize]
-
-		plain := xorBytes(decrypted[i], prev)
-		copy(out[start:], plain)
-		copy(prev, block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return out, nil
 }
 
-// CFB
+// CFB (параллельная расшифровка)
 func (ctx *CipherContext) encryptCFB(padded []byte) ([]byte, error) {
 	if ctx.iv == nil || len(ctx.iv) != ctx.blockSize {
 		return nil, errors.New("CFB requires IV of block size")
 	}
+	if ctx.shortSegments() {
+		return ctx.feedbackSegments(padded, false)
+	}
 	out := make([]byte, len(padded))
 	feedback := append([]byte{}, ctx.iv...)
 
@@ -408,18 +572,30 @@ func (ctx *CipherContext) decryptCFB(ciphertext []byte) ([]byte, error) {
 	if ctx.iv == nil || len(ctx.iv) != ctx.blockSize {
 		return nil, errors.New("CFB requires IV of block size")
 	}
-	out := make([]byte, len(ciphertext))
-	feedback := append([]byte{}, ctx.iv...)
+	if ctx.shortSegments() {
+		return ctx.feedbackSegments(ciphertext, true)
+	}
 
-	for i := 0; i < len(ciphertext); i += ctx.blockSize {
-		stream, err := ctx.cipher.EncryptBlock(feedback)
-		if err != nil {
-			return nil, err
+	// P_i = C_i xor E(C_{i-1}): как и в CBC, обратная связь берётся из известного шифртекста
+	bs := ctx.blockSize
+	out := make([]byte, len(ciphertext))
+	err := ctx.parallelBlocks(len(ciphertext)/bs, func(from, to int) error {
+		for i := from; i < to; i++ {
+			start := i * bs
+			feedback := ctx.iv
+			if i > 0 {
+				feedback = ciphertext[start-bs : start]
+			}
+			stream, err := ctx.cipher.EncryptBlock(feedback)
+			if err != nil {
+				return err
+			}
+			copy(out[start:], xorBytes(ciphertext[start:start+bs], stream))
 		}
-		block := ciphertext[i : i+ctx.blockSize]
-		plain := xorBytes(block, stream)
-		copy(out[i:], plain)
-		feedback = block
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return out, nil
 }
@@ -429,6 +605,9 @@ func (ctx *CipherContext) encryptOFB(padded []byte) ([]byte, error) {
 	if ctx.iv == nil || len(ctx.iv) != ctx.blockSize {
 		return nil, errors.New("OFB requires IV of block size")
 	}
+	if ctx.shortSegments() {
+		return ctx.feedbackSegments(padded, false)
+	}
 	out := make([]byte, len(padded))
 	feedback := append([]byte{}, ctx.iv...)
 
@@ -454,28 +633,42 @@ func (ctx *CipherContext) encryptCTR(padded []byte) ([]byte, error) {
 	if ctx.iv == nil || len(ctx.iv) != ctx.blockSize {
 		return nil, errors.New("CTR requires nonce/IV of block size")
 	}
-	n := len(padded) / ctx.blockSize
-	out := make([]byte, len(padded))
-	var wg sync.WaitGroup
-	wg.Add(n)
+	layout := ctx.counterLayout()
+	if _, _, err := layout.field(ctx.blockSize); err != nil {
+		return nil, err
+	}
+	bs := ctx.blockSize
+	n := len(padded) / bs
+	if layout.overflows(ctx.iv, uint64(n)) {
+		return nil, ErrCounterOverflow
+	}
 
-	for i := 0; i < n; i++ {
-		go func(i int) {
-			defer wg.Done()
-			// Каждый блок имеет свой собственный счетчик
-			counter := make([]byte, ctx.blockSize)
-			copy(counter, ctx.iv)
-			addUint64ToBE(counter, uint64(i))
+	// Блоки счётчика вычисляются заранее последовательным инкрементом,
+	// после чего гамма для диапазонов блоков считается независимо
+	counters := make([]byte, n*bs)
+	if n > 0 {
+		copy(counters, ctx.iv)
+	}
+	for i := 1; i < n; i++ {
+		counter := counters[i*bs : (i+1)*bs]
+		copy(counter, counters[(i-1)*bs:i*bs])
+		layout.add(counter, 1)
+	}
 
-			keystream, err := ctx.cipher.EncryptBlock(counter)
-			if err == nil {
-				block := padded[i*ctx.blockSize : (i+1)*ctx.blockSize]
-				res := xorBytes(block, keystream)
-				copy(out[i*ctx.blockSize:], res)
+	out := make([]byte, len(padded))
+	err := ctx.parallelBlocks(n, func(from, to int) error {
+		for i := from; i < to; i++ {
+			keystream, err := ctx.cipher.EncryptBlock(counters[i*bs : (i+1)*bs])
+			if err != nil {
+				return err
 			}
-		}(i)
+			copy(out[i*bs:], xorBytes(padded[i*bs:(i+1)*bs], keystream))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	wg.Wait()
 	return out, nil
 }
 
@@ -588,6 +781,10 @@ func (ctx *CipherContext) decryptRandomDelta(ciphertext []byte) ([]byte, error)
 	return out, nil
 }
 
+// ErrCounterOverflow возвращается, если счётчик CTR переполнился бы
+// и начал повторять уже использованные значения
+var ErrCounterOverflow = errors.New("CTR counter overflow: message too long for this nonce")
+
 // --- Utils ---
 func addUint64ToBE(buf []byte, v uint64) {
 	if len(buf) < 8 {
@@ -595,7 +792,7 @@ func addUint64ToBE(buf []byte, v uint64) {
 		for i := len(buf) - 1; i >= 0 && carry > 0; i-- {
 			sum := uint64(buf[i]) + (carry & 0xFF)
 			buf[i] = byte(sum & 0xFF)
-			carry = sum >> 8
+			carry = carry>>8 + sum>>8
 		}
 		return
 	}
@@ -607,6 +804,81 @@ func addUint64ToBE(buf []byte, v uint64) {
 // MaxInMemorySize максимальный размер файла для загрузки в память (10 МБ)
 const MaxInMemorySize = 10 * 1024 * 1024
 
+// fileChunkSize размер порции открытого текста в потоковой обработке файлов
+const fileChunkSize = 1024 * 1024
+
+// paddedSize длина открытого текста из n байт после паддинга
+func (ctx *CipherContext) paddedSize(n int) int {
+	unit := ctx.padUnit()
+	if ctx.padding == PadZeros {
+		return (n + unit - 1) / unit * unit
+	}
+	return (n/unit + 1) * unit
+}
+
+// encryptedSize длина шифртекста сообщения из n байт, включая записанный перед ним IV или delta
+func (ctx *CipherContext) encryptedSize(n int) int {
+	return ctx.paddedSize(n) + ctx.PrefixSize()
+}
+
+// PrefixSize число байт, которые Encrypt записывает перед шифртекстом:
+// сгенерированный IV или delta режима RandomDelta, иначе 0
+func (ctx *CipherContext) PrefixSize() int {
+	if ctx.autoIV() || ctx.mode == RandomDelta {
+		return ctx.blockSize
+	}
+	return 0
+}
+
+// chunkContext возвращает контекст для порции файла с номером index.
+// При фиксированном IV каждая порция получает собственный IV = IV + index*stride,
+// где stride — число блоков в порции, поэтому счётчики CTR порций не пересекаются.
+// Раньше все порции шифровались одним и тем же IV, так что большие файлы,
+// зашифрованные с фиксированным IV прежними версиями, этим кодом не читаются
+// (см. EncryptFile).
+// При продолжении файла порции с номером не меньше resumeFrom помечаются
+// reencrypt: NonceGuard пропускает повтор только их IV.
+func (ctx *CipherContext) chunkContext(index int) *CipherContext {
+	if ctx.iv == nil || !ctx.mode.usesIV() {
+		return ctx
+	}
+	reencrypt := ctx.resuming && index >= ctx.resumeFrom
+	if index == 0 && !reencrypt {
+		return ctx
+	}
+	iv := append([]byte{}, ctx.iv...)
+	if ctx.mode == CTR {
+		ctx.counterLayout().add(iv, uint64(index)*ctx.chunkStride())
+	} else {
+		addUint64ToBE(iv, uint64(index)*ctx.chunkStride())
+	}
+	chunk := ctx.withIV(iv)
+	chunk.fileChunk = index
+	chunk.reencrypt = reencrypt
+	return chunk
+}
+
+// chunkStride число блоков шифртекста в полной порции файла
+func (ctx *CipherContext) chunkStride() uint64 {
+	return uint64(ctx.paddedSize(fileChunkSize) / ctx.blockSize)
+}
+
+// checkFileCounter проверяет, что счётчик CTR не переполнится на файле размера size
+func (ctx *CipherContext) checkFileCounter(size int64) error {
+	if ctx.mode != CTR || ctx.iv == nil {
+		return nil
+	}
+	layout := ctx.counterLayout()
+	if _, _, err := layout.field(ctx.blockSize); err != nil {
+		return err
+	}
+	chunks := uint64((size + fileChunkSize - 1) / fileChunkSize)
+	if chunks > 0 && layout.overflows(ctx.iv, chunks*ctx.chunkStride()) {
+		return ErrCounterOverflow
+	}
+	return nil
+}
+
 // --- Файловые операции ---
 // func (ctx *CipherContext) EncryptFile(inPath, outPath string) error {
 
@@ -722,6 +994,7 @@ const MaxInMemorySize = 10 * 1024 * 1024
 type bufferTask struct {
 	data  []byte
 	index int
+	last  bool // последняя порция потока (используется форматом STREAM)
 }
 
 type bufferResult struct {
@@ -730,7 +1003,16 @@ type bufferResult struct {
 	err   error
 }
 
+// EncryptFile шифрует файл. Файлы до MaxInMemorySize шифруются одним сообщением,
+// большие — независимыми порциями по fileChunkSize, записанными подряд.
+// Формат больших файлов с фиксированным IV изменился: порция i шифруется с
+// IV + i*stride (см. chunkContext), а не с общим IV, как раньше. Маркера версии
+// в файле нет, поэтому такие файлы, зашифрованные прежними версиями, нужно
+// расшифровать прежней версией. ECB и RandomDelta изменение не затрагивает.
 func (ctx *CipherContext) EncryptFile(inPath, outPath string) error {
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
 	info, err := os.Stat(inPath)
 	if err != nil {
 		return err
@@ -744,100 +1026,154 @@ func (ctx *CipherContext) EncryptFile(inPath, outPath string) error {
 		}
 		defer inFile.Close()
 
+		if err := ctx.checkFileCounter(info.Size()); err != nil {
+			return err
+		}
+
 		outFile, err := os.Create(outPath)
 		if err != nil {
 			return err
 		}
 		defer outFile.Close()
 
-		numWorkers := runtime.NumCPU()
-		//numWorkers := 1
-		tasks := make(chan bufferTask, numWorkers*2)
-		results := make(chan bufferResult, numWorkers*2)
-
-		var wg sync.WaitGroup
-		for i := 0; i < numWorkers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for task := range tasks {
-					encrypted, err := ctx.Encrypt(task.data)
-					results <- bufferResult{
-						data:  encrypted,
-						index: task.index,
-						err:   err,
-					}
-				}
-			}()
-		}
+		return ctx.encryptChunks(inFile, outFile, 0, nil)
+	}
 
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+	// Для маленьких файлов загружаем в память
+	in, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	out, err := ctx.Encrypt(in)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, out, 0644)
+}
 
+// encryptChunks шифрует поток порциями по fileChunkSize параллельно и пишет
+// результаты в out по порядку. Нумерация порций начинается с first, чтобы
+// продолжить прерванное шифрование; written, если задан, вызывается после записи каждой порции.
+func (ctx *CipherContext) encryptChunks(in io.Reader, out io.Writer, first int, written func(index int, data []byte) error) error {
+	return ctx.chunkPipeline(in, out, fileChunkSize, first, func(task bufferTask) ([]byte, error) {
+		return ctx.chunkContext(task.index).Encrypt(task.data)
+	}, written)
+}
+
+// chunkPipeline читает in порциями по size байт, обрабатывает их process в
+// ctx.Workers() воркерах и пишет результаты в out по порядку. На первой ошибке
+// чтение и обработка остальных порций прекращаются.
+func (ctx *CipherContext) chunkPipeline(in io.Reader, out io.Writer, size, first int, process func(bufferTask) ([]byte, error), written func(index int, data []byte) error) error {
+	numWorkers := ctx.Workers()
+	tasks := make(chan bufferTask, numWorkers*2)
+	results := make(chan bufferResult, numWorkers*2)
+	// done закрывается при выходе, чтобы горутины не зависли после ошибки;
+	// воркеров дожидаемся, так как вызывающий может затем стереть ключ
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
 		go func() {
-			defer close(tasks)
-			buffer := make([]byte, 1024*1024) // 1MB буфер
-			index := 0
+			defer wg.Done()
 			for {
-				n, readErr := inFile.Read(buffer)
-				if n > 0 {
-					// Копируем данные, т.к. буфер переиспользуется
-					data := make([]byte, n)
-					copy(data, buffer[:n])
-					tasks <- bufferTask{data: data, index: index}
-					index++
-				}
-				if readErr == io.EOF {
-					break
+				var task bufferTask
+				select {
+				case t, ok := <-tasks:
+					if !ok {
+						return
+					}
+					task = t
+				case <-done:
+					return
 				}
-				if readErr != nil {
-					results <- bufferResult{err: readErr, index: -1}
-					break
+				data, err := process(task)
+				select {
+				case results <- bufferResult{data: data, index: task.index, err: err}:
+				case <-done:
+					return
 				}
 			}
 		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-		resultMap := make(map[int][]byte)
-		nextIndex := 0
-		for result := range results {
-			if result.err != nil {
-				return result.err
+	go func() {
+		defer close(tasks)
+		buffer := make([]byte, size)
+		index := first
+		for {
+			// Порции читаются целиком: от их границ зависят IV и разбиение при расшифровании
+			n, readErr := io.ReadFull(in, buffer)
+			if n > 0 {
+				// Копируем данные, т.к. буфер переиспользуется
+				data := make([]byte, n)
+				copy(data, buffer[:n])
+				select {
+				case tasks <- bufferTask{data: data, index: index}:
+				case <-done:
+					return
+				}
+				index++
+			}
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				break
+			}
+			if readErr != nil {
+				select {
+				case results <- bufferResult{err: readErr, index: -1}:
+				case <-done:
+				}
+				break
 			}
+		}
+	}()
+
+	resultMap := make(map[int][]byte)
+	nextIndex := first
+	for result := range results {
+		if result.err != nil {
+			return result.err
+		}
 
-			resultMap[result.index] = result.data
+		resultMap[result.index] = result.data
 
-			// Записываем все последовательные результаты
-			for {
-				if data, ok := resultMap[nextIndex]; ok {
-					if _, writeErr := outFile.Write(data); writeErr != nil {
-						return writeErr
-					}
-					delete(resultMap, nextIndex)
-					nextIndex++
-				} else {
-					break
+		// Записываем все последовательные результаты
+		for {
+			data, ok := resultMap[nextIndex]
+			if !ok {
+				break
+			}
+			if _, writeErr := out.Write(data); writeErr != nil {
+				return writeErr
+			}
+			delete(resultMap, nextIndex)
+			if written != nil {
+				if err := written(nextIndex, data); err != nil {
+					return err
 				}
 			}
+			nextIndex++
 		}
-
-		return nil
 	}
 
-	// Для маленьких файлов загружаем в память
-	in, err := os.ReadFile(inPath)
-	if err != nil {
-		return err
-	}
-	out, err := ctx.Encrypt(in)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(outPath, out, 0644)
+	return nil
 }
 
+// DecryptFile расшифровывает файл, записанный EncryptFile; о совместимости
+// больших файлов с фиксированным IV см. EncryptFile
 func (ctx *CipherContext) DecryptFile(inPath, outPath string) error {
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
 	info, err := os.Stat(inPath)
 	if err != nil {
 		return err
@@ -857,84 +1193,10 @@ func (ctx *CipherContext) DecryptFile(inPath, outPath string) error {
 		}
 		defer outFile.Close()
 
-		// Количество воркеров = количество CPU
-		numWorkers := runtime.NumCPU()
-		tasks := make(chan bufferTask, numWorkers*2)
-		results := make(chan bufferResult, numWorkers*2)
-
-		// Запускаем воркеры для дешифрования
-		var wg sync.WaitGroup
-		for i := 0; i < numWorkers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for task := range tasks {
-					decrypted, err := ctx.Decrypt(task.data)
-					results <- bufferResult{
-						data:  decrypted,
-						index: task.index,
-						err:   err,
-					}
-				}
-			}()
-		}
-
-		// Горутина для закрытия results после завершения всех воркеров
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
-
-		// Горутина для чтения файла и отправки задач
-		go func() {
-			defer close(tasks)
-			buffer := make([]byte, 1024*1024+ctx.blockSize) // 1MB + размер блока
-			index := 0
-			for {
-				n, readErr := inFile.Read(buffer)
-				if n > 0 {
-					// Копируем данные, т.к. буфер переиспользуется
-					data := make([]byte, n)
-					copy(data, buffer[:n])
-					tasks <- bufferTask{data: data, index: index}
-					index++
-				}
-				if readErr == io.EOF {
-					break
-				}
-				if readErr != nil {
-					// Отправляем ошибку через результат
-					results <- bufferResult{err: readErr, index: -1}
-					break
-				}
-			}
-		}()
-
-		// Собираем результаты в правильном порядке
-		resultMap := make(map[int][]byte)
-		nextIndex := 0
-		for result := range results {
-			if result.err != nil {
-				return result.err
-			}
-
-			resultMap[result.index] = result.data
-
-			// Записываем все последовательные результаты
-			for {
-				if data, ok := resultMap[nextIndex]; ok {
-					if _, writeErr := outFile.Write(data); writeErr != nil {
-						return writeErr
-					}
-					delete(resultMap, nextIndex)
-					nextIndex++
-				} else {
-					break
-				}
-			}
-		}
-
-		return nil
+		// Размер зашифрованной порции: 1MB после паддинга плюс IV или delta, если они есть
+		return ctx.chunkPipeline(inFile, outFile, ctx.encryptedSize(fileChunkSize), 0, func(task bufferTask) ([]byte, error) {
+			return ctx.chunkContext(task.index).Decrypt(task.data)
+		}, nil)
 	}
 
 	// Для маленьких файлов загружаем в память
@@ -1047,3 +1309,25 @@ func (p PaddingMode) String() string {
 		return "Unknown"
 	}
 }
+
+// ParseCipherMode возвращает режим шифрования по названию (без учёта регистра)
+func ParseCipherMode(name string) (CipherMode, error) {
+	for m := ECB; m <= RandomDelta; m++ {
+		if strings.EqualFold(m.String(), name) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher mode %q", name)
+}
+
+// ParsePaddingMode возвращает режим паддинга по названию (без учёта регистра).
+// Префикс "Pad" можно опускать: "PKCS7" и "PadPKCS7" эквивалентны.
+func ParsePaddingMode(name string) (PaddingMode, error) {
+	for p := PadZeros; p <= PadISO10126; p++ {
+		full := p.String()
+		if strings.EqualFold(full, name) || strings.EqualFold(strings.TrimPrefix(full, "Pad"), name) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown padding mode %q", name)
+}
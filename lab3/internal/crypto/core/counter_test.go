@@ -0,0 +1,75 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+)
+
+// Тест: раскладки счётчика CTR для блоков Rijndael 128, 192 и 256 бит
+func TestCTRCounterLayoutRijndael(t *testing.T) {
+	layouts := []struct {
+		name   string
+		layout core.CounterLayout
+		// поле счётчика: смещение от начала (>= 0) или от конца блока (< 0), ширина
+		offset, width int
+		little        bool
+	}{
+		{"32-bit big-endian", core.Counter32, -4, 4, false},
+		{"64-bit big-endian", core.Counter64, -8, 8, false},
+		{"64-bit little-endian", core.Counter64LE, -8, 8, true},
+		{"32-bit little-endian first", core.CounterLayout{CounterBits: 32, LittleEndian: true, CounterFirst: true}, 0, 4, true},
+	}
+
+	for _, blockSize := range []int{16, 24, 32} {
+		r := newRijndael(t, blockSize)
+		for _, l := range layouts {
+			// IV: nonce 0xA5 и счётчик с двумя оставшимися значениями
+			iv := bytes.Repeat([]byte{0xA5}, blockSize)
+			start := l.offset
+			if start < 0 {
+				start += blockSize
+			}
+			field := iv[start : start+l.width]
+			for i := range field {
+				field[i] = 0xFF
+			}
+			low := l.width - 1
+			if l.little {
+				low = 0
+			}
+			field[low] = 0xFE
+			next := bytes.Clone(iv)
+			next[start+low] = 0xFF
+
+			ctx := core.NewCipherContext(r, core.CTR, core.PadZeros, iv, l.layout)
+			stream, err := ctx.Encrypt(make([]byte, 2*blockSize))
+			if err != nil {
+				t.Fatalf("блок %d, %s: два блока должны поместиться: %v", blockSize, l.name, err)
+			}
+			first, _ := r.EncryptBlock(iv)
+			second, _ := r.EncryptBlock(next)
+			if !bytes.Equal(stream, append(first, second...)) {
+				t.Errorf("блок %d, %s: неверная гамма, счётчик вышел за своё поле", blockSize, l.name)
+			}
+			if _, err := ctx.Encrypt(make([]byte, 2*blockSize+1)); !errors.Is(err, core.ErrCounterOverflow) {
+				t.Errorf("блок %d, %s: ожидалась ErrCounterOverflow, получено %v", blockSize, l.name, err)
+			}
+
+			// Сгенерированный IV обнуляет только поле счётчика
+			auto := core.NewCipherContext(r, core.CTR, core.PadPKCS7, nil, l.layout)
+			sealed, err := auto.Encrypt([]byte("counter layout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(sealed[start:start+l.width], make([]byte, l.width)) {
+				t.Errorf("блок %d, %s: счётчик сгенерированного IV не начинается с нуля", blockSize, l.name)
+			}
+			if plain, err := auto.Decrypt(sealed); err != nil || string(plain) != "counter layout" {
+				t.Errorf("блок %d, %s: расшифрование не удалось: %v", blockSize, l.name, err)
+			}
+		}
+	}
+}
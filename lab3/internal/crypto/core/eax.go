@@ -0,0 +1,123 @@
+package core
+
+import "crypto/subtle"
+
+// EAX режим аутентифицированного шифрования с присоединёнными данными
+// (Bellare, Rogaway, Wagner): CTR для шифрования и OMAC (CMAC) для аутентификации.
+// Не требует обратного преобразования шифра и допускает nonce любой длины.
+type EAX struct {
+	cipher SymmetricCipher
+	mac    *CMAC
+}
+
+// NewEAX создаёт EAX для шифра c с уже установленным ключом шифрования.
+// Тег имеет длину блока шифра.
+func NewEAX(c SymmetricCipher) (*EAX, error) {
+	mac, err := NewCMAC(c)
+	if err != nil {
+		return nil, err
+	}
+	return &EAX{cipher: c, mac: mac}, nil
+}
+
+// Overhead длина тега, добавляемого к шифртексту
+func (e *EAX) Overhead() int {
+	return e.mac.Size()
+}
+
+// Destroy стирает подключи CMAC; ключ самого шифра не затрагивается
+func (e *EAX) Destroy() {
+	e.mac.Destroy()
+}
+
+// omac OMAC^t(data) = CMAC([t]_n || data)
+func (e *EAX) omac(t byte, data []byte) ([]byte, error) {
+	prefix := make([]byte, e.mac.Size())
+	prefix[len(prefix)-1] = t
+	return e.mac.MAC(prefix, data)
+}
+
+// Seal шифрует plaintext и возвращает шифртекст с тегом в конце
+func (e *EAX) Seal(nonce, plaintext, ad []byte) ([]byte, error) {
+	n, err := e.omac(0, nonce)
+	if err != nil {
+		return nil, err
+	}
+	h, err := e.omac(1, ad)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(plaintext), len(plaintext)+e.Overhead())
+	if err := e.ctr(n, out, plaintext); err != nil {
+		return nil, err
+	}
+	c, err := e.omac(2, out)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := n
+	subtle.XORBytes(tag, tag, h)
+	subtle.XORBytes(tag, tag, c)
+	return append(out, tag...), nil
+}
+
+// Open проверяет тег и расшифровывает; при несовпадении тега возвращает ErrAuthentication,
+// не раскрывая открытый текст
+func (e *EAX) Open(nonce, sealed, ad []byte) ([]byte, error) {
+	if len(sealed) < e.Overhead() {
+		return nil, ErrAuthentication
+	}
+	ciphertext, tag := sealed[:len(sealed)-e.Overhead()], sealed[len(sealed)-e.Overhead():]
+
+	n, err := e.omac(0, nonce)
+	if err != nil {
+		return nil, err
+	}
+	h, err := e.omac(1, ad)
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.omac(2, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	want := make([]byte, len(n))
+	subtle.XORBytes(want, n, h)
+	subtle.XORBytes(want, want, c)
+	if subtle.ConstantTimeCompare(tag, want) != 1 {
+		return nil, ErrAuthentication
+	}
+
+	out := make([]byte, len(ciphertext))
+	if err := e.ctr(n, out, ciphertext); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ctr гамма CTR от начального счётчика iv; счётчик — весь блок по модулю 2^n
+func (e *EAX) ctr(iv, dst, src []byte) error {
+	bs := len(iv)
+	counter := append([]byte{}, iv...)
+	for i := 0; i < len(src); i += bs {
+		keystream, err := e.cipher.EncryptBlock(counter)
+		if err != nil {
+			return err
+		}
+		end := min(i+bs, len(src))
+		subtle.XORBytes(dst[i:end], src[i:end], keystream)
+		incrementBE(counter)
+	}
+	return nil
+}
+
+// incrementBE увеличивает число big-endian на единицу с переносом через весь буфер
+func incrementBE(b []byte) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return
+		}
+	}
+}
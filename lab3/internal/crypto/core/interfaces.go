@@ -11,6 +11,8 @@ type RoundEncrypter interface {
 }
 
 // SymmetricCipher определяет функционал симметричного шифрования
+// После установки ключа EncryptBlock и DecryptBlock не должны менять состояние
+// шифра: CipherContext вызывает их одновременно из нескольких горутин.
 type SymmetricCipher interface {
 	EncryptBlock(block []byte) ([]byte, error)
 	DecryptBlock(block []byte) ([]byte, error)
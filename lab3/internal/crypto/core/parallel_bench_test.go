@@ -0,0 +1,122 @@
+package core_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/rijndael"
+)
+
+// распараллеливаемые режимы и направления
+var parallelCases = []struct {
+	mode    core.CipherMode
+	decrypt bool
+}{
+	{core.CBC, true},
+	{core.CFB, true},
+	{core.CTR, false},
+	{core.CTR, true},
+}
+
+func newRijndael(tb testing.TB, blockSize int) *rijndael.Rijndael {
+	tb.Helper()
+	r, err := rijndael.NewRijndael(blockSize, 16, 0x1B)
+	if err != nil {
+		tb.Fatalf("NewRijndael: %v", err)
+	}
+	key := bytes.Repeat([]byte{0x2B}, 16)
+	if err := r.SetEncryptionKey(key); err != nil {
+		tb.Fatalf("SetEncryptionKey: %v", err)
+	}
+	if err := r.SetDecryptionKey(key); err != nil {
+		tb.Fatalf("SetDecryptionKey: %v", err)
+	}
+	return r
+}
+
+// Тест: результат с несколькими воркерами совпадает с последовательной обработкой
+func TestParallelMatchesSequential(t *testing.T) {
+	for _, blockSize := range []int{16, 24, 32} {
+		r := newRijndael(t, blockSize)
+		iv := bytes.Repeat([]byte{0xA5}, blockSize)
+		data := bytes.Repeat([]byte("rijndael"), 1000)
+
+		for _, pc := range parallelCases {
+			seq := core.NewCipherContext(r, pc.mode, core.PadPKCS7, iv)
+			seq.SetWorkers(1)
+			par := core.NewCipherContext(r, pc.mode, core.PadPKCS7, iv)
+			par.SetWorkers(4)
+
+			want, err := seq.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if !pc.decrypt {
+				got, err := par.Encrypt(data)
+				if err != nil || !bytes.Equal(got, want) {
+					t.Errorf("блок %d, %s: параллельное шифрование отличается от последовательного (%v)", blockSize, pc.mode, err)
+				}
+				continue
+			}
+			got, err := par.Decrypt(want)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("блок %d, %s: параллельное дешифрование неверно (%v)", blockSize, pc.mode, err)
+			}
+		}
+	}
+}
+
+// BenchmarkParallelModes сравнивает последовательную обработку (один воркер)
+// с разбиением на диапазоны блоков для режимов, допускающих распараллеливание.
+//
+//	go test -run '^$' -bench ParallelModes ./internal/crypto/core
+func BenchmarkParallelModes(b *testing.B) {
+	data := bytes.Repeat([]byte("parallel"), 256<<10/8)
+
+	for _, blockSize := range []int{16, 32} {
+		r := newRijndael(b, blockSize)
+		iv := bytes.Repeat([]byte{0xA5}, blockSize)
+
+		for _, pc := range parallelCases {
+			op := "encrypt"
+			if pc.decrypt {
+				op = "decrypt"
+			}
+			for _, workers := range benchWorkers() {
+				b.Run(fmt.Sprintf("Rijndael%d-%s-%s/workers=%d", blockSize*8, pc.mode, op, workers), func(b *testing.B) {
+					ctx := core.NewCipherContext(r, pc.mode, core.PadPKCS7, iv)
+					ctx.SetWorkers(workers)
+					input := data
+					run := ctx.Encrypt
+					if pc.decrypt {
+						encrypted, err := ctx.Encrypt(data)
+						if err != nil {
+							b.Fatal(err)
+						}
+						input, run = encrypted, ctx.Decrypt
+					}
+
+					b.SetBytes(int64(len(data)))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if _, err := run(input); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// benchWorkers число воркеров для сравнения: 1 — последовательная обработка
+func benchWorkers() []int {
+	workers := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workers = append(workers, n)
+	}
+	return workers
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// redacted то, что выводится вместо ключа в fmt, логах и JSON
+const redacted = "[REDACTED]"
+
+// SecretKey ключ в памяти, который не попадает в вывод fmt (любой глагол,
+// включая %x и %#v, в том числе внутри структур), slog и encoding/json.
+// Байты ключа доступны только внутри Use; Destroy затирает их.
+//
+// SecretKey копируется по значению: копии ссылаются на один и тот же ключ,
+// и Destroy у любой из них уничтожает его для всех.
+type SecretKey struct {
+	// Ключ доступен через замыкание: fmt разыменовывает указатели на структуры
+	// даже в неэкспортируемых полях, а функции печатает только адресом
+	s func() *secretBytes
+}
+
+type secretBytes struct {
+	mu        sync.RWMutex
+	key       []byte
+	destroyed bool
+}
+
+// NewSecretKey копирует key в SecretKey. Исходный срез вызывающий может
+// затереть через Wipe.
+func NewSecretKey(key []byte) SecretKey {
+	s := &secretBytes{key: append([]byte(nil), key...)}
+	return SecretKey{s: func() *secretBytes { return s }}
+}
+
+// Len длина ключа в байтах (0 после Destroy)
+func (k SecretKey) Len() int {
+	if k.s == nil {
+		return 0
+	}
+	s := k.s()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.key)
+}
+
+// Use вызывает fn с байтами ключа. Срез действителен только внутри fn:
+// его нельзя сохранять или изменять.
+func (k SecretKey) Use(fn func(key []byte) error) error {
+	if k.s == nil {
+		return errors.New("secret key is not initialized")
+	}
+	s := k.s()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.destroyed {
+		return ErrDestroyed
+	}
+	return fn(s.key)
+}
+
+// SetOn устанавливает ключ шифрования и дешифрования шифра c
+func (k SecretKey) SetOn(c SymmetricCipher) error {
+	return k.Use(func(key []byte) error {
+		if err := c.SetEncryptionKey(key); err != nil {
+			return err
+		}
+		return c.SetDecryptionKey(key)
+	})
+}
+
+// Destroy затирает ключ; дальнейшие Use возвращают ErrDestroyed
+func (k SecretKey) Destroy() {
+	if k.s == nil {
+		return
+	}
+	s := k.s()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	Wipe(s.key)
+	s.key = nil
+	s.destroyed = true
+}
+
+func (k SecretKey) String() string {
+	return redacted
+}
+
+func (k SecretKey) GoString() string {
+	return "core.SecretKey{" + redacted + "}"
+}
+
+// Format подменяет вывод для всех глаголов fmt, включая %x и %d
+func (k SecretKey) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, k.GoString())
+		return
+	}
+	fmt.Fprint(f, redacted)
+}
+
+// LogValue скрывает ключ в log/slog
+func (k SecretKey) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// MarshalJSON не даёт сериализовать ключ
+func (k SecretKey) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("secret key must not be serialized")
+}
+
+// MarshalText не даёт сериализовать ключ текстовыми кодировщиками
+func (k SecretKey) MarshalText() ([]byte, error) {
+	return nil, errors.New("secret key must not be serialized")
+}
@@ -0,0 +1,76 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/rijndael"
+)
+
+// Тест: векторы NIST SP 800-38A (F.3) для CFB1 и CFB8 с Rijndael в роли AES
+func TestSegmentModesSP80038A(t *testing.T) {
+	iv, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	plaintext, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d")
+
+	tests := []struct {
+		name    string
+		key     string
+		segment core.SegmentSize
+		want    string
+	}{
+		// F.3.1: 16 бит открытого текста 0110101111000001
+		{"CFB1-AES128", "2b7e151628aed2a6abf7158809cf4f3c", core.CFB1, "68b3"},
+		{"CFB8-AES128", "2b7e151628aed2a6abf7158809cf4f3c", core.CFB8, "3b79424c9c0dd436bace9e0ed4586a4f32b9"},
+		{"CFB8-AES192", "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", core.CFB8, "cda2521ef0a905ca44cd057cbf0d47a0678a"},
+		{"CFB8-AES256", "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", core.CFB8, "dc1f1a8520a64db55fcc8ac554844e889700"},
+	}
+	for _, tt := range tests {
+		key, _ := hex.DecodeString(tt.key)
+		r, err := rijndael.NewRijndael(16, len(key), 0x1B)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.SetEncryptionKey(key); err != nil {
+			t.Fatal(err)
+		}
+		want, _ := hex.DecodeString(tt.want)
+		data := plaintext[:len(want)]
+
+		ctx := core.NewCipherContext(r, core.CFB, core.PadZeros, iv, tt.segment)
+		got, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: шифртекст %x, ожидался %x", tt.name, got, want)
+		}
+		decrypted, err := ctx.Decrypt(got)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Errorf("%s: расшифровано %x (%v), ожидалось %x", tt.name, decrypted, err, data)
+		}
+	}
+}
+
+// Тест: CFB-s и OFB-s для блоков Rijndael 192 и 256 бит
+func TestSegmentModesWideBlocks(t *testing.T) {
+	data := []byte("shift register wider than AES")
+	for _, blockSize := range []int{24, 32} {
+		r := newRijndael(t, blockSize)
+		iv := bytes.Repeat([]byte{0x3C}, blockSize)
+		for _, mode := range []core.CipherMode{core.CFB, core.OFB} {
+			for _, s := range []core.SegmentSize{1, 8, 64, core.SegmentSize(blockSize * 8)} {
+				ctx := core.NewCipherContext(r, mode, core.PadPKCS7, iv, s)
+				encrypted, err := ctx.Encrypt(data)
+				if err != nil {
+					t.Fatalf("блок %d, %s-%d: %v", blockSize, mode, s, err)
+				}
+				decrypted, err := ctx.Decrypt(encrypted)
+				if err != nil || !bytes.Equal(decrypted, data) {
+					t.Errorf("блок %d, %s-%d: расшифровано %q (%v)", blockSize, mode, s, decrypted, err)
+				}
+			}
+		}
+	}
+}
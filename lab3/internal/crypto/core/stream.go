@@ -0,0 +1,287 @@
+package core
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// Онлайн-AEAD формат STREAM (Hoang, Reyhanitabar, Rogaway, Vizár) поверх EAX.
+//
+// Поток: заголовок | сегмент_0 | ... | сегмент_k
+//
+//	заголовок  "CRST" | версия | размер блока | размер сегмента (4 байта) | случайный префикс nonce
+//	сегмент_i  EAX(nonce_i, открытый текст сегмента, AD = заголовок) = шифртекст | тег
+//	nonce_i    префикс | i (4 байта, big-endian) | 1 для последнего сегмента, иначе 0
+//
+// Все сегменты, кроме последнего, имеют полный размер. Номер в nonce не даёт
+// переставить или подменить сегменты, флаг последнего сегмента обнаруживает
+// обрезку потока по границе сегмента, префикс — вставку сегментов из другого потока.
+// Сегменты независимы, поэтому шифруются и проверяются параллельно.
+
+const (
+	streamMagic   = "CRST"
+	streamVersion = 1
+
+	// DefaultSegmentSize размер сегмента открытого текста по умолчанию
+	DefaultSegmentSize = 64 * 1024
+	// maxSegmentSize ограничивает память, выделяемую по заголовку при расшифровании
+	maxSegmentSize = 64 * 1024 * 1024
+	// StreamNoncePrefixSize длина случайного префикса nonce
+	StreamNoncePrefixSize = 16
+
+	streamHeaderSize = len(streamMagic) + 1 + 1 + 4 + StreamNoncePrefixSize
+)
+
+// ErrNotStream возвращается, если данные не начинаются с заголовка AEAD-потока
+var ErrNotStream = errors.New("not an AEAD stream")
+
+// streamNonce nonce сегмента: префикс || номер сегмента || флаг последнего сегмента
+func streamNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, len(prefix)+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// EncryptStreamAEAD шифрует in в формате STREAM с сегментами по segmentSize байт
+// (0 — DefaultSegmentSize). Используются только блочный шифр и число воркеров
+// контекста: режим, паддинг и IV для этого формата не применяются.
+func (ctx *CipherContext) EncryptStreamAEAD(in io.Reader, out io.Writer, segmentSize int) error {
+	if segmentSize == 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if segmentSize < 0 || segmentSize > maxSegmentSize {
+		return fmt.Errorf("segment size must be between 1 and %d bytes", maxSegmentSize)
+	}
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
+	aead, err := NewEAX(ctx.cipher)
+	if err != nil {
+		return err
+	}
+	defer aead.Destroy()
+
+	header := make([]byte, streamHeaderSize)
+	copy(header, streamMagic)
+	header[4] = streamVersion
+	header[5] = byte(ctx.blockSize)
+	binary.BigEndian.PutUint32(header[6:10], uint32(segmentSize))
+	prefix := header[10:]
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	return ctx.segmentPipeline(in, out, segmentSize, func(task bufferTask) ([]byte, error) {
+		return aead.Seal(streamNonce(prefix, uint32(task.index), task.last), task.data, header)
+	})
+}
+
+// DecryptStreamAEAD проверяет и расшифровывает поток формата STREAM. Открытый текст
+// пишется в out по мере проверки сегментов; при ошибке в out остаётся только
+// проверенное начало, и результат следует отбросить. Перестановка, подмена или
+// обрезка сегментов дают ErrAuthentication.
+func (ctx *CipherContext) DecryptStreamAEAD(in io.Reader, out io.Writer) error {
+	if err := ctx.checkUsable(); err != nil {
+		return err
+	}
+	aead, err := NewEAX(ctx.cipher)
+	if err != nil {
+		return err
+	}
+	defer aead.Destroy()
+
+	header := make([]byte, streamHeaderSize)
+	if n, err := io.ReadFull(in, header); err != nil {
+		if n >= len(streamMagic) && string(header[:len(streamMagic)]) == streamMagic {
+			return fmt.Errorf("%w: truncated header", ErrAuthentication)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrNotStream
+		}
+		return err
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return ErrNotStream
+	}
+	if header[4] != streamVersion {
+		return fmt.Errorf("unsupported AEAD stream version %d", header[4])
+	}
+	if int(header[5]) != ctx.blockSize {
+		return fmt.Errorf("AEAD stream was written with %d-byte blocks, cipher has %d", header[5], ctx.blockSize)
+	}
+	segmentSize := int(binary.BigEndian.Uint32(header[6:10]))
+	if segmentSize == 0 || segmentSize > maxSegmentSize {
+		return fmt.Errorf("%w: invalid segment size %d", ErrAuthentication, segmentSize)
+	}
+	prefix := header[10:]
+
+	return ctx.segmentPipeline(in, out, segmentSize+aead.Overhead(), func(task bufferTask) ([]byte, error) {
+		plain, err := aead.Open(streamNonce(prefix, uint32(task.index), task.last), task.data, header)
+		if err != nil {
+			return nil, fmt.Errorf("%w: segment %d", err, task.index)
+		}
+		return plain, nil
+	})
+}
+
+// EncryptFileAEAD шифрует файл в формате STREAM с сегментами по DefaultSegmentSize
+func (ctx *CipherContext) EncryptFileAEAD(inPath, outPath string) error {
+	return processFile(inPath, outPath, func(in io.Reader, out io.Writer) error {
+		return ctx.EncryptStreamAEAD(in, out, 0)
+	})
+}
+
+// DecryptFileAEAD расшифровывает файл формата STREAM; при ошибке проверки результат удаляется
+func (ctx *CipherContext) DecryptFileAEAD(inPath, outPath string) error {
+	return processFile(inPath, outPath, ctx.DecryptStreamAEAD)
+}
+
+func processFile(inPath, outPath string, fn func(io.Reader, io.Writer) error) error {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	err = fn(in, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outPath)
+	}
+	return err
+}
+
+// segmentPipeline читает in сегментами по size байт, обрабатывает их в ctx.Workers()
+// воркерах и пишет результаты в out по порядку. Последний сегмент определяется
+// упреждающим чтением и помечается в bufferTask.last; пустой вход даёт один пустой
+// последний сегмент.
+func (ctx *CipherContext) segmentPipeline(in io.Reader, out io.Writer, size int, process func(bufferTask) ([]byte, error)) error {
+	numWorkers := ctx.Workers()
+	tasks := make(chan bufferTask, numWorkers*2)
+	results := make(chan bufferResult, numWorkers*2)
+	done := make(chan struct{})
+
+	// На любом выходе дожидаемся воркеров: после возврата вызывающий может
+	// стереть ключ, которым process ещё пользуется
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var task bufferTask
+				select {
+				case t, ok := <-tasks:
+					if !ok {
+						return
+					}
+					task = t
+				case <-done:
+					return
+				}
+				data, err := process(task)
+				select {
+				case results <- bufferResult{data: data, index: task.index, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(tasks)
+		r := bufio.NewReader(in)
+		for index := 0; ; index++ {
+			if uint64(index) > math.MaxUint32 {
+				select {
+				case results <- bufferResult{err: errors.New("AEAD stream has too many segments"), index: -1}:
+				case <-done:
+				}
+				return
+			}
+			data := make([]byte, size)
+			n, readErr := io.ReadFull(r, data)
+			last := readErr == io.EOF || readErr == io.ErrUnexpectedEOF
+			if readErr == nil {
+				if _, peekErr := r.Peek(1); peekErr == io.EOF {
+					last = true
+				} else if peekErr != nil {
+					readErr = peekErr
+				}
+			}
+			if readErr != nil && !last {
+				select {
+				case results <- bufferResult{err: readErr, index: -1}:
+				case <-done:
+				}
+				return
+			}
+
+			select {
+			case tasks <- bufferTask{data: data[:n], index: index, last: last}:
+			case <-done:
+				return
+			}
+			if last {
+				return
+			}
+		}
+	}()
+
+	resultMap := make(map[int][]byte)
+	nextIndex := 0
+	for result := range results {
+		if result.err != nil {
+			return result.err
+		}
+		resultMap[result.index] = result.data
+
+		for {
+			data, ok := resultMap[nextIndex]
+			if !ok {
+				break
+			}
+			if _, err := out.Write(data); err != nil {
+				return err
+			}
+			delete(resultMap, nextIndex)
+			nextIndex++
+		}
+	}
+	return nil
+}
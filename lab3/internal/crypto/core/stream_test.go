@@ -0,0 +1,37 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+)
+
+// Тест: поток STREAM для всех размеров блока Rijndael (CMAC с блоками 192 и 256 бит)
+func TestStreamAEADRijndael(t *testing.T) {
+	data := bytes.Repeat([]byte("stream segment "), 100)
+
+	for _, blockSize := range []int{16, 24, 32} {
+		ctx := core.NewCipherContext(newRijndael(t, blockSize), core.CBC, core.PadPKCS7, nil)
+		ctx.SetWorkers(3)
+
+		var sealed bytes.Buffer
+		if err := ctx.EncryptStreamAEAD(bytes.NewReader(data), &sealed, 200); err != nil {
+			t.Fatalf("блок %d: EncryptStreamAEAD: %v", blockSize, err)
+		}
+		var opened bytes.Buffer
+		if err := ctx.DecryptStreamAEAD(bytes.NewReader(sealed.Bytes()), &opened); err != nil {
+			t.Fatalf("блок %d: DecryptStreamAEAD: %v", blockSize, err)
+		}
+		if !bytes.Equal(opened.Bytes(), data) {
+			t.Errorf("блок %d: расшифрованный поток не совпадает с исходным", blockSize)
+		}
+
+		broken := sealed.Bytes()
+		broken = broken[:len(broken)-(len(data)%200+blockSize)]
+		if err := ctx.DecryptStreamAEAD(bytes.NewReader(broken), &bytes.Buffer{}); !errors.Is(err, core.ErrAuthentication) {
+			t.Errorf("блок %d: обрезанный поток принят: %v", blockSize, err)
+		}
+	}
+}
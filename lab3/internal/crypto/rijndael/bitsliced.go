@@ -0,0 +1,273 @@
+package rijndael
+
+// Побитовая (bitsliced) реализация Rijndael без таблиц и ветвлений по данным.
+//
+// Состояние до 32 байт хранится как 8 битовых плоскостей: бит j плоскости i —
+// это i-й бит j-го байта состояния (байт j = строка + 4*столбец). S-box
+// вычисляется как обращение в GF(2^8) по модулю x^8+x^4+x^3+x+1 через x^254 и
+// аффинное преобразование; все байты состояния обрабатываются одновременно
+// операциями AND/XOR над плоскостями, поэтому время не зависит ни от ключа,
+// ни от данных. Сдвиги строк и перемешивание столбцов сводятся к фиксированным
+// перестановкам битов внутри плоскостей.
+
+// Implementation выбирает реализацию Rijndael в NewRijndael
+type Implementation int
+
+const (
+	// Reference табличная реализация (S-box и умножение в GF(2^8) с ветвлениями)
+	Reference Implementation = iota
+	// ConstantTime побитовая реализация с постоянным временем; только для модуля 0x1B
+	ConstantTime
+)
+
+// String возвращает название реализации
+func (i Implementation) String() string {
+	switch i {
+	case Reference:
+		return "reference"
+	case ConstantTime:
+		return "constant-time"
+	default:
+		return "unknown"
+	}
+}
+
+// standardModulus модуль AES x^8 + x^4 + x^3 + x + 1 без старшего бита
+const standardModulus = 0x1B
+
+// planes состояние в виде битовых плоскостей
+type planes [8]uint32
+
+// toPlanes раскладывает байты на битовые плоскости
+func toPlanes(b []byte) planes {
+	var p planes
+	for j, v := range b {
+		for i := 0; i < 8; i++ {
+			p[i] |= uint32(v>>i&1) << j
+		}
+	}
+	return p
+}
+
+// fromPlanes собирает байты из битовых плоскостей
+func fromPlanes(p *planes, out []byte) {
+	for j := range out {
+		var v byte
+		for i := 0; i < 8; i++ {
+			v |= byte(p[i]>>j&1) << i
+		}
+		out[j] = v
+	}
+}
+
+// reduce приводит произведение степени до 14 по модулю x^8+x^4+x^3+x+1
+func reduce(c *[15]uint32) planes {
+	// x^k = x^(k-4) + x^(k-5) + x^(k-7) + x^(k-8)
+	for k := 14; k >= 8; k-- {
+		c[k-4] ^= c[k]
+		c[k-5] ^= c[k]
+		c[k-7] ^= c[k]
+		c[k-8] ^= c[k]
+	}
+	var p planes
+	copy(p[:], c[:8])
+	return p
+}
+
+// mulPlanes умножает в GF(2^8) все байты a на соответствующие байты b
+func mulPlanes(a, b *planes) planes {
+	var c [15]uint32
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			c[i+j] ^= a[i] & b[j]
+		}
+	}
+	return reduce(&c)
+}
+
+// sqrPlanes возводит в квадрат: в характеристике 2 это линейное отображение
+func sqrPlanes(a *planes) planes {
+	var c [15]uint32
+	for i := 0; i < 8; i++ {
+		c[2*i] = a[i]
+	}
+	return reduce(&c)
+}
+
+// invPlanes вычисляет a^254, то есть обратный элемент (0 переходит в 0)
+func invPlanes(a *planes) planes {
+	a2 := sqrPlanes(a)
+	a3 := mulPlanes(&a2, a)
+	a6 := sqrPlanes(&a3)
+	a12 := sqrPlanes(&a6)
+	a14 := mulPlanes(&a12, &a2)
+	a15 := mulPlanes(&a12, &a3)
+	a30 := sqrPlanes(&a15)
+	a60 := sqrPlanes(&a30)
+	a120 := sqrPlanes(&a60)
+	a240 := sqrPlanes(&a120)
+	return mulPlanes(&a240, &a14)
+}
+
+// subPlanes применяет S-box ко всем байтам состояния
+func subPlanes(p *planes) {
+	inv := invPlanes(p)
+	// b_i = x_i ^ x_(i+4) ^ x_(i+5) ^ x_(i+6) ^ x_(i+7) ^ c_i, c = 0x63
+	for i := 0; i < 8; i++ {
+		p[i] = inv[i] ^ inv[(i+4)%8] ^ inv[(i+5)%8] ^ inv[(i+6)%8] ^ inv[(i+7)%8]
+		if 0x63>>i&1 == 1 {
+			p[i] = ^p[i]
+		}
+	}
+}
+
+// invSubPlanes применяет обратный S-box ко всем байтам состояния
+func invSubPlanes(p *planes) {
+	// x_i = b_(i+2) ^ b_(i+5) ^ b_(i+7) ^ d_i, d = 0x05
+	var x planes
+	for i := 0; i < 8; i++ {
+		x[i] = p[(i+2)%8] ^ p[(i+5)%8] ^ p[(i+7)%8]
+		if 0x05>>i&1 == 1 {
+			x[i] = ^x[i]
+		}
+	}
+	*p = invPlanes(&x)
+}
+
+// shiftPlanes циклически сдвигает строку row на n столбцов влево в состоянии из nb столбцов
+func shiftPlanes(p *planes, nb int) {
+	full := ^uint32(0) >> (32 - 4*nb)
+	for row := 1; row < 4; row++ {
+		n := row % nb
+		mask := uint32(0x11111111) << row & full
+		for i := range p {
+			x := p[i] & mask
+			// Столбец c получает столбец c+n: биты сдвигаются на 4n позиций вниз
+			rotated := (x>>(4*n) | x<<(4*(nb-n))) & mask
+			p[i] = p[i]&^mask | rotated
+		}
+	}
+}
+
+// invShiftPlanes обратный сдвиг строк
+func invShiftPlanes(p *planes, nb int) {
+	full := ^uint32(0) >> (32 - 4*nb)
+	for row := 1; row < 4; row++ {
+		n := row % nb
+		mask := uint32(0x11111111) << row & full
+		for i := range p {
+			x := p[i] & mask
+			rotated := (x<<(4*n) | x>>(4*(nb-n))) & mask
+			p[i] = p[i]&^mask | rotated
+		}
+	}
+}
+
+// rotRows сдвигает строки внутри каждого столбца: строка r получает строку r+n
+func rotRows(x uint32, n int) uint32 {
+	switch n {
+	case 1:
+		return x>>1&0x77777777 | x<<3&0x88888888
+	case 2:
+		return x>>2&0x33333333 | x<<2&0xCCCCCCCC
+	default:
+		return x>>3&0x11111111 | x<<1&0xEEEEEEEE
+	}
+}
+
+// xtimePlanes умножает все байты на x
+func xtimePlanes(a *planes) planes {
+	return planes{a[7], a[0] ^ a[7], a[1], a[2] ^ a[7], a[3] ^ a[7], a[4], a[5], a[6]}
+}
+
+// mixPlanes перемешивает столбцы: b_r = 2a_r ^ 3a_(r+1) ^ a_(r+2) ^ a_(r+3)
+func mixPlanes(p *planes) {
+	var s, r1 planes
+	for i := range p {
+		r1[i] = rotRows(p[i], 1)
+		s[i] = p[i] ^ r1[i]
+	}
+	x := xtimePlanes(&s)
+	for i := range p {
+		p[i] = x[i] ^ r1[i] ^ rotRows(p[i], 2) ^ rotRows(p[i], 3)
+	}
+}
+
+// invMixPlanes обратное перемешивание: a_r ^= 4(a_r ^ a_(r+2)), затем mixPlanes
+func invMixPlanes(p *planes) {
+	var s planes
+	for i := range p {
+		s[i] = p[i] ^ rotRows(p[i], 2)
+	}
+	x2 := xtimePlanes(&s)
+	x4 := xtimePlanes(&x2)
+	for i := range p {
+		p[i] ^= x4[i]
+	}
+	mixPlanes(p)
+}
+
+// addPlanes выполняет XOR состояния с раундовым ключом
+func addPlanes(p, key *planes) {
+	for i := range p {
+		p[i] ^= key[i]
+	}
+}
+
+// keyPlanes раскладывает раундовые ключи на битовые плоскости
+func keyPlanes(roundKeys [][]byte) []planes {
+	out := make([]planes, len(roundKeys))
+	for i, k := range roundKeys {
+		out[i] = toPlanes(k)
+	}
+	return out
+}
+
+// subWordBitsliced применяет S-box к слову расписания ключей без таблиц
+func subWordBitsliced(word []byte) []byte {
+	p := toPlanes(word)
+	subPlanes(&p)
+	result := make([]byte, len(word))
+	fromPlanes(&p, result)
+	return result
+}
+
+// encryptBitsliced шифрует блок побитовой реализацией
+func (r *Rijndael) encryptBitsliced(block []byte) []byte {
+	keys := r.encPlanes
+	p := toPlanes(block)
+	addPlanes(&p, &keys[0])
+	for round := 1; round < r.Nr; round++ {
+		subPlanes(&p)
+		shiftPlanes(&p, r.Nb)
+		mixPlanes(&p)
+		addPlanes(&p, &keys[round])
+	}
+	subPlanes(&p)
+	shiftPlanes(&p, r.Nb)
+	addPlanes(&p, &keys[r.Nr])
+
+	out := make([]byte, r.blockSize)
+	fromPlanes(&p, out)
+	return out
+}
+
+// decryptBitsliced дешифрует блок побитовой реализацией
+func (r *Rijndael) decryptBitsliced(block []byte) []byte {
+	keys := r.decPlanes
+	p := toPlanes(block)
+	addPlanes(&p, &keys[0])
+	for round := 1; round < r.Nr; round++ {
+		invShiftPlanes(&p, r.Nb)
+		invSubPlanes(&p)
+		addPlanes(&p, &keys[round])
+		invMixPlanes(&p)
+	}
+	invShiftPlanes(&p, r.Nb)
+	invSubPlanes(&p)
+	addPlanes(&p, &keys[r.Nr])
+
+	out := make([]byte, r.blockSize)
+	fromPlanes(&p, out)
+	return out
+}
@@ -0,0 +1,221 @@
+package rijndael
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math"
+	mrand "math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Побитовый S-box совпадает с табличным на всех 256 значениях
+func TestBitslicedSBox(t *testing.T) {
+	sbox, err := NewSBox(standardModulus)
+	if err != nil {
+		t.Fatalf("NewSBox: %v", err)
+	}
+	for base := 0; base < 256; base += 32 {
+		in := make([]byte, 32)
+		for j := range in {
+			in[j] = byte(base + j)
+		}
+
+		p := toPlanes(in)
+		subPlanes(&p)
+		out := make([]byte, 32)
+		fromPlanes(&p, out)
+
+		invP := toPlanes(out)
+		invSubPlanes(&invP)
+		back := make([]byte, 32)
+		fromPlanes(&invP, back)
+
+		for j, v := range in {
+			if out[j] != sbox.forward[v] {
+				t.Errorf("S(0x%02X) = 0x%02X; ожидалось 0x%02X", v, out[j], sbox.forward[v])
+			}
+			if back[j] != v {
+				t.Errorf("S^-1(S(0x%02X)) = 0x%02X", v, back[j])
+			}
+		}
+	}
+}
+
+// Обе реализации дают одинаковый результат для всех размеров блока и ключа
+func TestConstantTimeMatchesReference(t *testing.T) {
+	for _, blockSize := range []int{16, 24, 32} {
+		for _, keySize := range []int{16, 24, 32} {
+			ref, err := NewRijndael(blockSize, keySize, standardModulus)
+			if err != nil {
+				t.Fatalf("NewRijndael: %v", err)
+			}
+			ct, err := NewRijndael(blockSize, keySize, standardModulus, ConstantTime)
+			if err != nil {
+				t.Fatalf("NewRijndael(ConstantTime): %v", err)
+			}
+
+			for trial := 0; trial < 8; trial++ {
+				key := make([]byte, keySize)
+				block := make([]byte, blockSize)
+				rand.Read(key)
+				rand.Read(block)
+				for _, c := range []*Rijndael{ref, ct} {
+					if err := c.SetDecryptionKey(key); err != nil {
+						t.Fatalf("SetDecryptionKey: %v", err)
+					}
+				}
+
+				want, _ := ref.EncryptBlock(block)
+				got, err := ct.EncryptBlock(block)
+				if err != nil {
+					t.Fatalf("EncryptBlock: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("блок %d, ключ %d: шифрование различается\nпобитовая: %x\nтабличная: %x",
+						blockSize*8, keySize*8, got, want)
+				}
+
+				wantPlain, _ := ref.DecryptBlock(block)
+				gotPlain, err := ct.DecryptBlock(block)
+				if err != nil {
+					t.Fatalf("DecryptBlock: %v", err)
+				}
+				if !bytes.Equal(gotPlain, wantPlain) {
+					t.Fatalf("блок %d, ключ %d: дешифрование различается", blockSize*8, keySize*8)
+				}
+			}
+		}
+	}
+}
+
+// Вектор FIPS-197 C.1 для побитовой реализации
+func TestConstantTimeFIPS197(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	plaintext := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := []byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a}
+
+	c, err := NewRijndael(16, 16, standardModulus, ConstantTime)
+	if err != nil {
+		t.Fatalf("NewRijndael: %v", err)
+	}
+	if err := c.SetDecryptionKey(key); err != nil {
+		t.Fatalf("SetDecryptionKey: %v", err)
+	}
+	ciphertext, _ := c.EncryptBlock(plaintext)
+	if !bytes.Equal(ciphertext, expected) {
+		t.Errorf("шифртекст %x; ожидалось %x", ciphertext, expected)
+	}
+	decrypted, _ := c.DecryptBlock(ciphertext)
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("расшифровано %x; ожидалось %x", decrypted, plaintext)
+	}
+}
+
+func TestConstantTimeRequiresStandardModulus(t *testing.T) {
+	if _, err := NewRijndael(16, 16, 0x1D, ConstantTime); err == nil {
+		t.Error("ожидалась ошибка для нестандартного модуля")
+	}
+	if _, err := NewRijndael(16, 16, standardModulus, Implementation(7)); err == nil {
+		t.Error("ожидалась ошибка для неизвестной реализации")
+	}
+}
+
+// welchT статистика t-критерия Уэлча для двух выборок
+func welchT(a, b []float64) float64 {
+	mean := func(x []float64) float64 {
+		var s float64
+		for _, v := range x {
+			s += v
+		}
+		return s / float64(len(x))
+	}
+	variance := func(x []float64, m float64) float64 {
+		var s float64
+		for _, v := range x {
+			s += (v - m) * (v - m)
+		}
+		return s / float64(len(x)-1)
+	}
+	ma, mb := mean(a), mean(b)
+	return (ma - mb) / math.Sqrt(variance(a, ma)/float64(len(a))+variance(b, mb)/float64(len(b)))
+}
+
+// leakageT измеряет время шифрования для двух классов входов в духе dudect:
+// фиксированный блок из нулей против случайных блоков, классы чередуются
+// случайно, а выбросы выше 90-го процентиля отбрасываются
+func leakageT(c *Rijndael, samples int) float64 {
+	const batch = 8
+	rng := mrand.New(mrand.NewSource(1))
+	fixed := make([]byte, c.BlockSize())
+	inputs := make([][]byte, samples)
+	classes := make([]int, samples)
+	for i := range inputs {
+		classes[i] = rng.Intn(2)
+		inputs[i] = make([]byte, c.BlockSize())
+		if classes[i] == 0 {
+			copy(inputs[i], fixed)
+		} else {
+			rng.Read(inputs[i])
+		}
+	}
+
+	times := make([]float64, samples)
+	for i, in := range inputs {
+		start := time.Now()
+		for k := 0; k < batch; k++ {
+			c.EncryptBlock(in)
+		}
+		times[i] = float64(time.Since(start))
+	}
+
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+	cutoff := sorted[len(sorted)*9/10]
+
+	var groups [2][]float64
+	for i, v := range times {
+		if v <= cutoff {
+			groups[classes[i]] = append(groups[classes[i]], v)
+		}
+	}
+	return welchT(groups[0], groups[1])
+}
+
+// Тест утечки по времени: |t| побитовой реализации должен оставаться ниже
+// порога dudect, при котором утечка считается обнаруженной
+func TestTimingLeakage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("измерение времени пропускается в режиме -short")
+	}
+	const threshold = 10
+	key := make([]byte, 16)
+	rand.Read(key)
+
+	ref, _ := NewRijndael(16, 16, standardModulus)
+	ct, _ := NewRijndael(16, 16, standardModulus, ConstantTime)
+	for _, c := range []*Rijndael{ref, ct} {
+		if err := c.SetEncryptionKey(key); err != nil {
+			t.Fatalf("SetEncryptionKey: %v", err)
+		}
+	}
+
+	refT := leakageT(ref, 20000)
+	ctT := leakageT(ct, 20000)
+	t.Logf("t-статистика: табличная %.2f, побитовая %.2f (порог %d)", refT, ctT, threshold)
+
+	if math.Abs(ctT) > threshold {
+		t.Errorf("побитовая реализация: |t| = %.2f превышает порог %d", math.Abs(ctT), threshold)
+	}
+}
+
+func BenchmarkEncryptConstantTime(b *testing.B) {
+	c, _ := NewRijndael(16, 16, standardModulus, ConstantTime)
+	c.SetEncryptionKey(make([]byte, 16))
+	block := make([]byte, 16)
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		c.EncryptBlock(block)
+	}
+}
@@ -1,5 +1,7 @@
 package rijndael
 
+import "github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+
 // expandKey выполняет расширение ключа для получения раундовых ключей
 func (r *Rijndael) expandKey(key []byte) ([][]byte, error) {
 	// Количество 32-битных слов, необходимых для всех раундовых ключей
@@ -36,6 +38,7 @@ func (r *Rijndael) expandKey(key []byte) ([][]byte, error) {
 
 		// w[i] = w[i-Nk] XOR temp
 		w[i] = r.xorWord(w[i-r.Nk], temp)
+		core.Wipe(temp)
 	}
 
 	// Формируем раундовые ключи из слов
@@ -50,6 +53,9 @@ func (r *Rijndael) expandKey(key []byte) ([][]byte, error) {
 		}
 	}
 
+	// Расписание слов повторяет раундовые ключи и стирается сразу после сборки
+	core.WipeKeys(w)
+
 	return roundKeys, nil
 }
 
@@ -60,6 +66,9 @@ func (r *Rijndael) rotWord(word []byte) []byte {
 
 // subWord применяет S-box к каждому байту в слове
 func (r *Rijndael) subWord(word []byte) []byte {
+	if r.impl == ConstantTime {
+		return subWordBitsliced(word)
+	}
 	result := make([]byte, 4)
 	for i := 0; i < 4; i++ {
 		result[i] = r.sbox.forward[word[i]]
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
 	"github.com/NikitaKoros/cryptography/lab3/internal/gf256"
 )
 
@@ -18,12 +19,18 @@ type Rijndael struct {
 	sbox         *SBox
 	roundKeys    [][]byte // Раундовые ключи для шифрования
 	decRoundKeys [][]byte // Раундовые ключи для дешифрования
+	destroyed    bool     // ключи стёрты вызовом Destroy
+
+	impl      Implementation
+	encPlanes []planes // раундовые ключи шифрования в виде битовых плоскостей (ConstantTime)
+	decPlanes []planes // раундовые ключи дешифрования в виде битовых плоскостей (ConstantTime)
 }
 
 // NewRijndael создает новый экземпляр Rijndael с заданными параметрами
 // blockSize и keySize должны быть 16, 24 или 32 байта (128, 192 или 256 бит)
 // modulus - неприводимый полином для GF(2^8)
-func NewRijndael(blockSize, keySize int, modulus byte) (*Rijndael, error) {
+// impl - реализация (по умолчанию Reference); ConstantTime требует модуль 0x1B
+func NewRijndael(blockSize, keySize int, modulus byte, impl ...Implementation) (*Rijndael, error) {
 	if blockSize != 16 && blockSize != 24 && blockSize != 32 {
 		return nil, errors.New("недопустимый размер блока: должен быть 16, 24 или 32 байта")
 	}
@@ -31,6 +38,20 @@ func NewRijndael(blockSize, keySize int, modulus byte) (*Rijndael, error) {
 		return nil, errors.New("недопустимый размер ключа: должен быть 16, 24 или 32 байта")
 	}
 
+	implementation := Reference
+	if len(impl) > 0 {
+		implementation = impl[0]
+	}
+	switch implementation {
+	case Reference:
+	case ConstantTime:
+		if modulus != standardModulus {
+			return nil, fmt.Errorf("реализация %s поддерживает только модуль 0x%02X", implementation, standardModulus)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестная реализация Rijndael: %d", implementation)
+	}
+
 	gf, err := gf256.NewGF256(modulus)
 	if err != nil {
 		return nil, err
@@ -55,6 +76,7 @@ func NewRijndael(blockSize, keySize int, modulus byte) (*Rijndael, error) {
 		Nr:        Nr,
 		gf:        gf,
 		sbox:      sbox,
+		impl:      implementation,
 	}, nil
 }
 
@@ -70,13 +92,25 @@ func calculateRounds(Nb, Nk int) int {
 
 // SetEncryptionKey устанавливает ключ шифрования и выполняет расширение ключа
 func (r *Rijndael) SetEncryptionKey(key []byte) error {
+	if r.destroyed {
+		return core.ErrDestroyed
+	}
 	if len(key) != r.keySize {
 		return fmt.Errorf("неверный размер ключа: ожидается %d байт, получено %d", r.keySize, len(key))
 	}
 
-	var err error
-	r.roundKeys, err = r.expandKey(key)
-	return err
+	roundKeys, err := r.expandKey(key)
+	if err != nil {
+		return err
+	}
+	// Ключи предыдущего ключа больше не нужны
+	core.WipeKeys(r.roundKeys)
+	r.roundKeys = roundKeys
+	if r.impl == ConstantTime {
+		clear(r.encPlanes)
+		r.encPlanes = keyPlanes(roundKeys)
+	}
+	return nil
 }
 
 // SetDecryptionKey устанавливает ключ дешифрования
@@ -88,6 +122,7 @@ func (r *Rijndael) SetDecryptionKey(key []byte) error {
 
 	// Для дешифрования используем те же ключи в обратном порядке
 	// Применение InvMixColumns к ключам не требуется в стандартном алгоритме
+	core.WipeKeys(r.decRoundKeys)
 	r.decRoundKeys = make([][]byte, len(r.roundKeys))
 
 	// Копируем ключи в обратном порядке
@@ -95,10 +130,31 @@ func (r *Rijndael) SetDecryptionKey(key []byte) error {
 		r.decRoundKeys[i] = make([]byte, r.blockSize)
 		copy(r.decRoundKeys[i], r.roundKeys[r.Nr-i])
 	}
+	if r.impl == ConstantTime {
+		clear(r.decPlanes)
+		r.decPlanes = keyPlanes(r.decRoundKeys)
+	}
 
 	return nil
 }
 
+// Destroy затирает раундовые ключи шифрования и дешифрования;
+// дальнейшие операции возвращают core.ErrDestroyed
+func (r *Rijndael) Destroy() {
+	core.WipeKeys(r.roundKeys)
+	core.WipeKeys(r.decRoundKeys)
+	clear(r.encPlanes)
+	clear(r.decPlanes)
+	r.roundKeys, r.decRoundKeys = nil, nil
+	r.encPlanes, r.decPlanes = nil, nil
+	r.destroyed = true
+}
+
+// Implementation возвращает выбранную реализацию
+func (r *Rijndael) Implementation() Implementation {
+	return r.impl
+}
+
 // BlockSize возвращает размер блока в байтах
 func (r *Rijndael) BlockSize() int {
 	return r.blockSize
@@ -106,12 +162,18 @@ func (r *Rijndael) BlockSize() int {
 
 // EncryptBlock шифрует один блок данных
 func (r *Rijndael) EncryptBlock(plaintext []byte) ([]byte, error) {
+	if r.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if len(plaintext) != r.blockSize {
 		return nil, fmt.Errorf("неверный размер блока: ожидается %d байт, получено %d", r.blockSize, len(plaintext))
 	}
 	if r.roundKeys == nil {
 		return nil, errors.New("ключ шифрования не установлен")
 	}
+	if r.impl == ConstantTime {
+		return r.encryptBitsliced(plaintext), nil
+	}
 
 	state := make([]byte, r.blockSize)
 	copy(state, plaintext)
@@ -137,12 +199,18 @@ func (r *Rijndael) EncryptBlock(plaintext []byte) ([]byte, error) {
 
 // DecryptBlock дешифрует один блок данных
 func (r *Rijndael) DecryptBlock(ciphertext []byte) ([]byte, error) {
+	if r.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if len(ciphertext) != r.blockSize {
 		return nil, fmt.Errorf("неверный размер блока: ожидается %d байт, получено %d", r.blockSize, len(ciphertext))
 	}
 	if r.decRoundKeys == nil {
 		return nil, errors.New("ключ дешифрования не установлен")
 	}
+	if r.impl == ConstantTime {
+		return r.decryptBitsliced(ciphertext), nil
+	}
 
 	state := make([]byte, r.blockSize)
 	copy(state, ciphertext)
@@ -280,3 +348,8 @@ func (r *Rijndael) invMixColumns(state []byte) {
 		)
 	}
 }
+
+var (
+	_ core.SymmetricCipher = (*Rijndael)(nil)
+	_ core.Destroyer       = (*Rijndael)(nil)
+)
@@ -3,7 +3,10 @@ package rijndael
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
 	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
 )
 
 // Тест создания Rijndael с корректными параметрами
@@ -457,3 +460,31 @@ func BenchmarkExpandKey(b *testing.B) {
 		cipher.expandKey(key)
 	}
 }
+
+// Тест стирания раундовых ключей и ошибки после Destroy
+func TestDestroy(t *testing.T) {
+	cipher, _ := NewRijndael(16, 16, 0x1B)
+	key := make([]byte, 16)
+	rand.Read(key)
+	cipher.SetEncryptionKey(key)
+	cipher.SetDecryptionKey(key)
+
+	keys := append(append([][]byte{}, cipher.roundKeys...), cipher.decRoundKeys...)
+	cipher.Destroy()
+
+	for i, k := range keys {
+		if !bytes.Equal(k, make([]byte, len(k))) {
+			t.Errorf("раундовый ключ %d не стёрт: %x", i, k)
+		}
+	}
+	block := make([]byte, 16)
+	if _, err := cipher.EncryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock после Destroy вернул %v", err)
+	}
+	if _, err := cipher.DecryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("DecryptBlock после Destroy вернул %v", err)
+	}
+	if err := cipher.SetEncryptionKey(key); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("SetEncryptionKey после Destroy вернул %v", err)
+	}
+}
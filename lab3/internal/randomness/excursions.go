@@ -0,0 +1,152 @@
+package randomness
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExcursionStates состояния случайного блуждания для теста случайных экскурсий
+var ExcursionStates = []int{-4, -3, -2, -1, 1, 2, 3, 4}
+
+// ExcursionVariantStates состояния для варианта теста случайных экскурсий
+var ExcursionVariantStates = []int{-9, -8, -7, -6, -5, -4, -3, -2, -1, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+// ErrInsufficientCycles возвращается, если блуждание содержит слишком мало циклов
+type ErrInsufficientCycles struct {
+	Cycles   int
+	Required int
+}
+
+func (e ErrInsufficientCycles) Error() string {
+	return fmt.Sprintf("randomness: random walk has %d cycles, at least %d required", e.Cycles, e.Required)
+}
+
+// requiredCycles минимальное число циклов J = max(0.005*sqrt(n), 500)
+func requiredCycles(n int) int {
+	return int(math.Max(0.005*math.Sqrt(float64(n)), 500))
+}
+
+// partialSums возвращает частичные суммы S_1..S_n блуждания X_i = 2ε_i - 1
+func partialSums(bits Bits) []int {
+	s := make([]int, len(bits))
+	sum := 0
+	for i, b := range bits {
+		sum += 2*int(b) - 1
+		s[i] = sum
+	}
+	return s
+}
+
+// RandomExcursions тест случайных экскурсий, SP 800-22 §2.14.
+// Возвращает P-значения для состояний ExcursionStates.
+func RandomExcursions(bits Bits) ([]float64, error) {
+	return randomExcursions(bits, true)
+}
+
+func randomExcursions(bits Bits, checkCycles bool) ([]float64, error) {
+	if len(bits) == 0 {
+		return nil, ErrInsufficientData
+	}
+	s := partialSums(bits)
+
+	// visits[x][k] — число циклов, в которых состояние x встретилось ровно k раз (k = 5 означает >= 5)
+	visits := make(map[int]*[6]int, len(ExcursionStates))
+	for _, x := range ExcursionStates {
+		visits[x] = &[6]int{}
+	}
+
+	cycles := 0
+	inCycle := make(map[int]int, len(ExcursionStates))
+	closeCycle := func() {
+		cycles++
+		for _, x := range ExcursionStates {
+			k := inCycle[x]
+			if k > 5 {
+				k = 5
+			}
+			visits[x][k]++
+			inCycle[x] = 0
+		}
+	}
+	for _, v := range s {
+		if v == 0 {
+			closeCycle()
+			continue
+		}
+		if v >= -4 && v <= 4 {
+			inCycle[v]++
+		}
+	}
+	if s[len(s)-1] != 0 {
+		closeCycle()
+	}
+
+	if checkCycles && cycles < requiredCycles(len(bits)) {
+		return nil, ErrInsufficientCycles{Cycles: cycles, Required: requiredCycles(len(bits))}
+	}
+
+	j := float64(cycles)
+	pValues := make([]float64, 0, len(ExcursionStates))
+	for _, x := range ExcursionStates {
+		chi2 := 0.0
+		for k := 0; k < 6; k++ {
+			expected := j * excursionProbability(x, k)
+			chi2 += sq(float64(visits[x][k])-expected) / expected
+		}
+		pValues = append(pValues, igamc(2.5, chi2/2))
+	}
+	return pValues, nil
+}
+
+// excursionProbability вероятность π_k(x) того, что состояние x
+// посещается ровно k раз за цикл (k = 5 — не менее пяти раз)
+func excursionProbability(x, k int) float64 {
+	ax := math.Abs(float64(x))
+	q := 1 - 1/(2*ax)
+	switch {
+	case k == 0:
+		return q
+	case k < 5:
+		return 1 / (4 * ax * ax) * math.Pow(q, float64(k-1))
+	default:
+		return 1 / (2 * ax) * math.Pow(q, 4)
+	}
+}
+
+// RandomExcursionsVariant вариант теста случайных экскурсий, SP 800-22 §2.15.
+// Возвращает P-значения для состояний ExcursionVariantStates.
+func RandomExcursionsVariant(bits Bits) ([]float64, error) {
+	return randomExcursionsVariant(bits, true)
+}
+
+func randomExcursionsVariant(bits Bits, checkCycles bool) ([]float64, error) {
+	if len(bits) == 0 {
+		return nil, ErrInsufficientData
+	}
+	s := partialSums(bits)
+
+	counts := make(map[int]int, len(ExcursionVariantStates))
+	cycles := 0
+	for _, v := range s {
+		if v == 0 {
+			cycles++
+		} else if v >= -9 && v <= 9 {
+			counts[v]++
+		}
+	}
+	if s[len(s)-1] != 0 {
+		cycles++
+	}
+
+	if checkCycles && cycles < requiredCycles(len(bits)) {
+		return nil, ErrInsufficientCycles{Cycles: cycles, Required: requiredCycles(len(bits))}
+	}
+
+	j := float64(cycles)
+	pValues := make([]float64, 0, len(ExcursionVariantStates))
+	for _, x := range ExcursionVariantStates {
+		ax := math.Abs(float64(x))
+		pValues = append(pValues, math.Erfc(math.Abs(float64(counts[x])-j)/math.Sqrt(2*j*(4*ax-2))))
+	}
+	return pValues, nil
+}
@@ -0,0 +1,199 @@
+package randomness
+
+import (
+	"errors"
+	"math"
+)
+
+// Frequency тест частот (монобит), SP 800-22 §2.1
+func Frequency(bits Bits) (float64, error) {
+	n := len(bits)
+	if n == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	sum := 0
+	for _, b := range bits {
+		sum += 2*int(b) - 1
+	}
+	sObs := math.Abs(float64(sum)) / math.Sqrt(float64(n))
+	return math.Erfc(sObs / math.Sqrt2), nil
+}
+
+// BlockFrequency тест частот в блоках длины m, SP 800-22 §2.2
+func BlockFrequency(bits Bits, m int) (float64, error) {
+	if m <= 0 {
+		return 0, errors.New("randomness: block length must be positive")
+	}
+	blocks := len(bits) / m
+	if blocks == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	chi2 := 0.0
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi2 += pi * pi
+	}
+	chi2 *= 4 * float64(m)
+	return igamc(float64(blocks)/2, chi2/2), nil
+}
+
+// Runs тест серий, SP 800-22 §2.3.
+// Если не выполнено предварительное условие теста частот, P-значение равно 0.
+func Runs(bits Bits) (float64, error) {
+	n := len(bits)
+	if n < 2 {
+		return 0, ErrInsufficientData
+	}
+
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / float64(n)
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return 0, nil
+	}
+
+	vObs := 1
+	for k := 0; k < n-1; k++ {
+		if bits[k] != bits[k+1] {
+			vObs++
+		}
+	}
+
+	num := math.Abs(float64(vObs) - 2*float64(n)*pi*(1-pi))
+	den := 2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)
+	return math.Erfc(num / den), nil
+}
+
+// longestRunParams параметры теста самой длинной серии для разных n
+type longestRunParams struct {
+	m     int       // длина блока
+	vMin  int       // нижняя граница первого класса
+	probs []float64 // теоретические вероятности классов
+}
+
+func selectLongestRunParams(n int) (longestRunParams, bool) {
+	switch {
+	case n >= 750000:
+		return longestRunParams{
+			m: 10000, vMin: 10,
+			probs: []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727},
+		}, true
+	case n >= 6272:
+		return longestRunParams{
+			m: 128, vMin: 4,
+			probs: []float64{0.1174, 0.2430, 0.2493, 0.1752, 0.1027, 0.1124},
+		}, true
+	case n >= 128:
+		return longestRunParams{
+			m: 8, vMin: 1,
+			probs: []float64{0.2148, 0.3672, 0.2305, 0.1875},
+		}, true
+	default:
+		return longestRunParams{}, false
+	}
+}
+
+// LongestRunOfOnes тест самой длинной серии единиц в блоке, SP 800-22 §2.4
+func LongestRunOfOnes(bits Bits) (float64, error) {
+	params, ok := selectLongestRunParams(len(bits))
+	if !ok {
+		return 0, ErrInsufficientData
+	}
+
+	k := len(params.probs) - 1
+	blocks := len(bits) / params.m
+	counts := make([]int, k+1)
+
+	for i := 0; i < blocks; i++ {
+		longest, run := 0, 0
+		for _, b := range bits[i*params.m : (i+1)*params.m] {
+			if b == 1 {
+				run++
+				if run > longest {
+					longest = run
+				}
+			} else {
+				run = 0
+			}
+		}
+
+		class := longest - params.vMin
+		if class < 0 {
+			class = 0
+		}
+		if class > k {
+			class = k
+		}
+		counts[class]++
+	}
+
+	chi2 := 0.0
+	for i, c := range counts {
+		expected := float64(blocks) * params.probs[i]
+		diff := float64(c) - expected
+		chi2 += diff * diff / expected
+	}
+	return igamc(float64(k)/2, chi2/2), nil
+}
+
+// CumulativeSums тест кумулятивных сумм, SP 800-22 §2.13.
+// Возвращает P-значения для прямого и обратного направлений.
+func CumulativeSums(bits Bits) ([]float64, error) {
+	n := len(bits)
+	if n == 0 {
+		return nil, ErrInsufficientData
+	}
+
+	forward, backward := 0, 0
+	sum := 0
+	for _, b := range bits {
+		sum += 2*int(b) - 1
+		if abs(sum) > forward {
+			forward = abs(sum)
+		}
+	}
+	sum = 0
+	for i := n - 1; i >= 0; i-- {
+		sum += 2*int(bits[i]) - 1
+		if abs(sum) > backward {
+			backward = abs(sum)
+		}
+	}
+
+	return []float64{cusumPValue(n, forward), cusumPValue(n, backward)}, nil
+}
+
+// cusumPValue вычисляет P-значение по максимальному отклонению z.
+// Границы суммирования считаются целочисленным делением, как в эталонной реализации NIST STS.
+func cusumPValue(n, z int) float64 {
+	if z == 0 {
+		return 1
+	}
+	fz := float64(z)
+	sqrtN := math.Sqrt(float64(n))
+
+	sum1 := 0.0
+	for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+		sum1 += normalCDF(float64(4*k+1)*fz/sqrtN) - normalCDF(float64(4*k-1)*fz/sqrtN)
+	}
+	sum2 := 0.0
+	for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+		sum2 += normalCDF(float64(4*k+3)*fz/sqrtN) - normalCDF(float64(4*k+1)*fz/sqrtN)
+	}
+	return 1 - sum1 + sum2
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
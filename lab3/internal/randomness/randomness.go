@@ -0,0 +1,191 @@
+package randomness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DefaultAlpha уровень значимости, рекомендованный SP 800-22
+const DefaultAlpha = 0.01
+
+// ErrInsufficientData возвращается, если последовательность слишком коротка для теста
+var ErrInsufficientData = errors.New("randomness: sequence is too short for this test")
+
+// Bits битовая последовательность: каждый элемент равен 0 или 1
+type Bits []byte
+
+// BitsFromBytes раскладывает байты в биты, начиная со старшего бита каждого байта
+func BitsFromBytes(data []byte) Bits {
+	bits := make(Bits, len(data)*8)
+	for i, b := range data {
+		for j := 0; j < 8; j++ {
+			bits[i*8+j] = (b >> uint(7-j)) & 1
+		}
+	}
+	return bits
+}
+
+// BitsFromString разбирает строку вида "0110..." (удобно для тестов и примеров из стандарта)
+func BitsFromString(s string) (Bits, error) {
+	bits := make(Bits, 0, len(s))
+	for _, ch := range s {
+		switch ch {
+		case '0':
+			bits = append(bits, 0)
+		case '1':
+			bits = append(bits, 1)
+		case ' ', '\n', '\t':
+		default:
+			return nil, fmt.Errorf("randomness: invalid bit character %q", ch)
+		}
+	}
+	return bits, nil
+}
+
+// ReadBits читает ровно n бит из r
+func ReadBits(r io.Reader, n int) (Bits, error) {
+	if n <= 0 {
+		return nil, errors.New("randomness: number of bits must be positive")
+	}
+	buf := make([]byte, (n+7)/8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("randomness: reading %d bits: %w", n, err)
+	}
+	return BitsFromBytes(buf)[:n], nil
+}
+
+// Result результат одного статистического теста
+type Result struct {
+	Name    string    // название теста
+	PValues []float64 // P-значения (у шаблонных тестов и тестов экскурсий их несколько)
+	Err     error     // причина, по которой тест неприменим к последовательности
+}
+
+// Passed сообщает, прошёл ли тест на уровне значимости alpha.
+// Для теста с одним P-значением требуется P >= alpha. Для тестов с несколькими
+// P-значениями применяется критерий доли (SP 800-22 §4.2.1): доля прошедших
+// должна быть не меньше (1-α) - 3·sqrt(α(1-α)/k).
+// Неприменимый тест (Err != nil) считается непройденным.
+func (r Result) Passed(alpha float64) bool {
+	if r.Err != nil || len(r.PValues) == 0 {
+		return false
+	}
+	passed := 0
+	for _, p := range r.PValues {
+		if p >= alpha {
+			passed++
+		}
+	}
+	k := float64(len(r.PValues))
+	minProportion := (1 - alpha) - 3*math.Sqrt(alpha*(1-alpha)/k)
+	return float64(passed)/k >= minProportion && (len(r.PValues) > 1 || passed == 1)
+}
+
+// MinPValue возвращает наименьшее P-значение результата
+func (r Result) MinPValue() float64 {
+	if len(r.PValues) == 0 {
+		return 0
+	}
+	min := r.PValues[0]
+	for _, p := range r.PValues[1:] {
+		if p < min {
+			min = p
+		}
+	}
+	return min
+}
+
+// Config параметры тестов батареи
+type Config struct {
+	BlockFrequencyM     int // длина блока теста частот в блоках
+	NonOverlappingM     int // длина непериодических шаблонов
+	OverlappingM        int // длина шаблона из единиц для теста с перекрытием
+	LinearComplexityM   int // длина блока теста линейной сложности
+	SerialM             int // длина шаблона последовательного теста
+	ApproximateEntropyM int // длина блока теста приближённой энтропии
+}
+
+// DefaultConfig возвращает параметры, рекомендованные SP 800-22 для n порядка 10^6
+func DefaultConfig() Config {
+	return Config{
+		BlockFrequencyM:     128,
+		NonOverlappingM:     9,
+		OverlappingM:        9,
+		LinearComplexityM:   500,
+		SerialM:             16,
+		ApproximateEntropyM: 10,
+	}
+}
+
+// Run читает nBits бит из r и прогоняет на них всю батарею SP 800-22
+func Run(r io.Reader, nBits int, cfg Config) ([]Result, error) {
+	bits, err := ReadBits(r, nBits)
+	if err != nil {
+		return nil, err
+	}
+	return RunBits(bits, cfg), nil
+}
+
+// RunBits прогоняет всю батарею SP 800-22 на готовой последовательности
+func RunBits(bits Bits, cfg Config) []Result {
+	single := func(name string, p float64, err error) Result {
+		if err != nil {
+			return Result{Name: name, Err: err}
+		}
+		return Result{Name: name, PValues: []float64{p}}
+	}
+	multi := func(name string, p []float64, err error) Result {
+		return Result{Name: name, PValues: p, Err: err}
+	}
+
+	var results []Result
+
+	p, err := Frequency(bits)
+	results = append(results, single("Frequency", p, err))
+
+	p, err = BlockFrequency(bits, cfg.BlockFrequencyM)
+	results = append(results, single("BlockFrequency", p, err))
+
+	ps, err := CumulativeSums(bits)
+	results = append(results, multi("CumulativeSums", ps, err))
+
+	p, err = Runs(bits)
+	results = append(results, single("Runs", p, err))
+
+	p, err = LongestRunOfOnes(bits)
+	results = append(results, single("LongestRun", p, err))
+
+	p, err = Rank(bits)
+	results = append(results, single("Rank", p, err))
+
+	p, err = DiscreteFourierTransform(bits)
+	results = append(results, single("FFT", p, err))
+
+	ps, err = NonOverlappingTemplate(bits, cfg.NonOverlappingM)
+	results = append(results, multi("NonOverlappingTemplate", ps, err))
+
+	p, err = OverlappingTemplate(bits, cfg.OverlappingM)
+	results = append(results, single("OverlappingTemplate", p, err))
+
+	p, err = Universal(bits)
+	results = append(results, single("Universal", p, err))
+
+	p, err = ApproximateEntropy(bits, cfg.ApproximateEntropyM)
+	results = append(results, single("ApproximateEntropy", p, err))
+
+	ps, err = RandomExcursions(bits)
+	results = append(results, multi("RandomExcursions", ps, err))
+
+	ps, err = RandomExcursionsVariant(bits)
+	results = append(results, multi("RandomExcursionsVariant", ps, err))
+
+	ps, err = Serial(bits, cfg.SerialM)
+	results = append(results, multi("Serial", ps, err))
+
+	p, err = LinearComplexity(bits, cfg.LinearComplexityM)
+	results = append(results, single("LinearComplexity", p, err))
+
+	return results
+}
@@ -0,0 +1,326 @@
+package randomness
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/core"
+	"github.com/NikitaKoros/cryptography/lab3/internal/crypto/rijndael"
+)
+
+// piBits первые 100 бит двоичного разложения π из примеров SP 800-22
+const piBits = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+
+const tolerance = 1e-6
+
+// tableTolerance допуск для примеров, в которых стандарт считает по округлённым таблицам вероятностей
+const tableTolerance = 1e-4
+
+func mustBits(t *testing.T, s string) Bits {
+	t.Helper()
+	bits, err := BitsFromString(s)
+	if err != nil {
+		t.Fatalf("BitsFromString: %v", err)
+	}
+	return bits
+}
+
+func assertPValue(t *testing.T, name string, got, want, tol float64) {
+	t.Helper()
+	if tol == 0 {
+		tol = tolerance
+	}
+	if math.Abs(got-want) > tol {
+		t.Errorf("%s: P-value = %.6f, want %.6f", name, got, want)
+	}
+}
+
+func TestSpecExamples(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T) (float64, error)
+		want float64
+		tol  float64
+	}{
+		{
+			name: "Frequency",
+			run:  func(t *testing.T) (float64, error) { return Frequency(mustBits(t, "1011010101")) },
+			want: 0.527089,
+		},
+		{
+			name: "BlockFrequency",
+			run:  func(t *testing.T) (float64, error) { return BlockFrequency(mustBits(t, "0110011010"), 3) },
+			want: 0.801252,
+		},
+		{
+			name: "Runs",
+			run:  func(t *testing.T) (float64, error) { return Runs(mustBits(t, "1001101011")) },
+			want: 0.147232,
+		},
+		{
+			name: "LongestRunOfOnes",
+			run: func(t *testing.T) (float64, error) {
+				return LongestRunOfOnes(mustBits(t, "11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010"))
+			},
+			want: 0.180609,
+			tol:  tableTolerance,
+		},
+		{
+			name: "DiscreteFourierTransform",
+			run:  func(t *testing.T) (float64, error) { return DiscreteFourierTransform(mustBits(t, "1001010011")) },
+			// пример в SP 800-22 посчитан по прежнему порогу; по формулам ред. 1a
+			// все пять пиков ниже T = sqrt(ln(20) n), откуда N1 = 5
+			want: 0.468160,
+		},
+		{
+			name: "NonOverlappingTemplateMatch",
+			run: func(t *testing.T) (float64, error) {
+				return NonOverlappingTemplateMatch(mustBits(t, "10100100101110010110"), mustBits(t, "001"), 2)
+			},
+			want: 0.344154,
+		},
+		{
+			name: "Universal",
+			run:  func(t *testing.T) (float64, error) { return universal(mustBits(t, "01011010011101010111"), 2, 4) },
+			// пример стандарта опускает множитель c/sqrt(K) в σ (там P = 0.767189),
+			// здесь σ считается полностью, как в эталонной реализации
+			want: 0.063454,
+		},
+		{
+			name: "ApproximateEntropy",
+			run:  func(t *testing.T) (float64, error) { return ApproximateEntropy(mustBits(t, "0100110101"), 3) },
+			want: 0.261961,
+		},
+		{
+			name: "Frequency pi",
+			run:  func(t *testing.T) (float64, error) { return Frequency(mustBits(t, piBits)) },
+			want: 0.109599,
+		},
+		{
+			name: "BlockFrequency pi",
+			run:  func(t *testing.T) (float64, error) { return BlockFrequency(mustBits(t, piBits), 10) },
+			want: 0.706438,
+		},
+		{
+			name: "Runs pi",
+			run:  func(t *testing.T) (float64, error) { return Runs(mustBits(t, piBits)) },
+			want: 0.500798,
+		},
+		{
+			name: "DiscreteFourierTransform pi",
+			run:  func(t *testing.T) (float64, error) { return DiscreteFourierTransform(mustBits(t, piBits)) },
+			// N1 = 48 по формулам ред. 1a (в примере стандарта приведено значение прежней редакции)
+			want: 0.646355,
+		},
+		{
+			name: "ApproximateEntropy pi",
+			run:  func(t *testing.T) (float64, error) { return ApproximateEntropy(mustBits(t, piBits), 2) },
+			want: 0.235301,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(t)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertPValue(t, tt.name, got, tt.want, tt.tol)
+		})
+	}
+}
+
+func TestMultiValueSpecExamples(t *testing.T) {
+	tests := []struct {
+		name  string
+		run   func(t *testing.T) ([]float64, error)
+		index int
+		want  float64
+		tol   float64
+	}{
+		{
+			name: "Serial del1",
+			run:  func(t *testing.T) ([]float64, error) { return Serial(mustBits(t, "0011011101"), 3) },
+			want: 0.808792,
+		},
+		{
+			name:  "Serial del2",
+			run:   func(t *testing.T) ([]float64, error) { return Serial(mustBits(t, "0011011101"), 3) },
+			index: 1,
+			want:  0.670320,
+		},
+		{
+			name: "CumulativeSums forward",
+			run:  func(t *testing.T) ([]float64, error) { return CumulativeSums(mustBits(t, "1011010111")) },
+			want: 0.4116588,
+		},
+		{
+			name: "CumulativeSums pi forward",
+			run:  func(t *testing.T) ([]float64, error) { return CumulativeSums(mustBits(t, piBits)) },
+			want: 0.219194,
+		},
+		{
+			name:  "CumulativeSums pi backward",
+			run:   func(t *testing.T) ([]float64, error) { return CumulativeSums(mustBits(t, piBits)) },
+			index: 1,
+			want:  0.114866,
+		},
+		{
+			name: "RandomExcursions x=+1",
+			run:  func(t *testing.T) ([]float64, error) { return randomExcursions(mustBits(t, "0110110101"), false) },
+			// состояния -4..-1, +1..+4: x = +1 имеет индекс 4
+			index: 4,
+			want:  0.502529,
+			tol:   tableTolerance,
+		},
+		{
+			name: "RandomExcursionsVariant x=+1",
+			run: func(t *testing.T) ([]float64, error) {
+				return randomExcursionsVariant(mustBits(t, "0110110101"), false)
+			},
+			index: 9,
+			want:  0.683091,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(t)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.index >= len(got) {
+				t.Fatalf("got %d P-values, want index %d", len(got), tt.index)
+			}
+			assertPValue(t, tt.name, got[tt.index], tt.want, tt.tol)
+		})
+	}
+}
+
+func TestBerlekampMassey(t *testing.T) {
+	if l := BerlekampMassey(mustBits(t, "1101011110001")); l != 4 {
+		t.Errorf("linear complexity = %d, want 4", l)
+	}
+}
+
+func TestAperiodicTemplates(t *testing.T) {
+	if n := len(AperiodicTemplates(9)); n != 148 {
+		t.Errorf("got %d aperiodic templates of length 9, want 148", n)
+	}
+}
+
+func TestRandomExcursionsInsufficientCycles(t *testing.T) {
+	if _, err := RandomExcursions(mustBits(t, piBits)); err == nil {
+		t.Error("expected error for a sequence with too few cycles")
+	}
+}
+
+func TestBitsFromBytes(t *testing.T) {
+	bits := BitsFromBytes([]byte{0xA5})
+	want := mustBits(t, "10100101")
+	for i := range want {
+		if bits[i] != want[i] {
+			t.Fatalf("BitsFromBytes(0xA5) = %v, want %v", bits, want)
+		}
+	}
+}
+
+func TestRunBitsPseudoRandom(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping full battery in short mode")
+	}
+	const n = 1_000_000
+	rng := rand.New(rand.NewSource(1))
+	data := make([]byte, n/8)
+	rng.Read(data)
+
+	results := RunBits(BitsFromBytes(data), DefaultConfig())
+	if len(results) != 15 {
+		t.Fatalf("got %d results, want 15", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		for _, p := range r.PValues {
+			if p < 0 || p > 1 || math.IsNaN(p) {
+				t.Errorf("%s: P-value %v out of [0, 1]", r.Name, p)
+			}
+		}
+		// на случайных данных несколько шаблонов из 148 могут не пройти, поэтому
+		// для многозначных тестов проверяем только минимальный порог
+		if r.MinPValue() < 1e-4 {
+			t.Errorf("%s: P-value %.6f is too small for a pseudo-random sequence", r.Name, r.MinPValue())
+		}
+	}
+}
+
+func TestEvaluateCipher(t *testing.T) {
+	key := make([]byte, 16)
+	iv := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+		iv[i] = byte(0xF0 + i)
+	}
+	const n = 20000
+
+	// стандартный модуль AES и нестандартный x^8 + x^4 + x^3 + x^2 + 1
+	for _, modulus := range []byte{0x1B, 0x1D} {
+		newContext := func(mode core.CipherMode) *core.CipherContext {
+			c, err := rijndael.NewRijndael(16, 16, modulus)
+			if err != nil {
+				t.Fatalf("NewRijndael(0x%02X): %v", modulus, err)
+			}
+			if err := c.SetEncryptionKey(key); err != nil {
+				t.Fatalf("SetEncryptionKey: %v", err)
+			}
+			return core.NewCipherContext(c, mode, core.PadZeros, iv)
+		}
+
+		ctr, err := EvaluateCipher(newContext(core.CTR), n, DefaultConfig())
+		if err != nil {
+			t.Fatalf("EvaluateCipher(CTR): %v", err)
+		}
+		if ctr[0].Name != "Frequency" || !ctr[0].Passed(DefaultAlpha) {
+			t.Errorf("modulus 0x%02X: CTR keystream failed the frequency test: %+v", modulus, ctr[0])
+		}
+
+		// ECB на нулевом открытом тексте повторяет один и тот же блок
+		ecb, err := EvaluateCipher(newContext(core.ECB), n, DefaultConfig())
+		if err != nil {
+			t.Fatalf("EvaluateCipher(ECB): %v", err)
+		}
+		failed := false
+		for _, r := range ecb {
+			if r.Err == nil && !r.Passed(DefaultAlpha) {
+				failed = true
+				break
+			}
+		}
+		if !failed {
+			t.Errorf("modulus 0x%02X: repeated ECB blocks passed every applicable test", modulus)
+		}
+	}
+}
+
+func TestDFTMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, n := range []int{1, 2, 7, 10, 16, 100, 127} {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = float64(rng.Intn(2)*2 - 1)
+		}
+		got := dft(x)
+		for k := 0; k < n; k++ {
+			var want complex128
+			for j, v := range x {
+				angle := -2 * math.Pi * float64(k*j) / float64(n)
+				want += complex(v*math.Cos(angle), v*math.Sin(angle))
+			}
+			if d := got[k] - want; math.Hypot(real(d), imag(d)) > 1e-9 {
+				t.Fatalf("n=%d k=%d: dft = %v, want %v", n, k, got[k], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package randomness
+
+import (
+	"errors"
+	"math"
+)
+
+// patternCounts считает частоты всех перекрывающихся m-битных шаблонов
+// в последовательности, циклически дополненной первыми m-1 битами
+func patternCounts(bits Bits, m int) []int {
+	counts := make([]int, 1<<uint(m))
+	if m == 0 {
+		return counts
+	}
+	n := len(bits)
+	mask := 1<<uint(m) - 1
+	v := 0
+	for i := 0; i < m-1; i++ {
+		v = v<<1 | int(bits[i%n])
+	}
+	for i := 0; i < n; i++ {
+		v = (v<<1 | int(bits[(i+m-1)%n])) & mask
+		counts[v]++
+	}
+	return counts
+}
+
+// psiSquared статистика ψ²_m последовательного теста
+func psiSquared(bits Bits, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	n := float64(len(bits))
+	sum := 0.0
+	for _, c := range patternCounts(bits, m) {
+		sum += float64(c) * float64(c)
+	}
+	return sum*math.Pow(2, float64(m))/n - n
+}
+
+// Serial последовательный тест, SP 800-22 §2.11.
+// Возвращает два P-значения: для ∇ψ²_m и ∇²ψ²_m.
+func Serial(bits Bits, m int) ([]float64, error) {
+	if m < 2 {
+		return nil, errors.New("randomness: serial test block length must be at least 2")
+	}
+	if len(bits) < m {
+		return nil, ErrInsufficientData
+	}
+
+	psiM := psiSquared(bits, m)
+	psiM1 := psiSquared(bits, m-1)
+	psiM2 := psiSquared(bits, m-2)
+
+	del1 := psiM - psiM1
+	del2 := psiM - 2*psiM1 + psiM2
+	return []float64{
+		igamc(math.Pow(2, float64(m-1))/2, del1/2),
+		igamc(math.Pow(2, float64(m-2))/2, del2/2),
+	}, nil
+}
+
+// ApproximateEntropy тест приближённой энтропии, SP 800-22 §2.12
+func ApproximateEntropy(bits Bits, m int) (float64, error) {
+	if m < 1 {
+		return 0, errors.New("randomness: approximate entropy block length must be positive")
+	}
+	n := len(bits)
+	if n < m+1 {
+		return 0, ErrInsufficientData
+	}
+
+	phi := func(blockLen int) float64 {
+		sum := 0.0
+		for _, c := range patternCounts(bits, blockLen) {
+			if c > 0 {
+				p := float64(c) / float64(n)
+				sum += p * math.Log(p)
+			}
+		}
+		return sum
+	}
+
+	apEn := phi(m) - phi(m+1)
+	chi2 := 2 * float64(n) * (math.Ln2 - apEn)
+	return igamc(math.Pow(2, float64(m-1)), chi2/2), nil
+}
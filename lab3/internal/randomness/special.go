@@ -0,0 +1,171 @@
+package randomness
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	gammaEpsilon  = 1e-15
+	gammaMaxIter  = 10000
+	gammaTinyFrac = 1e-300
+)
+
+// igamc вычисляет регуляризованную верхнюю неполную гамма-функцию Q(a, x).
+// Именно через неё в SP 800-22 выражаются P-значения статистики хи-квадрат.
+func igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	if x < a+1 {
+		return 1 - igamSeries(a, x)
+	}
+	return igamContinuedFraction(a, x)
+}
+
+// igamSeries вычисляет P(a, x) разложением в ряд (сходится при x < a+1)
+func igamSeries(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	ap := a
+	sum := 1 / a
+	del := sum
+	for i := 0; i < gammaMaxIter; i++ {
+		ap++
+		del *= x / ap
+		sum += del
+		if math.Abs(del) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+// igamContinuedFraction вычисляет Q(a, x) цепной дробью Лентца (сходится при x >= a+1)
+func igamContinuedFraction(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / gammaTinyFrac
+	d := 1 / b
+	h := d
+	for i := 1; i < gammaMaxIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTinyFrac {
+			d = gammaTinyFrac
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTinyFrac {
+			c = gammaTinyFrac
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < gammaEpsilon {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+// normalCDF функция распределения стандартного нормального закона
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// dft вычисляет дискретное преобразование Фурье последовательности произвольной длины.
+// Для длин, равных степени двойки, используется БПФ по основанию 2,
+// для остальных — алгоритм Блюстейна через свёртку.
+func dft(x []float64) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for i, v := range x {
+		out[i] = complex(v, 0)
+	}
+	if n <= 1 {
+		return out
+	}
+	if n&(n-1) == 0 {
+		fftRadix2(out, false)
+		return out
+	}
+	return bluestein(out)
+}
+
+// bluestein выражает ДПФ длины n через циклическую свёртку длины степени двойки
+func bluestein(x []complex128) []complex128 {
+	n := len(x)
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+
+	// w[k] = exp(-i*pi*k^2/n); k^2 берётся по модулю 2n, чтобы не терять точность
+	w := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		kk := (uint64(k) * uint64(k)) % uint64(2*n)
+		angle := math.Pi * float64(kk) / float64(n)
+		w[k] = cmplx.Rect(1, -angle)
+	}
+
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * w[k]
+	}
+	b[0] = cmplx.Conj(w[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(w[k])
+		b[m-k] = cmplx.Conj(w[k])
+	}
+
+	fftRadix2(a, false)
+	fftRadix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fftRadix2(a, true)
+
+	out := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		out[k] = a[k] / complex(float64(m), 0) * w[k]
+	}
+	return out
+}
+
+// fftRadix2 выполняет БПФ на месте; длина должна быть степенью двойки.
+// При inverse=true вычисляется обратное преобразование без нормировки.
+func fftRadix2(a []complex128, inverse bool) {
+	n := len(a)
+
+	// Бит-реверсная перестановка
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(length))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			half := length / 2
+			for k := 0; k < half; k++ {
+				u := a[start+k]
+				v := a[start+k+half] * w
+				a[start+k] = u + v
+				a[start+k+half] = u - v
+				w *= step
+			}
+		}
+	}
+}
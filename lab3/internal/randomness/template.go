@@ -0,0 +1,174 @@
+package randomness
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	nonOverlappingBlocks = 8    // число блоков N в тесте без перекрытия
+	overlappingBlockSize = 1032 // длина блока M в тесте с перекрытием
+	overlappingClasses   = 5    // число степеней свободы K в тесте с перекрытием
+)
+
+// AperiodicTemplates возвращает все непериодические шаблоны длины m
+// в лексикографическом порядке (для m = 9 их 148, как в наборе NIST).
+// Шаблон непериодичен, если никакой его собственный сдвиг не совпадает с ним самим.
+func AperiodicTemplates(m int) []Bits {
+	var templates []Bits
+	for v := 0; v < 1<<uint(m); v++ {
+		t := make(Bits, m)
+		for i := 0; i < m; i++ {
+			t[i] = byte(v>>uint(m-1-i)) & 1
+		}
+		if isAperiodic(t) {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
+
+func isAperiodic(t Bits) bool {
+	m := len(t)
+	for shift := 1; shift < m; shift++ {
+		overlap := true
+		for i := 0; i < m-shift; i++ {
+			if t[i] != t[i+shift] {
+				overlap = false
+				break
+			}
+		}
+		if overlap {
+			return false
+		}
+	}
+	return true
+}
+
+// NonOverlappingTemplate тест непересекающихся шаблонов, SP 800-22 §2.7.
+// Прогоняет все непериодические шаблоны длины m и возвращает P-значение для каждого.
+func NonOverlappingTemplate(bits Bits, m int) ([]float64, error) {
+	if m < 2 || m > 21 {
+		return nil, errors.New("randomness: template length must be in [2, 21]")
+	}
+	templates := AperiodicTemplates(m)
+	pValues := make([]float64, 0, len(templates))
+	for _, t := range templates {
+		p, err := NonOverlappingTemplateMatch(bits, t, nonOverlappingBlocks)
+		if err != nil {
+			return nil, err
+		}
+		pValues = append(pValues, p)
+	}
+	return pValues, nil
+}
+
+// NonOverlappingTemplateMatch тест непересекающихся вхождений одного шаблона
+// при разбиении последовательности на blocks блоков
+func NonOverlappingTemplateMatch(bits Bits, template Bits, blocks int) (float64, error) {
+	m := len(template)
+	if m == 0 || blocks <= 0 {
+		return 0, errors.New("randomness: empty template or no blocks")
+	}
+	blockLen := len(bits) / blocks
+	if blockLen < m {
+		return 0, ErrInsufficientData
+	}
+
+	twoM := math.Pow(2, float64(m))
+	mu := float64(blockLen-m+1) / twoM
+	variance := float64(blockLen) * (1/twoM - float64(2*m-1)/(twoM*twoM))
+
+	chi2 := 0.0
+	for j := 0; j < blocks; j++ {
+		block := bits[j*blockLen : (j+1)*blockLen]
+		w := 0
+		for i := 0; i <= blockLen-m; {
+			if matchAt(block, template, i) {
+				w++
+				i += m
+			} else {
+				i++
+			}
+		}
+		chi2 += sq(float64(w)-mu) / variance
+	}
+	return igamc(float64(blocks)/2, chi2/2), nil
+}
+
+// OverlappingTemplate тест пересекающихся шаблонов из m единиц, SP 800-22 §2.8
+func OverlappingTemplate(bits Bits, m int) (float64, error) {
+	if m < 2 || m >= overlappingBlockSize {
+		return 0, errors.New("randomness: invalid overlapping template length")
+	}
+	blocks := len(bits) / overlappingBlockSize
+	if blocks == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	template := make(Bits, m)
+	for i := range template {
+		template[i] = 1
+	}
+
+	counts := make([]int, overlappingClasses+1)
+	for j := 0; j < blocks; j++ {
+		block := bits[j*overlappingBlockSize : (j+1)*overlappingBlockSize]
+		w := 0
+		for i := 0; i <= overlappingBlockSize-m; i++ {
+			if matchAt(block, template, i) {
+				w++
+			}
+		}
+		if w > overlappingClasses {
+			w = overlappingClasses
+		}
+		counts[w]++
+	}
+
+	lambda := float64(overlappingBlockSize-m+1) / math.Pow(2, float64(m))
+	eta := lambda / 2
+
+	probs := make([]float64, overlappingClasses+1)
+	sum := 0.0
+	for u := 0; u < overlappingClasses; u++ {
+		probs[u] = overlappingProbability(u, eta)
+		sum += probs[u]
+	}
+	probs[overlappingClasses] = 1 - sum
+
+	chi2 := 0.0
+	for i, c := range counts {
+		expected := float64(blocks) * probs[i]
+		chi2 += sq(float64(c)-expected) / expected
+	}
+	return igamc(float64(overlappingClasses)/2, chi2/2), nil
+}
+
+// overlappingProbability вероятность ровно u вхождений шаблона в блок (формула NIST STS)
+func overlappingProbability(u int, eta float64) float64 {
+	if u == 0 {
+		return math.Exp(-eta)
+	}
+	lg := func(x float64) float64 {
+		v, _ := math.Lgamma(x)
+		return v
+	}
+	sum := 0.0
+	fu := float64(u)
+	for l := 1; l <= u; l++ {
+		fl := float64(l)
+		sum += math.Exp(-eta - fu*math.Ln2 + fl*math.Log(eta) -
+			lg(fl+1) + lg(fu) - lg(fl) - lg(fu-fl+1))
+	}
+	return sum
+}
+
+func matchAt(bits, template Bits, pos int) bool {
+	for k, t := range template {
+		if bits[pos+k] != t {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package randomness
+
+import (
+	"errors"
+	"math"
+)
+
+// universalExpected ожидаемое значение и дисперсия статистики Маурера для L = 1..16
+var universalExpected = [17]struct{ mean, variance float64 }{
+	{0, 0},
+	{0.7326495, 0.690},
+	{1.5374383, 1.338},
+	{2.4016068, 1.901},
+	{3.3112247, 2.358},
+	{4.2534266, 2.705},
+	{5.2177052, 2.954},
+	{6.1962507, 3.125},
+	{7.1836656, 3.238},
+	{8.1764248, 3.311},
+	{9.1723243, 3.356},
+	{10.170032, 3.384},
+	{11.168765, 3.401},
+	{12.168070, 3.410},
+	{13.167693, 3.416},
+	{14.167488, 3.419},
+	{15.167379, 3.421},
+}
+
+// universalMinLength минимальная длина последовательности для L = 6..16 (SP 800-22, табл. §2.9.7)
+var universalMinLength = []struct {
+	l int
+	n int
+}{
+	{16, 1059061760},
+	{15, 496435200},
+	{14, 231669760},
+	{13, 107560960},
+	{12, 49643520},
+	{11, 22753280},
+	{10, 10342400},
+	{9, 4654080},
+	{8, 2068480},
+	{7, 904960},
+	{6, 387840},
+}
+
+// Universal универсальный статистический тест Маурера, SP 800-22 §2.9.
+// Длина блока L выбирается по длине последовательности, Q = 10 * 2^L.
+func Universal(bits Bits) (float64, error) {
+	n := len(bits)
+	for _, row := range universalMinLength {
+		if n >= row.n {
+			return universal(bits, row.l, 10*(1<<uint(row.l)))
+		}
+	}
+	return 0, ErrInsufficientData
+}
+
+// universal вычисляет статистику Маурера при заданных L и Q
+func universal(bits Bits, l, q int) (float64, error) {
+	if l < 1 || l > 16 {
+		return 0, errors.New("randomness: universal test block length must be in [1, 16]")
+	}
+	k := len(bits)/l - q
+	if q <= 0 || k <= 0 {
+		return 0, ErrInsufficientData
+	}
+
+	block := func(i int) int {
+		v := 0
+		for _, b := range bits[i*l : (i+1)*l] {
+			v = v<<1 | int(b)
+		}
+		return v
+	}
+
+	table := make([]int, 1<<uint(l))
+	for i := 1; i <= q; i++ {
+		table[block(i-1)] = i
+	}
+	sum := 0.0
+	for i := q + 1; i <= q+k; i++ {
+		v := block(i - 1)
+		sum += math.Log2(float64(i - table[v]))
+		table[v] = i
+	}
+	fn := sum / float64(k)
+
+	fl := float64(l)
+	c := 0.7 - 0.8/fl + (4+32/fl)*math.Pow(float64(k), -3/fl)/15
+	sigma := c * math.Sqrt(universalExpected[l].variance/float64(k))
+	return math.Erfc(math.Abs(fn-universalExpected[l].mean) / (math.Sqrt2 * sigma)), nil
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -18,7 +17,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Полное тестирование FROG ===\n")
+	fmt.Println("=== Полное тестирование FROG ===")
+	fmt.Println()
 
 	key := []byte("TestKey123456789")
 	frogCipher, err := frog.New(key)
@@ -46,13 +46,11 @@ func main() {
 	fmt.Printf("Decrypted block: %x\n", decryptedBlock)
 	fmt.Printf("Match: %t\n\n", bytes.Equal(plaintextBlock, decryptedBlock))
 
-	// Генерируем IV
-	iv := make([]byte, frog.BlockSize)
-	if _, err := rand.Read(iv); err != nil {
-		log.Fatalf("Ошибка генерации IV: %v", err)
-	}
+	// IV не задаётся: контекст генерирует случайный IV для каждого сообщения
+	// и записывает его перед шифртекстом, поэтому сообщения не делят один IV
 
-	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===\n")
+	fmt.Println("=== Тестирование всех комбинаций режимов и паддингов ===")
+	fmt.Println()
 
 	modes := []core.CipherMode{
 		core.ECB,
@@ -85,7 +83,7 @@ func main() {
 		for _, padding := range paddings {
 			fmt.Printf("Testing %v + %v... ", mode, padding)
 
-			ctx := core.NewCipherContext(frogCipher, mode, padding, iv)
+			ctx := core.NewCipherContext(frogCipher, mode, padding, nil)
 
 			startEnc := time.Now()
 			ciphertext, err := ctx.Encrypt(plaintext)
@@ -121,7 +119,8 @@ func main() {
 	fmt.Printf("Успешно: %d/%d\n", successCount, successCount+failCount)
 	fmt.Printf("Неудачно: %d/%d\n\n", failCount, successCount+failCount)
 
-	fmt.Println("=== Тестирование файловых операций ===\n")
+	fmt.Println("=== Тестирование файловых операций ===")
+	fmt.Println()
 
 	testFiles := []string{
 		"test.txt",
@@ -166,7 +165,7 @@ func main() {
 			encryptedFile := filepath.Join("./test_files", fmt.Sprintf("%s_encrypted.bin", filename))
 			decryptedFile := filepath.Join("./test_files", fmt.Sprintf("%s_decrypted.%s", filename, ext))
 
-			ctx := core.NewCipherContext(frogCipher, tm.mode, tm.padding, iv)
+			ctx := core.NewCipherContext(frogCipher, tm.mode, tm.padding, nil)
 
 			startEnc := time.Now()
 			if err := ctx.EncryptFile(inputPath, encryptedFile); err != nil {
@@ -210,7 +209,8 @@ func main() {
 		}
 	}
 
-	fmt.Println("\n=== Неприводимые полиномы GF(2^8) ===\n")
+	fmt.Println("\n=== Неприводимые полиномы GF(2^8) ===")
+	fmt.Println()
 	polynomials := gf256.GetAllIrreduciblePolynomials()
 	fmt.Printf("Всего найдено неприводимых полиномов: %d\n", len(polynomials))
 	fmt.Printf("Первые 5 полиномов:\n")
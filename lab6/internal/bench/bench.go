@@ -0,0 +1,315 @@
+// Package bench прогоняет матрицу производительности
+// «шифр × режим × паддинг × число воркеров × размер данных»
+// через CipherContext и файловые API.
+package bench
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab6/internal/crypto/core"
+)
+
+// API способ, которым данные подаются в CipherContext
+type API string
+
+const (
+	APIMemory API = "memory" // Encrypt/Decrypt над срезом байт
+	APIFile   API = "file"   // EncryptFile/DecryptFile через временные файлы
+)
+
+// Operation измеряемая операция
+type Operation string
+
+const (
+	OpEncrypt Operation = "encrypt"
+	OpDecrypt Operation = "decrypt"
+)
+
+// DefaultMinTime минимальное время измерения одного случая
+const DefaultMinTime = 200 * time.Millisecond
+
+// Case одна точка матрицы
+type Case struct {
+	Cipher  string
+	Mode    core.CipherMode
+	Padding core.PaddingMode
+	Workers int // 0 — значение по умолчанию контекста
+	Size    int // размер открытого текста в байтах
+	API     API
+}
+
+// Matrix измерения матрицы. Пустое измерение заменяется значением по умолчанию.
+type Matrix struct {
+	Ciphers  []string
+	Modes    []core.CipherMode
+	Paddings []core.PaddingMode
+	Workers  []int
+	Sizes    []int
+	APIs     []API
+}
+
+// Cases разворачивает матрицу в список случаев
+func (m Matrix) Cases() []Case {
+	ciphers := m.Ciphers
+	if len(ciphers) == 0 {
+		ciphers = CipherNames()
+	}
+	modes := m.Modes
+	if len(modes) == 0 {
+		modes = []core.CipherMode{core.ECB, core.CBC, core.PCBC, core.CFB, core.OFB, core.CTR, core.RandomDelta}
+	}
+	paddings := m.Paddings
+	if len(paddings) == 0 {
+		paddings = []core.PaddingMode{core.PadPKCS7}
+	}
+	workers := m.Workers
+	if len(workers) == 0 {
+		workers = []int{0}
+	}
+	sizes := m.Sizes
+	if len(sizes) == 0 {
+		sizes = []int{1 << 10}
+	}
+	apis := m.APIs
+	if len(apis) == 0 {
+		apis = []API{APIMemory}
+	}
+
+	var cases []Case
+	for _, c := range ciphers {
+		for _, mode := range modes {
+			for _, padding := range paddings {
+				for _, w := range workers {
+					for _, size := range sizes {
+						for _, api := range apis {
+							cases = append(cases, Case{
+								Cipher:  c,
+								Mode:    mode,
+								Padding: padding,
+								Workers: w,
+								Size:    size,
+								API:     api,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// Result результат измерения одной операции одного случая
+type Result struct {
+	Cipher      string    `json:"cipher"`
+	Mode        string    `json:"mode"`
+	Padding     string    `json:"padding"`
+	Workers     int       `json:"workers"`
+	Size        int       `json:"size"`
+	API         API       `json:"api"`
+	Op          Operation `json:"op"`
+	Iterations  int       `json:"iterations"`
+	NsPerOp     int64     `json:"ns_per_op"`
+	MBPerSec    float64   `json:"mb_per_sec"`
+	AllocsPerOp int64     `json:"allocs_per_op"`
+	BytesPerOp  int64     `json:"bytes_per_op"`
+	Err         string    `json:"error,omitempty"`
+}
+
+// Key однозначно идентифицирует измерение при сравнении отчётов
+func (r Result) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%s/w%d/%d/%s", r.Cipher, r.API, r.Mode, r.Padding, r.Workers, r.Size, r.Op)
+}
+
+// Report полный отчёт прогона вместе с окружением
+type Report struct {
+	GoVersion string    `json:"go_version"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	NumCPU    int       `json:"num_cpu"`
+	Started   time.Time `json:"started"`
+	Results   []Result  `json:"results"`
+}
+
+// Options параметры прогона
+type Options struct {
+	MinTime  time.Duration // минимальное время на одну операцию; 0 — DefaultMinTime
+	TempDir  string        // каталог для временных файлов; "" — os.TempDir()
+	Progress func(Result)  // вызывается после каждого измерения, может быть nil
+}
+
+// Run прогоняет все случаи матрицы. Ошибка отдельного случая
+// записывается в Result.Err и не прерывает прогон.
+func Run(m Matrix, opts Options) (*Report, error) {
+	if opts.MinTime <= 0 {
+		opts.MinTime = DefaultMinTime
+	}
+
+	dir, err := os.MkdirTemp(opts.TempDir, "bench-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	report := &Report{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		Started:   time.Now().UTC(),
+	}
+	for i, c := range m.Cases() {
+		for _, r := range runCase(c, filepath.Join(dir, fmt.Sprintf("case%d", i)), opts.MinTime) {
+			report.Results = append(report.Results, r)
+			if opts.Progress != nil {
+				opts.Progress(r)
+			}
+		}
+	}
+	return report, nil
+}
+
+// runCase измеряет шифрование и расшифрование одного случая
+func runCase(c Case, prefix string, minTime time.Duration) []Result {
+	base := Result{
+		Cipher:  c.Cipher,
+		Mode:    c.Mode.String(),
+		Padding: c.Padding.String(),
+		Workers: c.Workers,
+		Size:    c.Size,
+		API:     c.API,
+	}
+	fail := func(err error) []Result {
+		enc, dec := base, base
+		enc.Op, dec.Op = OpEncrypt, OpDecrypt
+		enc.Err, dec.Err = err.Error(), err.Error()
+		return []Result{enc, dec}
+	}
+
+	ctx, err := newContext(c)
+	if err != nil {
+		return fail(err)
+	}
+	plaintext := make([]byte, c.Size)
+	if _, err := rand.Read(plaintext); err != nil {
+		return fail(err)
+	}
+
+	var encrypt, decrypt func() error
+	switch c.API {
+	case APIMemory:
+		ciphertext, err := ctx.Encrypt(plaintext)
+		if err != nil {
+			return fail(err)
+		}
+		encrypt = func() error {
+			_, err := ctx.Encrypt(plaintext)
+			return err
+		}
+		decrypt = func() error {
+			_, err := ctx.Decrypt(ciphertext)
+			return err
+		}
+	case APIFile:
+		in, enc, dec := prefix+".in", prefix+".enc", prefix+".dec"
+		if err := os.WriteFile(in, plaintext, 0644); err != nil {
+			return fail(err)
+		}
+		defer os.Remove(in)
+		defer os.Remove(enc)
+		defer os.Remove(dec)
+		if err := ctx.EncryptFile(in, enc); err != nil {
+			return fail(err)
+		}
+		encrypt = func() error { return ctx.EncryptFile(in, enc) }
+		decrypt = func() error { return ctx.DecryptFile(enc, dec) }
+	default:
+		return fail(fmt.Errorf("bench: unknown API %q", c.API))
+	}
+
+	enc := measure(base, OpEncrypt, encrypt, minTime)
+	dec := measure(base, OpDecrypt, decrypt, minTime)
+	return []Result{enc, dec}
+}
+
+// newContext создаёт шифр со случайным ключом и контекст для случая
+func newContext(c Case) (*core.CipherContext, error) {
+	keySize, blockSize, err := cipherSizes(c.Cipher)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, keySize)
+	iv := make([]byte, blockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	sc, err := NewCipher(c.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	ctx := core.NewCipherContext(sc, c.Mode, c.Padding, iv)
+	ctx.SetWorkers(c.Workers)
+	return ctx, nil
+}
+
+// measure выполняет op, удваивая число итераций, пока суммарное время
+// не превысит minTime, и считает аллокации по runtime.MemStats
+func measure(base Result, op Operation, fn func() error, minTime time.Duration) Result {
+	r := base
+	r.Op = op
+
+	// прогрев: первый вызов не учитывается
+	if err := fn(); err != nil {
+		r.Err = err.Error()
+		return r
+	}
+
+	var before, after runtime.MemStats
+	n := 1
+	for {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := fn(); err != nil {
+				r.Err = err.Error()
+				return r
+			}
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		if elapsed >= minTime || n >= 1<<30 {
+			r.Iterations = n
+			r.NsPerOp = elapsed.Nanoseconds() / int64(n)
+			r.AllocsPerOp = int64(after.Mallocs-before.Mallocs) / int64(n)
+			r.BytesPerOp = int64(after.TotalAlloc-before.TotalAlloc) / int64(n)
+			if r.NsPerOp > 0 {
+				r.MBPerSec = float64(r.Size) / float64(r.NsPerOp) * 1e3
+			}
+			return r
+		}
+
+		// оцениваем число итераций до minTime с запасом, как testing.B
+		next := n * 2
+		if elapsed > 0 {
+			predicted := int(int64(n) * minTime.Nanoseconds() * 6 / 5 / elapsed.Nanoseconds())
+			if predicted > next {
+				next = predicted
+			}
+			if next > 100*n {
+				next = 100 * n
+			}
+		}
+		n = next
+	}
+}
@@ -0,0 +1,197 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikitaKoros/cryptography/lab6/internal/crypto/core"
+)
+
+func TestMatrixCases(t *testing.T) {
+	m := Matrix{
+		Ciphers:  []string{"frog", "stdlib-aes-128"},
+		Modes:    []core.CipherMode{core.CBC, core.CTR},
+		Paddings: []core.PaddingMode{core.PadPKCS7, core.PadZeros},
+		Workers:  []int{1, 4},
+		Sizes:    []int{64, 128},
+		APIs:     []API{APIMemory, APIFile},
+	}
+	if got, want := len(m.Cases()), 2*2*2*2*2*2; got != want {
+		t.Errorf("len(Cases()) = %d, want %d", got, want)
+	}
+
+	if got, want := len((Matrix{}).Cases()), len(CipherNames())*7; got != want {
+		t.Errorf("default matrix has %d cases, want %d", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	m := Matrix{
+		Ciphers:  []string{"frog", "stdlib-aes-128"},
+		Modes:    []core.CipherMode{core.ECB, core.CBC, core.RandomDelta},
+		Paddings: []core.PaddingMode{core.PadPKCS7},
+		Workers:  []int{2},
+		Sizes:    []int{64},
+		APIs:     []API{APIMemory, APIFile},
+	}
+
+	progress := 0
+	report, err := Run(m, Options{
+		MinTime:  time.Millisecond,
+		TempDir:  t.TempDir(),
+		Progress: func(Result) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if want := len(m.Cases()) * 2; len(report.Results) != want || progress != want {
+		t.Fatalf("got %d results and %d progress calls, want %d", len(report.Results), progress, want)
+	}
+	for _, r := range report.Results {
+		if r.Err != "" {
+			t.Errorf("%s: %s", r.Key(), r.Err)
+			continue
+		}
+		if r.Iterations == 0 || r.NsPerOp <= 0 || r.MBPerSec <= 0 {
+			t.Errorf("%s: empty measurement %+v", r.Key(), r)
+		}
+		if r.AllocsPerOp <= 0 {
+			t.Errorf("%s: allocations were not counted", r.Key())
+		}
+	}
+}
+
+func TestRunUnknownCipher(t *testing.T) {
+	report, err := Run(Matrix{Ciphers: []string{"no-such-cipher"}, Modes: []core.CipherMode{core.ECB}},
+		Options{MinTime: time.Millisecond, TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, r := range report.Results {
+		if r.Err == "" {
+			t.Errorf("%s: expected error for unknown cipher", r.Key())
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	for name, d := range ciphers {
+		t.Run(name, func(t *testing.T) {
+			key := bytes.Repeat([]byte{0x5A}, d.keySize)
+			iv := bytes.Repeat([]byte{0x01}, d.blockSize)
+			c, err := NewCipher(name, key)
+			if err != nil {
+				t.Fatalf("NewCipher failed: %v", err)
+			}
+
+			ctx := core.NewCipherContext(c, core.CBC, core.PadPKCS7, iv)
+			plaintext := []byte("every cipher goes through CipherContext")
+			ciphertext, err := ctx.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			decrypted, err := ctx.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("round trip mismatch: got %q", decrypted)
+			}
+		})
+	}
+
+	if _, err := NewCipher("stdlib-aes-128", make([]byte, 5)); err == nil {
+		t.Error("expected error for invalid key size")
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	report := &Report{
+		GoVersion: "go1.x",
+		GOOS:      "linux",
+		GOARCH:    "amd64",
+		NumCPU:    4,
+		Started:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Results: []Result{
+			{Cipher: "frog", Mode: "CBC", Padding: "PadPKCS7", Size: 1024, API: APIMemory, Op: OpEncrypt,
+				Iterations: 10, NsPerOp: 1000, MBPerSec: 1024, AllocsPerOp: 3, BytesPerOp: 2048},
+			{Cipher: "frog", Mode: "CBC", Padding: "PadPKCS7", Size: 1024, API: APIFile, Op: OpDecrypt, Err: "boom"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	decoded, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if len(decoded.Results) != 2 || decoded.Results[0] != report.Results[0] || decoded.Results[1].Err != "boom" {
+		t.Errorf("JSON round trip mismatch: %+v", decoded.Results)
+	}
+
+	buf.Reset()
+	if err := WriteMarkdown(&buf, report); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	md := buf.String()
+	for _, want := range []string{
+		"| Cipher | API | Op |",
+		"| frog | memory | encrypt | CBC | PadPKCS7 | default | 1KiB | 1000 | 1024.00 | 3 | 2048 |",
+		"error: boom",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown does not contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	result := func(op Operation, ns int64) Result {
+		return Result{Cipher: "frog", Mode: "CTR", Padding: "PadZeros", Size: 64, API: APIMemory, Op: op, NsPerOp: ns}
+	}
+	base := &Report{Results: []Result{result(OpEncrypt, 1000), result(OpDecrypt, 1000)}}
+	current := &Report{Results: []Result{result(OpEncrypt, 1050), result(OpDecrypt, 1500)}}
+
+	regressions, err := Compare(base, current, 0.10)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(regressions) != 1 || regressions[0].Key != result(OpDecrypt, 0).Key() {
+		t.Fatalf("unexpected regressions: %+v", regressions)
+	}
+	if regressions[0].Slowdown != 0.5 {
+		t.Errorf("Slowdown = %v, want 0.5", regressions[0].Slowdown)
+	}
+
+	if _, err := Compare(base, &Report{}, 0.10); err != ErrNoResults {
+		t.Errorf("expected ErrNoResults, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"64KiB", 64 << 10},
+		{"4MiB", 4 << 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "KiB", "-1", "1GB"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q): expected error", bad)
+		}
+	}
+}
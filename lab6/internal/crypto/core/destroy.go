@@ -0,0 +1,61 @@
+package core
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrDestroyed возвращается при использовании шифра, контекста или ключа после Destroy
+var ErrDestroyed = errors.New("key material has been destroyed")
+
+// Destroyer реализуют шифры и контексты, умеющие стирать ключевой материал.
+// После Destroy раундовые ключи и буферы перезаписаны нулями, а операции
+// возвращают ErrDestroyed. Destroy нельзя вызывать параллельно с шифрованием.
+type Destroyer interface {
+	Destroy()
+}
+
+// Destroy стирает ключи шифра c, если он реализует Destroyer, и сообщает об этом
+func Destroy(c SymmetricCipher) bool {
+	d, ok := c.(Destroyer)
+	if ok {
+		d.Destroy()
+	}
+	return ok
+}
+
+// Wipe перезаписывает буферы нулями
+func Wipe(bufs ...[]byte) {
+	for _, b := range bufs {
+		clear(b)
+	}
+	// Не даём компилятору счесть запись в уже ненужные буферы мёртвой
+	runtime.KeepAlive(bufs)
+}
+
+// WipeKeys перезаписывает нулями набор раундовых ключей
+func WipeKeys(keys [][]byte) {
+	Wipe(keys...)
+}
+
+// Destroy стирает IV контекста и ключи шифра (если шифр реализует Destroyer).
+// IV, переданный в NewCipherContext, перезаписывается на месте. После вызова
+// все операции контекста возвращают ErrDestroyed.
+func (ctx *CipherContext) Destroy() {
+	Wipe(ctx.iv)
+	if ctx.cipher != nil {
+		Destroy(ctx.cipher)
+	}
+	ctx.destroyed = true
+}
+
+// checkUsable возвращает ошибку, если контекст нельзя использовать
+func (ctx *CipherContext) checkUsable() error {
+	if ctx.destroyed {
+		return ErrDestroyed
+	}
+	if ctx.cipher == nil {
+		return errors.New("cipher not set")
+	}
+	return nil
+}
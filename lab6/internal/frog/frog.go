@@ -2,6 +2,8 @@ package frog
 
 import (
 	"errors"
+
+	"github.com/NikitaKoros/cryptography/lab6/internal/crypto/core"
 )
 
 const (
@@ -46,9 +48,15 @@ type InternalKey struct {
 
 // FROG представляет шифр FROG
 type FROG struct {
-	key *InternalKey
+	key       *InternalKey
+	destroyed bool
 }
 
+var (
+	_ core.SymmetricCipher = (*FROG)(nil)
+	_ core.Destroyer       = (*FROG)(nil)
+)
+
 // New создает новый экземпляр FROG с заданным ключом
 func New(key []byte) (*FROG, error) {
 	if len(key) == 0 {
@@ -66,14 +74,31 @@ func New(key []byte) (*FROG, error) {
 
 // SetEncryptionKey устанавливает ключ для шифрования
 func (f *FROG) SetEncryptionKey(key []byte) error {
+	if f.destroyed {
+		return core.ErrDestroyed
+	}
 	internalKey, err := hashKey(key)
 	if err != nil {
 		return err
 	}
+	f.wipeKey()
 	f.key = internalKey
 	return nil
 }
 
+// Destroy затирает внутренний ключ; дальнейшие операции возвращают core.ErrDestroyed
+func (f *FROG) Destroy() {
+	f.wipeKey()
+	f.key = nil
+	f.destroyed = true
+}
+
+func (f *FROG) wipeKey() {
+	if f.key != nil {
+		*f.key = InternalKey{}
+	}
+}
+
 // SetDecryptionKey устанавливает ключ для дешифрования (для FROG это то же самое)
 func (f *FROG) SetDecryptionKey(key []byte) error {
 	return f.SetEncryptionKey(key)
@@ -90,6 +115,9 @@ func (f *FROG) EncryptBlock(plaintext []byte) ([]byte, error) {
 		return nil, errors.New("размер блока должен быть 16 байт")
 	}
 
+	if f.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if f.key == nil {
 		return nil, errors.New("ключ не установлен")
 	}
@@ -106,6 +134,9 @@ func (f *FROG) DecryptBlock(ciphertext []byte) ([]byte, error) {
 		return nil, errors.New("размер блока должен быть 16 байт")
 	}
 
+	if f.destroyed {
+		return nil, core.ErrDestroyed
+	}
 	if f.key == nil {
 		return nil, errors.New("ключ не установлен")
 	}
@@ -345,5 +376,12 @@ func hashKey(binaryKey []byte) (*InternalKey, error) {
 	copy(result.KeyE[:], keyE)
 	copy(result.KeyD[:], keyD)
 
+	// Промежуточные ключи больше не нужны
+	clear(simpleKey)
+	clear(internalKey)
+	clear(keyE)
+	clear(keyD)
+	core.Wipe(buffer)
+
 	return result, nil
 }
@@ -2,7 +2,10 @@ package frog
 
 import (
 	"bytes"
+	"errors"
 	"testing"
+
+	"github.com/NikitaKoros/cryptography/lab6/internal/crypto/core"
 )
 
 func TestFROGBasic(t *testing.T) {
@@ -121,6 +124,31 @@ func TestFROGEmptyKey(t *testing.T) {
 	}
 }
 
+func TestFROGDestroy(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	frog, err := New(key)
+	if err != nil {
+		t.Fatalf("Ошибка создания FROG: %v", err)
+	}
+	internal := frog.key
+
+	frog.Destroy()
+
+	if *internal != (InternalKey{}) {
+		t.Error("Внутренний ключ не затёрт после Destroy")
+	}
+	block := make([]byte, BlockSize)
+	if _, err := frog.EncryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("EncryptBlock после Destroy: ожидалась ErrDestroyed, получено %v", err)
+	}
+	if _, err := frog.DecryptBlock(block); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("DecryptBlock после Destroy: ожидалась ErrDestroyed, получено %v", err)
+	}
+	if err := frog.SetEncryptionKey(key); !errors.Is(err, core.ErrDestroyed) {
+		t.Errorf("SetEncryptionKey после Destroy: ожидалась ErrDestroyed, получено %v", err)
+	}
+}
+
 func BenchmarkFROGEncrypt(b *testing.B) {
 	key := make([]byte, 16)
 	plaintext := make([]byte, BlockSize)